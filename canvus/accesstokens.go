@@ -18,14 +18,17 @@ type CreateAccessTokenRequest struct {
 	Description string `json:"description"`
 }
 
-// ListAccessTokens retrieves all access tokens for a user from the Canvus API.
-func (s *Session) ListAccessTokens(ctx context.Context, userID int64) ([]AccessToken, error) {
+// ListAccessTokens retrieves all access tokens for a user from the Canvus API. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListAccessTokens(ctx context.Context, userID int64, filter *Filter) ([]AccessToken, error) {
 	var tokens []AccessToken
 	endpoint := fmt.Sprintf("users/%d/access-tokens", userID)
 	err := s.doRequest(ctx, "GET", endpoint, nil, &tokens, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListAccessTokens: %w", err)
 	}
+	if filter != nil {
+		tokens = FilterSlice(tokens, filter)
+	}
 	return tokens, nil
 }
 
@@ -5,14 +5,17 @@ import (
 	"fmt"
 )
 
-// ListAnchors retrieves all anchors for a given canvas.
-func (s *Session) ListAnchors(ctx context.Context, canvasID string) ([]Anchor, error) {
+// ListAnchors retrieves all anchors for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListAnchors(ctx context.Context, canvasID string, filter *Filter) ([]Anchor, error) {
 	var anchors []Anchor
 	path := fmt.Sprintf("canvases/%s/anchors", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &anchors, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListAnchors: %w", err)
 	}
+	if filter != nil {
+		anchors = FilterSlice(anchors, filter)
+	}
 	return anchors, nil
 }
 
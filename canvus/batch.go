@@ -17,6 +17,12 @@ const (
 	BatchOperationDelete BatchOperationType = "delete"
 	BatchOperationPin    BatchOperationType = "pin"
 	BatchOperationUnpin  BatchOperationType = "unpin"
+
+	BatchOperationCreate            BatchOperationType = "create"             // create a widget from a payload
+	BatchOperationUpdate            BatchOperationType = "update"             // patch any widget
+	BatchOperationReparent          BatchOperationType = "reparent"           // change a widget's parent
+	BatchOperationUpdateUser        BatchOperationType = "update_user"        // update a user
+	BatchOperationUpdatePermissions BatchOperationType = "update_permissions" // grant or revoke on a canvas or folder
 )
 
 // BatchOperation represents a single operation in a batch
@@ -26,19 +32,49 @@ type BatchOperation struct {
 	Resource interface{}            // The resource being operated on (Canvas, Widget, etc.)
 	Target   interface{}            // Target for move/copy operations (folder ID, canvas ID, etc.)
 	Metadata map[string]interface{} // Additional operation-specific data
+
+	// DependsOn lists operations that must succeed before this one starts. Operations referenced
+	// with BatchRef in Resource, Target or Metadata are added implicitly.
+	DependsOn []string
 }
 
 // BatchResult represents the result of a single batch operation
 type BatchResult struct {
 	OperationID string
+	ResourceID  string // ID of the resource created or acted on; what BatchRef resolves to
 	Success     bool
 	Error       error
 	StartTime   time.Time
 	EndTime     time.Time
 	Duration    time.Duration
 	Retries     int
+	Resumed     bool // completed by an earlier run and loaded from the journal
+	// Fingerprint identifies the operation by its type, resource and target. A journal stores it
+	// so that a rerun whose operation differs under the same ID is refused.
+	Fingerprint string
+
+	Inverse      *BatchOperation // undoes the operation; nil if there is nothing to undo or it was not recorded
+	Irreversible bool            // the operation succeeded but cannot be undone (delete, widget copy)
+	RolledBack   bool            // set by Rollback once Inverse has been applied
+}
+
+// BatchRollbackError is returned by ExecuteBatch when RollbackOnError rolled back a failed batch.
+// Err is the failure that stopped the batch and Rollback summarizes the compensating operations.
+type BatchRollbackError struct {
+	Err      error
+	Rollback *BatchSummary
+}
+
+func (e *BatchRollbackError) Error() string {
+	msg := fmt.Sprintf("%v; rolled back %d of %d operations", e.Err, e.Rollback.Successful, e.Rollback.TotalOperations)
+	if e.Rollback.Failed > 0 {
+		msg += fmt.Sprintf(" (%d failed)", e.Rollback.Failed)
+	}
+	return msg
 }
 
+func (e *BatchRollbackError) Unwrap() error { return e.Err }
+
 // BatchConfig holds configuration for batch operations
 type BatchConfig struct {
 	MaxConcurrency   int                                                // Maximum number of concurrent operations
@@ -47,6 +83,17 @@ type BatchConfig struct {
 	RetryDelay       time.Duration                                      // Delay between retry attempts
 	ContinueOnError  bool                                               // Continue processing if individual operations fail
 	ProgressCallback func(completed, total int, results []*BatchResult) // Optional progress callback
+	// RecordInverses makes every operation record the compensating operation that undoes it
+	// (BatchResult.Inverse) for Rollback. Pins, unpins, updates, reparents and user updates read
+	// the prior state first, which costs one extra request each.
+	RecordInverses bool
+	// RollbackOnError stops the batch at the first failure, as ContinueOnError false does, and
+	// rolls back the operations that succeeded. A timeout or cancellation rolls back the same
+	// way. It implies RecordInverses.
+	RollbackOnError bool
+	// Journal, if set, records every result and lets a rerun of the same batch skip the
+	// operations it already completed. Operations must then have unique, non-empty IDs.
+	Journal BatchJournal
 }
 
 // DefaultBatchConfig returns sensible defaults for batch operations
@@ -88,13 +135,74 @@ func NewBatchProcessor(session *Session, config *BatchConfig) *BatchProcessor {
 	}
 }
 
-// ExecuteBatch executes a batch of operations concurrently
+// ExecuteBatch executes a batch of operations concurrently. An operation waits for the
+// operations it depends on and is skipped with an error if one of them fails; unknown or
+// cyclic dependencies are rejected before anything runs. Unless ContinueOnError is set, the
+// first failure stops the batch: operations not yet started are skipped and an error is
+// returned (a *BatchRollbackError with RollbackOnError). With a Journal, operations that
+// succeeded in an earlier run are not executed again: their recorded results are returned
+// with Resumed set, and BatchRefs to them resolve to the recorded IDs.
 func (bp *BatchProcessor) ExecuteBatch(ctx context.Context, operations []*BatchOperation) ([]*BatchResult, error) {
+	return bp.executeBatch(ctx, operations, nil)
+}
+
+// ExecuteBatchStream is ExecuteBatch delivering each result on a channel as it completes,
+// including results resumed from the journal. An error for the batch as a whole (invalid
+// dependencies, cancellation, a journal failure) is sent last as a result with an empty
+// OperationID. The channel is closed when the batch finishes; it must be drained.
+//
+// Usage Example:
+//
+//	for r := range bp.ExecuteBatchStream(ctx, ops) {
+//		if r.Error != nil {
+//			log.Printf("%s: %v", r.OperationID, r.Error)
+//		}
+//	}
+func (bp *BatchProcessor) ExecuteBatchStream(ctx context.Context, operations []*BatchOperation) <-chan *BatchResult {
+	out := make(chan *BatchResult)
+	go func() {
+		defer close(out)
+		if _, err := bp.executeBatch(ctx, operations, func(r *BatchResult) { out <- r }); err != nil {
+			out <- &BatchResult{Error: err}
+		}
+	}()
+	return out
+}
+
+func (bp *BatchProcessor) executeBatch(ctx context.Context, operations []*BatchOperation, emit func(*BatchResult)) ([]*BatchResult, error) {
 	if len(operations) == 0 {
 		return []*BatchResult{}, nil
 	}
+	deps, err := batchDependencies(operations)
+	if err != nil {
+		return nil, fmt.Errorf("ExecuteBatch: %w", err)
+	}
+	var completed map[string]*BatchResult
+	if bp.config.Journal != nil {
+		for _, op := range operations {
+			if op.ID == "" {
+				return nil, fmt.Errorf("ExecuteBatch: operations need IDs to be journaled")
+			}
+		}
+		recorded, err := bp.config.Journal.Load()
+		if err != nil {
+			return nil, fmt.Errorf("ExecuteBatch: failed to load journal: %w", err)
+		}
+		completed = make(map[string]*BatchResult, len(recorded))
+		for _, r := range recorded {
+			if r.Success {
+				completed[r.OperationID] = r
+			}
+		}
+		for _, op := range operations {
+			if prev, ok := completed[op.ID]; ok && prev.Fingerprint != "" && prev.Fingerprint != batchFingerprint(op) {
+				return nil, fmt.Errorf("ExecuteBatch: operation %q does not match the journaled operation with that ID", op.ID)
+			}
+		}
+	}
 
 	// Create context with timeout
+	parent := ctx
 	if bp.config.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, bp.config.Timeout)
@@ -105,21 +213,49 @@ func (bp *BatchProcessor) ExecuteBatch(ctx context.Context, operations []*BatchO
 	results := make([]*BatchResult, len(operations))
 	resultsChan := make(chan *BatchResult, len(operations))
 
+	// stop is closed when a failure stops the batch
+	stop := make(chan struct{})
+	stopOnFailure := !bp.config.ContinueOnError || bp.config.RollbackOnError
+	record := bp.config.RecordInverses || bp.config.RollbackOnError
+
+	// done[i] is closed once results[i] is set
+	done := make([]chan struct{}, len(operations))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
 	// WaitGroup to wait for all operations to complete
 	var wg sync.WaitGroup
 
 	// Execute operations concurrently
 	for i, op := range operations {
+		if prev, ok := completed[op.ID]; ok {
+			resumed := *prev
+			resumed.Resumed = true
+			results[i] = &resumed
+			close(done[i])
+			resultsChan <- &resumed
+			continue
+		}
 		wg.Add(1)
 		go func(idx int, operation *BatchOperation) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			bp.sem <- struct{}{}
-			defer func() { <-bp.sem }()
-
-			result := bp.executeOperation(ctx, operation)
+			ids, result := awaitBatchDependencies(ctx, operation, operations, deps[idx], done, results, stop)
+			if result == nil {
+				// Acquire semaphore
+				bp.sem <- struct{}{}
+				select {
+				case <-stop:
+					result = skippedBatchResult(operation, errBatchStopped)
+				default:
+					result = bp.executeOperation(ctx, resolveBatchOperation(operation, ids), record)
+				}
+				<-bp.sem
+			}
+			result.Fingerprint = batchFingerprint(operation)
 			results[idx] = result
+			close(done[idx])
 			resultsChan <- result
 		}(i, op)
 	}
@@ -130,52 +266,120 @@ func (bp *BatchProcessor) ExecuteBatch(ctx context.Context, operations []*BatchO
 		close(resultsChan)
 	}()
 
-	// Collect results and call progress callback if provided
+	// Collect results, journal them and call progress callback if provided
 	var completedResults []*BatchResult
+	var journalErr, stopErr error
 	for result := range resultsChan {
 		completedResults = append(completedResults, result)
+		if stopOnFailure && !result.Success && stopErr == nil {
+			stopErr = fmt.Errorf("ExecuteBatch: stopped after operation %q failed: %w", result.OperationID, result.Error)
+			close(stop)
+		}
 
+		if bp.config.Journal != nil && !result.Resumed && journalErr == nil {
+			journalErr = bp.config.Journal.Record(result)
+		}
+		if emit != nil {
+			emit(result)
+		}
 		if bp.config.ProgressCallback != nil {
 			bp.config.ProgressCallback(len(completedResults), len(operations), completedResults)
 		}
 	}
 
 	// Check for overall timeout or cancellation
+	runErr := stopErr
 	if ctx.Err() != nil {
-		return results, fmt.Errorf("batch operation cancelled or timed out: %w", ctx.Err())
+		runErr = fmt.Errorf("batch operation cancelled or timed out: %w", ctx.Err())
+	} else if journalErr != nil {
+		return results, fmt.Errorf("ExecuteBatch: failed to write journal: %w", journalErr)
+	}
+	if runErr != nil {
+		if bp.config.RollbackOnError {
+			rollbackCtx, cancel := batchRollbackContext(parent, bp.config.Timeout)
+			defer cancel()
+			summary, _ := bp.Rollback(rollbackCtx, completedResults)
+			return results, &BatchRollbackError{Err: runErr, Rollback: summary}
+		}
+		return results, runErr
 	}
 
 	return results, nil
 }
 
-// executeOperation executes a single operation with retry logic
-func (bp *BatchProcessor) executeOperation(ctx context.Context, op *BatchOperation) *BatchResult {
+// batchRollbackContext returns the context a RollbackOnError rollback runs under. A cancelled
+// or timed-out caller context must not stop the undo, so it is detached and bounded by the
+// batch timeout instead.
+func batchRollbackContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if parent.Err() == nil {
+		return parent, func() {}
+	}
+	if timeout > 0 {
+		return context.WithTimeout(context.WithoutCancel(parent), timeout)
+	}
+	return context.WithoutCancel(parent), func() {}
+}
+
+// executeOperation executes a single operation with retry logic, recording its inverse if record is set
+func (bp *BatchProcessor) executeOperation(ctx context.Context, op *BatchOperation, record bool) *BatchResult {
 	result := &BatchResult{
 		OperationID: op.ID,
 		StartTime:   time.Now(),
 	}
 
+	var undo batchUndo
+	if record {
+		var err error
+		if undo, err = bp.captureUndo(ctx, op); err != nil {
+			result.Error = fmt.Errorf("failed to record rollback state: %w", err)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+	}
+
 	for attempt := 0; attempt <= bp.config.RetryAttempts; attempt++ {
 		result.Retries = attempt
 
 		var err error
+		var createdID string
+		var permRollback *PermissionRollback
 		switch op.Type {
 		case BatchOperationMove:
 			err = bp.executeMove(ctx, op)
 		case BatchOperationCopy:
-			err = bp.executeCopy(ctx, op)
+			createdID, err = bp.executeCopy(ctx, op)
 		case BatchOperationDelete:
 			err = bp.executeDelete(ctx, op)
 		case BatchOperationPin:
 			err = bp.executePin(ctx, op)
 		case BatchOperationUnpin:
 			err = bp.executeUnpin(ctx, op)
+		case BatchOperationCreate:
+			createdID, err = bp.executeCreate(ctx, op)
+		case BatchOperationUpdate:
+			err = bp.executeUpdate(ctx, op)
+		case BatchOperationReparent:
+			err = bp.executeReparent(ctx, op)
+		case BatchOperationUpdateUser:
+			err = bp.executeUpdateUser(ctx, op)
+		case BatchOperationUpdatePermissions:
+			permRollback, err = bp.executeUpdatePermissions(ctx, op)
+		case batchOperationRestorePermissions:
+			err = bp.executeRestorePermissions(ctx, op)
 		default:
 			err = fmt.Errorf("unsupported operation type: %s", op.Type)
 		}
 
 		if err == nil {
 			result.Success = true
+			result.ResourceID = createdID
+			if createdID == "" {
+				result.ResourceID = batchResourceID(op.Resource)
+			}
+			if undo != nil {
+				result.Inverse, result.Irreversible = undo(createdID, permRollback)
+			}
 			break
 		}
 
@@ -217,29 +421,48 @@ func (bp *BatchProcessor) executeMove(ctx context.Context, op *BatchOperation) e
 		}
 		return bp.session.MoveWidget(ctx, resource.ID, targetCanvasID)
 	default:
-		return fmt.Errorf("unsupported resource type for move operation")
+		widgetID, _, err := batchWidgetRef(op)
+		if err != nil {
+			return err
+		}
+		targetCanvasID, ok := op.Target.(string)
+		if !ok {
+			return fmt.Errorf("move target must be a canvas ID string")
+		}
+		return bp.session.MoveWidget(ctx, widgetID, targetCanvasID)
 	}
 }
 
-// executeCopy executes a copy operation
-func (bp *BatchProcessor) executeCopy(ctx context.Context, op *BatchOperation) error {
+// executeCopy executes a copy operation and returns the ID of a copied canvas
+func (bp *BatchProcessor) executeCopy(ctx context.Context, op *BatchOperation) (string, error) {
 	switch resource := op.Resource.(type) {
 	case *Canvas:
 		targetFolderID, ok := op.Target.(string)
 		if !ok {
-			return fmt.Errorf("copy target must be a folder ID string")
+			return "", fmt.Errorf("copy target must be a folder ID string")
 		}
 		req := MoveOrCopyCanvasRequest{FolderID: targetFolderID}
-		_, err := bp.session.CopyCanvas(ctx, resource.ID, req)
-		return err
+		copied, err := bp.session.CopyCanvas(ctx, resource.ID, req)
+		if err != nil {
+			return "", err
+		}
+		return copied.ID, nil
 	case *Widget:
 		targetCanvasID, ok := op.Target.(string)
 		if !ok {
-			return fmt.Errorf("copy target must be a canvas ID string")
+			return "", fmt.Errorf("copy target must be a canvas ID string")
 		}
-		return bp.session.CopyWidget(ctx, resource.ID, targetCanvasID)
+		return "", bp.session.CopyWidget(ctx, resource.ID, targetCanvasID)
 	default:
-		return fmt.Errorf("unsupported resource type for copy operation")
+		widgetID, _, err := batchWidgetRef(op)
+		if err != nil {
+			return "", err
+		}
+		targetCanvasID, ok := op.Target.(string)
+		if !ok {
+			return "", fmt.Errorf("copy target must be a canvas ID string")
+		}
+		return "", bp.session.CopyWidget(ctx, widgetID, targetCanvasID)
 	}
 }
 
@@ -248,38 +471,39 @@ func (bp *BatchProcessor) executeDelete(ctx context.Context, op *BatchOperation)
 	switch resource := op.Resource.(type) {
 	case *Canvas:
 		return bp.session.DeleteCanvas(ctx, resource.ID)
-	case *Widget:
-		// For delete operations, we need the canvas ID and widget type
-		// This information should be stored in the operation metadata
-		canvasID, hasCanvas := op.Metadata["canvas_id"].(string)
-		widgetType, hasType := op.Metadata["widget_type"].(string)
-		if !hasCanvas || !hasType {
-			return fmt.Errorf("delete operation requires canvas_id and widget_type in metadata")
-		}
-		return bp.session.DeleteWidget(ctx, canvasID, resource.ID, widgetType)
 	case *User:
 		return bp.session.DeleteUser(ctx, resource.ID)
 	default:
-		return fmt.Errorf("unsupported resource type for delete operation")
+		// Widgets need the canvas ID in the operation metadata, and a widget type from the
+		// resource or metadata
+		widgetID, widgetType, err := batchWidgetRef(op)
+		if err != nil {
+			return err
+		}
+		canvasID, hasCanvas := op.Metadata["canvas_id"].(string)
+		if !hasCanvas || widgetType == "" {
+			return fmt.Errorf("delete operation requires canvas_id in metadata and a widget type")
+		}
+		return bp.session.DeleteWidget(ctx, canvasID, widgetID, widgetType)
 	}
 }
 
 // executePin executes a pin operation
 func (bp *BatchProcessor) executePin(ctx context.Context, op *BatchOperation) error {
-	widget, ok := op.Resource.(*Widget)
-	if !ok {
-		return fmt.Errorf("pin operation only supports widgets")
+	widgetID, _, err := batchWidgetRef(op)
+	if err != nil {
+		return err
 	}
-	return bp.session.PinWidget(ctx, widget.ID)
+	return bp.session.PinWidget(ctx, widgetID)
 }
 
 // executeUnpin executes an unpin operation
 func (bp *BatchProcessor) executeUnpin(ctx context.Context, op *BatchOperation) error {
-	widget, ok := op.Resource.(*Widget)
-	if !ok {
-		return fmt.Errorf("unpin operation only supports widgets")
+	widgetID, _, err := batchWidgetRef(op)
+	if err != nil {
+		return err
 	}
-	return bp.session.UnpinWidget(ctx, widget.ID)
+	return bp.session.UnpinWidget(ctx, widgetID)
 }
 
 // BatchOperationBuilder helps build batch operations fluently
@@ -294,7 +518,7 @@ func NewBatchOperationBuilder() *BatchOperationBuilder {
 	}
 }
 
-// Move adds a move operation to the batch
+// Move adds a move operation to the batch. Use MoveWidget for widgets that may be rolled back.
 func (bob *BatchOperationBuilder) Move(id string, resource interface{}, targetFolderID string) *BatchOperationBuilder {
 	bob.operations = append(bob.operations, &BatchOperation{
 		ID:       id,
@@ -316,7 +540,7 @@ func (bob *BatchOperationBuilder) Copy(id string, resource interface{}, targetCa
 	return bob
 }
 
-// Delete adds a delete operation to the batch
+// Delete adds a delete operation to the batch. Widgets need their canvas; use DeleteWidget.
 func (bob *BatchOperationBuilder) Delete(id string, resource interface{}) *BatchOperationBuilder {
 	bob.operations = append(bob.operations, &BatchOperation{
 		ID:       id,
@@ -326,7 +550,7 @@ func (bob *BatchOperationBuilder) Delete(id string, resource interface{}) *Batch
 	return bob
 }
 
-// Pin adds a pin operation to the batch
+// Pin adds a pin operation to the batch. Use PinWidget if the batch records inverses.
 func (bob *BatchOperationBuilder) Pin(id string, widget *Widget) *BatchOperationBuilder {
 	bob.operations = append(bob.operations, &BatchOperation{
 		ID:       id,
@@ -336,7 +560,7 @@ func (bob *BatchOperationBuilder) Pin(id string, widget *Widget) *BatchOperation
 	return bob
 }
 
-// Unpin adds an unpin operation to the batch
+// Unpin adds an unpin operation to the batch. Use UnpinWidget if the batch records inverses.
 func (bob *BatchOperationBuilder) Unpin(id string, widget *Widget) *BatchOperationBuilder {
 	bob.operations = append(bob.operations, &BatchOperation{
 		ID:       id,
@@ -346,6 +570,113 @@ func (bob *BatchOperationBuilder) Unpin(id string, widget *Widget) *BatchOperati
 	return bob
 }
 
+// Create adds an operation that creates a widget on a canvas from a request payload (a map or
+// a struct with JSON tags) containing "widget_type". Use BatchRef in the payload to refer to
+// widgets created by earlier operations, e.g. a connector's "src" and "dst" IDs.
+func (bob *BatchOperationBuilder) Create(id, canvasID string, payload interface{}) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     BatchOperationCreate,
+		Resource: payload,
+		Target:   canvasID,
+	})
+	return bob
+}
+
+// Update adds an operation that patches a widget (colour, text, scale, location, ...). widget is
+// a *Widget, a typed widget such as *Note, or a BatchRef to a create operation; a plain widget ID
+// needs "widget_type" in patch.
+func (bob *BatchOperationBuilder) Update(id, canvasID string, widget interface{}, patch map[string]interface{}) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     BatchOperationUpdate,
+		Resource: widget,
+		Target:   patch,
+		Metadata: map[string]interface{}{"canvas_id": canvasID},
+	})
+	return bob
+}
+
+// Reparent adds an operation that moves a widget under another parent widget. widget is given as
+// for Update; parent is the parent widget's ID or a BatchRef.
+func (bob *BatchOperationBuilder) Reparent(id, canvasID string, widget interface{}, parent interface{}) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     BatchOperationReparent,
+		Resource: widget,
+		Target:   parent,
+		Metadata: map[string]interface{}{"canvas_id": canvasID},
+	})
+	return bob
+}
+
+// MoveWidget adds an operation that moves a widget from canvasID to targetCanvasID. Unlike
+// Move, it records the source canvas, which RecordInverses needs to move the widget back.
+// widget is given as for Update.
+func (bob *BatchOperationBuilder) MoveWidget(id, canvasID string, widget interface{}, targetCanvasID string) *BatchOperationBuilder {
+	return bob.addWidgetOperation(id, BatchOperationMove, canvasID, widget, targetCanvasID)
+}
+
+// DeleteWidget adds an operation that deletes a widget on canvasID. widget is given as for
+// Update.
+func (bob *BatchOperationBuilder) DeleteWidget(id, canvasID string, widget interface{}) *BatchOperationBuilder {
+	return bob.addWidgetOperation(id, BatchOperationDelete, canvasID, widget, nil)
+}
+
+// PinWidget adds an operation that pins a widget on canvasID. Unlike Pin, it records the canvas,
+// which RecordInverses needs to read the prior pinned state. widget is given as for Update.
+func (bob *BatchOperationBuilder) PinWidget(id, canvasID string, widget interface{}) *BatchOperationBuilder {
+	return bob.addWidgetOperation(id, BatchOperationPin, canvasID, widget, nil)
+}
+
+// UnpinWidget adds an operation that unpins a widget on canvasID; see PinWidget.
+func (bob *BatchOperationBuilder) UnpinWidget(id, canvasID string, widget interface{}) *BatchOperationBuilder {
+	return bob.addWidgetOperation(id, BatchOperationUnpin, canvasID, widget, nil)
+}
+
+func (bob *BatchOperationBuilder) addWidgetOperation(id string, opType BatchOperationType, canvasID string, widget, target interface{}) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     opType,
+		Resource: widget,
+		Target:   target,
+		Metadata: map[string]interface{}{"canvas_id": canvasID},
+	})
+	return bob
+}
+
+// UpdateUser adds an operation that updates a user (a *User or user ID) with req, such as an
+// UpdateUserRequest.
+func (bob *BatchOperationBuilder) UpdateUser(id string, user interface{}, req interface{}) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     BatchOperationUpdateUser,
+		Resource: user,
+		Target:   req,
+	})
+	return bob
+}
+
+// UpdatePermissions adds an operation that applies a grant or revoke to one canvas or folder,
+// with the same read-modify-write as BulkUpdatePermissions.
+func (bob *BatchOperationBuilder) UpdatePermissions(id string, target PermissionTarget, change BulkPermissionChange) *BatchOperationBuilder {
+	bob.operations = append(bob.operations, &BatchOperation{
+		ID:       id,
+		Type:     BatchOperationUpdatePermissions,
+		Resource: target,
+		Target:   change,
+	})
+	return bob
+}
+
+// After makes the most recently added operation depend on the given operations.
+func (bob *BatchOperationBuilder) After(ids ...string) *BatchOperationBuilder {
+	if n := len(bob.operations); n > 0 {
+		bob.operations[n-1].DependsOn = append(bob.operations[n-1].DependsOn, ids...)
+	}
+	return bob
+}
+
 // Build returns the built batch operations
 func (bob *BatchOperationBuilder) Build() []*BatchOperation {
 	return bob.operations
@@ -1,6 +1,8 @@
 package canvus
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,3 +95,96 @@ func TestBatchProcessor(t *testing.T) {
 		assert.NotNil(t, defaultConfig)
 	})
 }
+
+func TestBatchDependenciesAndGenericOperations(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n0", "text": "old", "background_color": "FFFFFFFF"})
+	fake.seed("users", map[string]interface{}{"id": float64(7), "email": "u@example.com", "name": "U"})
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 4, RetryAttempts: 0})
+
+	name := "Renamed"
+	ops := NewBatchOperationBuilder().
+		Create("conn", "c1", map[string]interface{}{
+			"widget_type": "connector",
+			"src":         map[string]interface{}{"id": BatchRef("a"), "tip": tipNone},
+			"dst":         map[string]interface{}{"id": BatchRef("b"), "tip": tipArrow},
+		}).
+		Create("a", "c1", map[string]interface{}{"widget_type": "Note", "text": "A"}).
+		Create("b", "c1", map[string]interface{}{"widget_type": "note", "text": "B"}).
+		Update("recolor", "c1", &Note{ID: "n0", WidgetType: "Note"}, map[string]interface{}{"background_color": "FFCC00FF", "scale": 2.0}).
+		Reparent("nest", "c1", BatchRef("b"), "n0").After("recolor").
+		UpdateUser("user", int64(7), UpdateUserRequest{Name: &name}).
+		Create("price", "c1", map[string]interface{}{"widget_type": "note", "text": "${PRICE}"}).
+		Build()
+
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Fatalf("%s failed: %v", r.OperationID, r.Error)
+		}
+	}
+	conns := fake.items("canvases/c1/connectors")
+	if len(conns) != 1 {
+		t.Fatalf("connectors = %v", conns)
+	}
+	src := conns[0]["src"].(map[string]interface{})["id"]
+	dst := conns[0]["dst"].(map[string]interface{})["id"]
+	if src != results[1].ResourceID || dst != results[2].ResourceID || src == "" {
+		t.Errorf("connector ends %v -> %v, want %s -> %s", src, dst, results[1].ResourceID, results[2].ResourceID)
+	}
+	notes := fake.items("canvases/c1/notes")
+	if notes[0]["background_color"] != "FFCC00FF" || notes[0]["scale"] != 2.0 {
+		t.Errorf("update not applied: %v", notes[0])
+	}
+	for _, n := range notes {
+		if n["id"] == results[2].ResourceID && n["parent_id"] != "n0" {
+			t.Errorf("reparent not applied: %v", n)
+		}
+	}
+	if u := fake.items("users")[0]; u["name"] != "Renamed" {
+		t.Errorf("user = %v", u)
+	}
+	// Placeholder-like text is content, not a reference.
+	found := false
+	for _, n := range notes {
+		found = found || (n["id"] == results[6].ResourceID && n["text"] == "${PRICE}")
+	}
+	if !found {
+		t.Errorf("note with placeholder-like text not created as is: %v", notes)
+	}
+}
+
+func TestBatchDependencyFailureSkipsDependents(t *testing.T) {
+	fake := newFakeCanvus(t)
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 2, ContinueOnError: true})
+	ops := NewBatchOperationBuilder().
+		Update("missing", "c1", &Note{ID: "nope", WidgetType: "Note"}, map[string]interface{}{"text": "x"}).
+		Create("after", "c1", map[string]interface{}{"widget_type": "note", "text": "y"}).After("missing").
+		Build()
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Success || results[1].Success {
+		t.Fatalf("results = %+v %+v", results[0], results[1])
+	}
+	if !strings.Contains(results[1].Error.Error(), `dependency "missing" failed`) {
+		t.Errorf("dependent error = %v", results[1].Error)
+	}
+	if n := fake.callCount("POST canvases/c1/notes"); n != 0 {
+		t.Errorf("dependent operation ran %d times", n)
+	}
+
+	for _, bad := range [][]*BatchOperation{
+		{{ID: "x", Type: BatchOperationPin, Resource: "w", DependsOn: []string{"y"}}},
+		{{ID: "x", Type: BatchOperationPin, Resource: BatchRef("y")}, {ID: "y", Type: BatchOperationPin, Resource: BatchRef("x")}},
+		{{ID: "x", Type: BatchOperationPin, Resource: "w"}, {ID: "x", Type: BatchOperationUnpin, Resource: "w"}},
+	} {
+		if _, err := bp.ExecuteBatch(context.Background(), bad); err == nil {
+			t.Errorf("expected an error for %+v", bad[0])
+		}
+	}
+}
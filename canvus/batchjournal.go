@@ -0,0 +1,213 @@
+package canvus
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchJournal persists batch results so that an interrupted batch can be resumed. Load
+// returns the recorded results, one per operation (the latest wins), and Record appends one.
+// Set it as BatchConfig.Journal.
+type BatchJournal interface {
+	Load() ([]*BatchResult, error)
+	Record(result *BatchResult) error
+}
+
+// batchJournalEntry is one JSONL line of a FileBatchJournal.
+type batchJournalEntry struct {
+	OperationID string    `json:"operation_id"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	ResourceID  string    `json:"resource_id,omitempty"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	DurationMS  int64     `json:"duration_ms"`
+	Retries     int       `json:"retries,omitempty"`
+}
+
+// FileBatchJournal is a BatchJournal stored as a JSON Lines file, one result per line.
+// A line cut short by a crash is ignored when the journal is loaded and removed when it is
+// opened again.
+type FileBatchJournal struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenBatchJournal opens the journal file at path, creating it if needed, and drops a final
+// line left incomplete by a crash so that new entries start on a line of their own. Close it
+// when the batch is done.
+//
+// Usage Example:
+//
+//	journal, err := canvus.OpenBatchJournal("migration.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//	defer journal.Close()
+//	config := canvus.DefaultBatchConfig()
+//	config.Journal = journal
+//	results, err := canvus.NewBatchProcessor(session, config).ExecuteBatch(ctx, ops) // rerun to resume
+func OpenBatchJournal(path string) (*FileBatchJournal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("OpenBatchJournal: %w", err)
+	}
+	if err := trimBatchJournal(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("OpenBatchJournal: %w", err)
+	}
+	return &FileBatchJournal{path: path, f: f}, nil
+}
+
+// trimBatchJournal truncates f after its last newline and leaves the offset at the end.
+func trimBatchJournal(f *os.File) error {
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return err
+	}
+	size := int64(bytes.LastIndexByte(data, '\n') + 1)
+	if size != int64(len(data)) {
+		if err := f.Truncate(size); err != nil {
+			return err
+		}
+	}
+	_, err = f.Seek(size, io.SeekStart)
+	return err
+}
+
+// Load reads the latest recorded result of each operation, in the order the operations were
+// first recorded.
+func (j *FileBatchJournal) Load() ([]*BatchResult, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("FileBatchJournal.Load: %w", err)
+	}
+	defer f.Close()
+
+	var order []string
+	latest := map[string]*BatchResult{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e batchJournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.OperationID == "" {
+			// Only the final line can be incomplete; anything earlier is corruption.
+			if sc.Scan() {
+				return nil, fmt.Errorf("FileBatchJournal.Load: %s line %d is not a journal entry", j.path, line)
+			}
+			break
+		}
+		if _, seen := latest[e.OperationID]; !seen {
+			order = append(order, e.OperationID)
+		}
+		latest[e.OperationID] = e.result()
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("FileBatchJournal.Load: %w", err)
+	}
+	out := make([]*BatchResult, len(order))
+	for i, id := range order {
+		out[i] = latest[id]
+	}
+	return out, nil
+}
+
+// Record appends a result to the journal.
+func (j *FileBatchJournal) Record(result *BatchResult) error {
+	e := batchJournalEntry{
+		OperationID: result.OperationID,
+		Fingerprint: result.Fingerprint,
+		ResourceID:  result.ResourceID,
+		Success:     result.Success,
+		StartTime:   result.StartTime,
+		EndTime:     result.EndTime,
+		DurationMS:  result.Duration.Milliseconds(),
+		Retries:     result.Retries,
+	}
+	if result.Error != nil {
+		e.Error = result.Error.Error()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("FileBatchJournal.Record: %w", err)
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return fmt.Errorf("FileBatchJournal.Record: journal is closed")
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("FileBatchJournal.Record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the journal file.
+func (j *FileBatchJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+func (e batchJournalEntry) result() *BatchResult {
+	r := &BatchResult{
+		OperationID: e.OperationID,
+		Fingerprint: e.Fingerprint,
+		ResourceID:  e.ResourceID,
+		Success:     e.Success,
+		StartTime:   e.StartTime,
+		EndTime:     e.EndTime,
+		Duration:    time.Duration(e.DurationMS) * time.Millisecond,
+		Retries:     e.Retries,
+	}
+	if e.Error != "" {
+		r.Error = errors.New(e.Error)
+	}
+	return r
+}
+
+// batchFingerprint identifies an operation by its type, resource and target. Resources with an
+// ID (widgets, users, permission targets) count by their ID, so a widget whose other fields
+// changed since the first run still matches.
+func batchFingerprint(op *BatchOperation) string {
+	var resource interface{} = op.Resource
+	if id := batchResourceID(op.Resource); id != "" {
+		resource = id
+	}
+	data, err := json.Marshal([]interface{}{op.Type, resource, op.Target})
+	if err != nil {
+		data = []byte(fmt.Sprintf("%s %v %v", op.Type, resource, op.Target))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SummarizeJournal summarizes the latest recorded result of every operation in a journal.
+func SummarizeJournal(journal BatchJournal) (*BatchSummary, error) {
+	results, err := journal.Load()
+	if err != nil {
+		return nil, fmt.Errorf("SummarizeJournal: %w", err)
+	}
+	return Summarize(results), nil
+}
@@ -0,0 +1,112 @@
+package canvus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchJournalResume(t *testing.T) {
+	fake := newFakeCanvus(t)
+	path := filepath.Join(t.TempDir(), "batch.jsonl")
+	journal, err := OpenBatchJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 2, ContinueOnError: true, Journal: journal})
+
+	ops := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		Update("fix", "c1", &Note{ID: "n9", WidgetType: "Note"}, map[string]interface{}{"text": "fixed"}).
+		Reparent("nest", "c1", BatchRef("a"), "n9").After("fix").
+		Build()
+
+	first, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first[0].Success || first[1].Success || first[2].Success {
+		t.Fatalf("first run = %+v %+v %+v", first[0], first[1], first[2])
+	}
+
+	// The missing note appears; rerunning the batch only executes what did not succeed.
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n9", "text": "broken"})
+	var streamed []*BatchResult
+	for r := range bp.ExecuteBatchStream(context.Background(), ops) {
+		streamed = append(streamed, r)
+	}
+	if len(streamed) != 3 {
+		t.Fatalf("streamed %d results", len(streamed))
+	}
+	byID := map[string]*BatchResult{}
+	for _, r := range streamed {
+		if r.OperationID == "" {
+			t.Fatalf("batch error: %v", r.Error)
+		}
+		byID[r.OperationID] = r
+	}
+	if !byID["a"].Resumed || byID["a"].ResourceID != first[0].ResourceID {
+		t.Errorf("a = %+v, want resumed %s", byID["a"], first[0].ResourceID)
+	}
+	if !byID["fix"].Success || byID["fix"].Resumed || !byID["nest"].Success {
+		t.Errorf("fix = %+v, nest = %+v", byID["fix"], byID["nest"])
+	}
+	if n := fake.callCount("POST canvases/c1/notes"); n != 1 {
+		t.Errorf("note created %d times", n)
+	}
+	for _, n := range fake.items("canvases/c1/notes") {
+		if n["id"] == first[0].ResourceID && n["parent_id"] != "n9" {
+			t.Errorf("resumed reference not resolved: %v", n)
+		}
+	}
+
+	summary, err := SummarizeJournal(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalOperations != 3 || summary.Successful != 3 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// A line cut short by a crash is ignored.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"operation_id":"late","succ`)
+	f.Close()
+	if results, err := journal.Load(); err != nil || len(results) != 3 {
+		t.Errorf("Load after truncated line = %d results, %v", len(results), err)
+	}
+
+	// Reopening drops the partial line, so later entries are not merged into it.
+	journal.Close()
+	journal, err = OpenBatchJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	if err := journal.Record(&BatchResult{OperationID: "late", Success: true}); err != nil {
+		t.Fatal(err)
+	}
+	if results, err := journal.Load(); err != nil || len(results) != 4 || results[3].OperationID != "late" {
+		t.Errorf("Load after reopening = %+v, %v", results, err)
+	}
+
+	// An operation that differs from the journaled one under the same ID is not resumed.
+	changed := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "Other"}).
+		Build()
+	bp = NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 2, ContinueOnError: true, Journal: journal})
+	if _, err := bp.ExecuteBatch(context.Background(), changed); err == nil {
+		t.Error("expected an error for an operation that does not match the journal")
+	}
+	if n := fake.callCount("POST canvases/c1/notes"); n != 1 {
+		t.Errorf("note created %d times", n)
+	}
+
+	if _, err := bp.ExecuteBatch(context.Background(), []*BatchOperation{{Type: BatchOperationPin, Resource: "w"}}); err == nil {
+		t.Error("expected an error for an operation without ID")
+	}
+}
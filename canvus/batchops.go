@@ -0,0 +1,380 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BatchRefValue is a placeholder for the ResourceID of another operation in the same batch,
+// created with BatchRef. Strings are never treated as references, so payload text such as
+// "${PRICE}" is sent as is.
+type BatchRefValue struct {
+	Op string // ID of the referenced operation
+}
+
+// BatchRef returns a placeholder for the ResourceID of another operation in the same batch,
+// such as the ID of a note created earlier. It may appear as a value anywhere in an
+// operation's Resource, Target or Metadata (including nested maps and []interface{} slices)
+// and makes the operation depend on the referenced one.
+//
+// Usage Example:
+//
+//	ops := canvus.NewBatchOperationBuilder().
+//		Create("a", canvasID, map[string]interface{}{"widget_type": "note", "text": "Idea"}).
+//		Create("b", canvasID, map[string]interface{}{"widget_type": "note", "text": "Plan"}).
+//		Create("ab", canvasID, map[string]interface{}{
+//			"widget_type": "connector",
+//			"src":         map[string]interface{}{"id": canvus.BatchRef("a")},
+//			"dst":         map[string]interface{}{"id": canvus.BatchRef("b")},
+//		}).
+//		Build()
+//	results, err := canvus.NewBatchProcessor(session, nil).ExecuteBatch(ctx, ops)
+func BatchRef(operationID string) BatchRefValue {
+	return BatchRefValue{Op: operationID}
+}
+
+// collectBatchRefs appends the operation IDs referenced anywhere in v.
+func collectBatchRefs(v interface{}, refs []string) []string {
+	switch x := v.(type) {
+	case BatchRefValue:
+		refs = append(refs, x.Op)
+	case map[string]interface{}:
+		for _, e := range x {
+			refs = collectBatchRefs(e, refs)
+		}
+	case []interface{}:
+		for _, e := range x {
+			refs = collectBatchRefs(e, refs)
+		}
+	}
+	return refs
+}
+
+// batchRefTarget is what a BatchRef resolves to.
+type batchRefTarget struct {
+	id         string
+	widgetType string // lower case; empty if unknown
+}
+
+// resolveBatchRefs returns a copy of v with BatchRef placeholders replaced by resource IDs.
+func resolveBatchRefs(v interface{}, refs map[string]batchRefTarget) interface{} {
+	switch x := v.(type) {
+	case BatchRefValue:
+		if t, ok := refs[x.Op]; ok {
+			return t.id
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			out[k] = resolveBatchRefs(e, refs)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = resolveBatchRefs(e, refs)
+		}
+		return out
+	}
+	return v
+}
+
+// resolveBatchOperation returns op with its BatchRefs resolved. A widget given as a BatchRef
+// takes its widget type from the referenced operation unless Metadata sets one.
+func resolveBatchOperation(op *BatchOperation, refs map[string]batchRefTarget) *BatchOperation {
+	if len(refs) == 0 {
+		return op
+	}
+	out := *op
+	out.Resource = resolveBatchRefs(op.Resource, refs)
+	out.Target = resolveBatchRefs(op.Target, refs)
+	if op.Metadata != nil {
+		out.Metadata = resolveBatchRefs(op.Metadata, refs).(map[string]interface{})
+	}
+	if ref, ok := op.Resource.(BatchRefValue); ok && refs[ref.Op].widgetType != "" {
+		if _, set := out.Metadata["widget_type"]; !set {
+			out.Metadata = copyFields(out.Metadata)
+			out.Metadata["widget_type"] = refs[ref.Op].widgetType
+		}
+	}
+	return &out
+}
+
+// batchDependencies returns the indices each operation depends on, from DependsOn and BatchRefs,
+// and rejects duplicate IDs, unknown operations and cycles.
+func batchDependencies(operations []*BatchOperation) ([][]int, error) {
+	index := make(map[string]int, len(operations))
+	for i, op := range operations {
+		if op.ID == "" {
+			continue
+		}
+		if _, dup := index[op.ID]; dup {
+			return nil, fmt.Errorf("duplicate operation ID %q", op.ID)
+		}
+		index[op.ID] = i
+	}
+	deps := make([][]int, len(operations))
+	for i, op := range operations {
+		names := append([]string(nil), op.DependsOn...)
+		names = collectBatchRefs(op.Resource, names)
+		names = collectBatchRefs(op.Target, names)
+		names = collectBatchRefs(op.Metadata, names)
+		seen := map[int]bool{}
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("operation %q depends on unknown operation %q", op.ID, name)
+			}
+			if !seen[j] {
+				seen[j] = true
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	// Depth-first search for cycles: 1 = on the current path, 2 = finished.
+	state := make([]int, len(operations))
+	var visit func(i int) error
+	visit = func(i int) error {
+		state[i] = 1
+		for _, j := range deps[i] {
+			switch state[j] {
+			case 1:
+				return fmt.Errorf("dependency cycle between operations %q and %q", operations[i].ID, operations[j].ID)
+			case 0:
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range operations {
+		if state[i] == 0 {
+			if err := visit(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return deps, nil
+}
+
+// awaitBatchDependencies waits for the operations op depends on. It returns what their
+// BatchRefs resolve to, or a failed result if a dependency failed or ctx ended first.
+func awaitBatchDependencies(ctx context.Context, op *BatchOperation, operations []*BatchOperation, deps []int, done []chan struct{}, results []*BatchResult, stop <-chan struct{}) (map[string]batchRefTarget, *BatchResult) {
+	if len(deps) == 0 {
+		return nil, nil
+	}
+	refs := make(map[string]batchRefTarget, len(deps))
+	for _, j := range deps {
+		select {
+		case <-done[j]:
+		case <-stop:
+			return nil, skippedBatchResult(op, errBatchStopped)
+		case <-ctx.Done():
+			return nil, skippedBatchResult(op, ctx.Err())
+		}
+		if !results[j].Success {
+			return nil, skippedBatchResult(op, fmt.Errorf("dependency %q failed", operations[j].ID))
+		}
+		refs[operations[j].ID] = batchRefTarget{id: results[j].ResourceID, widgetType: batchWidgetType(operations[j])}
+	}
+	return refs, nil
+}
+
+// errBatchStopped is the error of operations skipped because a failure stopped the batch.
+var errBatchStopped = errors.New("skipped: batch stopped after a failure")
+
+func skippedBatchResult(op *BatchOperation, err error) *BatchResult {
+	return &BatchResult{OperationID: op.ID, Error: err}
+}
+
+// batchWidgetType returns the lower-case widget type an operation creates or acts on, if known.
+func batchWidgetType(op *BatchOperation) string {
+	var t string
+	switch r := op.Resource.(type) {
+	case map[string]interface{}:
+		t, _ = r["widget_type"].(string)
+	case Filterable:
+		t, _ = r.AsMap()["widget_type"].(string)
+	}
+	if t == "" {
+		t, _ = op.Metadata["widget_type"].(string)
+	}
+	return strings.ToLower(t)
+}
+
+// batchWidgetRef returns the ID and lower-case type of the widget an operation acts on: a
+// *Widget, any typed widget such as Note or *Browser, or a widget ID with its type in
+// Metadata["widget_type"].
+func batchWidgetRef(op *BatchOperation) (id, widgetType string, err error) {
+	switch r := op.Resource.(type) {
+	case string:
+		id = r
+	case Filterable:
+		id, _ = r.AsMap()["id"].(string)
+	}
+	if id == "" {
+		return "", "", fmt.Errorf("%s operation requires a widget or widget ID, got %T", op.Type, op.Resource)
+	}
+	return id, batchWidgetType(op), nil
+}
+
+// batchResourceID returns the ID of an operation's resource for BatchResult.ResourceID.
+func batchResourceID(resource interface{}) string {
+	switch r := resource.(type) {
+	case string:
+		return r
+	case int64:
+		return strconv.FormatInt(r, 10)
+	case int:
+		return strconv.Itoa(r)
+	case PermissionTarget:
+		return r.ID
+	case *PermissionTarget:
+		return r.ID
+	case Filterable:
+		if id, ok := r.AsMap()["id"]; ok && id != nil {
+			return fmt.Sprint(id)
+		}
+	}
+	return ""
+}
+
+// batchPayload returns a request payload as a new map; structs are converted through their
+// JSON encoding.
+func batchPayload(v interface{}) (map[string]interface{}, error) {
+	switch p := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("missing payload")
+	case map[string]interface{}:
+		return copyFields(p), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("payload %T is not an object", v)
+	}
+	return m, nil
+}
+
+// executeCreate executes a create operation and returns the new widget's ID.
+func (bp *BatchProcessor) executeCreate(ctx context.Context, op *BatchOperation) (string, error) {
+	canvasID, ok := op.Target.(string)
+	if !ok || canvasID == "" {
+		return "", fmt.Errorf("create target must be a canvas ID string")
+	}
+	req, err := batchPayload(op.Resource)
+	if err != nil {
+		return "", err
+	}
+	widgetType, _ := req["widget_type"].(string)
+	if widgetType == "" {
+		return "", fmt.Errorf("create payload requires widget_type")
+	}
+	widget, err := bp.session.CreateWidget(ctx, canvasID, withWidgetType(req, strings.ToLower(widgetType)))
+	if err != nil {
+		return "", err
+	}
+	return widget.ID, nil
+}
+
+// executeUpdate executes an update operation; Target is the patch.
+func (bp *BatchProcessor) executeUpdate(ctx context.Context, op *BatchOperation) error {
+	patch, err := batchPayload(op.Target)
+	if err != nil {
+		return err
+	}
+	return bp.patchWidget(ctx, op, patch)
+}
+
+// executeReparent executes a reparent operation; Target is the new parent ID.
+func (bp *BatchProcessor) executeReparent(ctx context.Context, op *BatchOperation) error {
+	parentID, ok := op.Target.(string)
+	if !ok || parentID == "" {
+		return fmt.Errorf("reparent target must be a parent widget ID string")
+	}
+	return bp.patchWidget(ctx, op, map[string]interface{}{"parent_id": parentID})
+}
+
+func (bp *BatchProcessor) patchWidget(ctx context.Context, op *BatchOperation, patch map[string]interface{}) error {
+	widgetID, widgetType, err := batchWidgetRef(op)
+	if err != nil {
+		return err
+	}
+	if widgetType == "" {
+		t, _ := patch["widget_type"].(string)
+		widgetType = strings.ToLower(t)
+	}
+	canvasID, _ := op.Metadata["canvas_id"].(string)
+	if canvasID == "" || widgetType == "" {
+		return fmt.Errorf("%s operation requires canvas_id in metadata and a widget type", op.Type)
+	}
+	_, err = bp.session.UpdateWidget(ctx, canvasID, widgetID, withWidgetType(patch, widgetType))
+	return err
+}
+
+// batchUserID returns the ID of the user an operation acts on: a *User, User or user ID.
+func batchUserID(op *BatchOperation) (int64, error) {
+	switch r := op.Resource.(type) {
+	case *User:
+		return r.ID, nil
+	case User:
+		return r.ID, nil
+	case int64:
+		return r, nil
+	case int:
+		return int64(r), nil
+	}
+	return 0, fmt.Errorf("%s operation requires a user or user ID, got %T", op.Type, op.Resource)
+}
+
+// executeUpdateUser executes a user update; Resource is a *User, User or user ID.
+func (bp *BatchProcessor) executeUpdateUser(ctx context.Context, op *BatchOperation) error {
+	userID, err := batchUserID(op)
+	if err != nil {
+		return err
+	}
+	if op.Target == nil {
+		return fmt.Errorf("update_user operation requires a request")
+	}
+	_, err = bp.session.UpdateUser(ctx, userID, op.Target)
+	return err
+}
+
+// executeUpdatePermissions applies a BulkPermissionChange to one PermissionTarget and returns
+// the rollback record of the previous overrides.
+func (bp *BatchProcessor) executeUpdatePermissions(ctx context.Context, op *BatchOperation) (*PermissionRollback, error) {
+	var target PermissionTarget
+	switch r := op.Resource.(type) {
+	case PermissionTarget:
+		target = r
+	case *PermissionTarget:
+		target = *r
+	default:
+		return nil, fmt.Errorf("update_permissions operation requires a PermissionTarget, got %T", op.Resource)
+	}
+	var change BulkPermissionChange
+	switch t := op.Target.(type) {
+	case BulkPermissionChange:
+		change = t
+	case *BulkPermissionChange:
+		change = *t
+	default:
+		return nil, fmt.Errorf("update_permissions target must be a BulkPermissionChange, got %T", op.Target)
+	}
+	change.ContinueOnError = false
+	res, err := bp.session.BulkUpdatePermissions(ctx, []PermissionTarget{target}, change)
+	if err != nil {
+		return nil, err
+	}
+	return res.Rollback, nil
+}
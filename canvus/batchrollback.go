@@ -0,0 +1,244 @@
+package canvus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// batchOperationRestorePermissions is the inverse of BatchOperationUpdatePermissions: it
+// applies the *PermissionRollback in Resource.
+const batchOperationRestorePermissions BatchOperationType = "restore_permissions"
+
+// batchUndo builds the inverse of an operation once it has succeeded, from the ID the operation
+// created and, for permission changes, the record of the previous overrides.
+type batchUndo func(createdID string, perms *PermissionRollback) (inverse *BatchOperation, irreversible bool)
+
+func fixedBatchUndo(inverse *BatchOperation) batchUndo {
+	return func(string, *PermissionRollback) (*BatchOperation, bool) { return inverse, false }
+}
+
+func irreversibleBatchUndo(string, *PermissionRollback) (*BatchOperation, bool) { return nil, true }
+
+// captureUndo records what is needed to undo op before it runs: the original folder of a canvas
+// move and the prior pinned state, both read from the server, the source canvas of a widget
+// move, and the prior values of the fields an update changes.
+func (bp *BatchProcessor) captureUndo(ctx context.Context, op *BatchOperation) (batchUndo, error) {
+	switch op.Type {
+	case BatchOperationMove:
+		if c, ok := op.Resource.(*Canvas); ok {
+			// c.FolderID may be stale, so read where the canvas actually is.
+			current, err := bp.session.GetCanvas(ctx, c.ID)
+			if err != nil {
+				return nil, err
+			}
+			return fixedBatchUndo(&BatchOperation{ID: op.ID, Type: BatchOperationMove, Resource: &Canvas{ID: c.ID}, Target: current.FolderID}), nil
+		}
+		widgetID, _, err := batchWidgetRef(op)
+		if err != nil {
+			return nil, err
+		}
+		canvasID, _ := op.Metadata["canvas_id"].(string)
+		if canvasID == "" {
+			return irreversibleBatchUndo, nil
+		}
+		return fixedBatchUndo(&BatchOperation{ID: op.ID, Type: BatchOperationMove, Resource: widgetID, Target: canvasID}), nil
+
+	case BatchOperationCopy:
+		if _, ok := op.Resource.(*Canvas); !ok {
+			// CopyWidget does not report the ID of the copy.
+			return irreversibleBatchUndo, nil
+		}
+		return func(createdID string, _ *PermissionRollback) (*BatchOperation, bool) {
+			if createdID == "" {
+				return nil, true
+			}
+			return &BatchOperation{ID: op.ID, Type: BatchOperationDelete, Resource: &Canvas{ID: createdID}}, false
+		}, nil
+
+	case BatchOperationDelete:
+		return irreversibleBatchUndo, nil
+
+	case BatchOperationPin, BatchOperationUnpin:
+		widgetID, _, err := batchWidgetRef(op)
+		if err != nil {
+			return nil, err
+		}
+		canvasID, _ := op.Metadata["canvas_id"].(string)
+		if canvasID == "" {
+			return irreversibleBatchUndo, nil // the prior pinned state cannot be read
+		}
+		current, err := bp.session.GetWidget(ctx, canvasID, widgetID)
+		if err != nil {
+			return irreversibleBatchUndo, nil
+		}
+		pinning := op.Type == BatchOperationPin
+		if current.Pinned == pinning {
+			return fixedBatchUndo(nil), nil // already in the requested state
+		}
+		inverse := BatchOperationUnpin
+		if !pinning {
+			inverse = BatchOperationPin
+		}
+		return fixedBatchUndo(&BatchOperation{ID: op.ID, Type: inverse, Resource: widgetID,
+			Metadata: map[string]interface{}{"canvas_id": canvasID}}), nil
+
+	case BatchOperationCreate:
+		canvasID, _ := op.Target.(string)
+		widgetType := batchWidgetType(op)
+		return func(createdID string, _ *PermissionRollback) (*BatchOperation, bool) {
+			if createdID == "" {
+				return nil, true
+			}
+			return &BatchOperation{ID: op.ID, Type: BatchOperationDelete, Resource: createdID,
+				Metadata: map[string]interface{}{"canvas_id": canvasID, "widget_type": widgetType}}, false
+		}, nil
+
+	case BatchOperationUpdate, BatchOperationReparent:
+		widgetID, widgetType, err := batchWidgetRef(op)
+		if err != nil {
+			return nil, err
+		}
+		canvasID, _ := op.Metadata["canvas_id"].(string)
+		if canvasID == "" {
+			return nil, fmt.Errorf("%s operation requires canvas_id in metadata", op.Type)
+		}
+		fields := []string{"parent_id"}
+		if op.Type == BatchOperationUpdate {
+			patch, err := batchPayload(op.Target)
+			if err != nil {
+				return nil, err
+			}
+			fields = fields[:0]
+			for k := range patch {
+				fields = append(fields, k)
+			}
+		}
+		current, err := bp.session.GetWidget(ctx, canvasID, widgetID)
+		if err != nil {
+			return nil, err
+		}
+		prior := current.AsMap()
+		if widgetType == "" {
+			t, _ := prior["widget_type"].(string)
+			widgetType = strings.ToLower(t)
+		}
+		restore := map[string]interface{}{}
+		for _, k := range fields {
+			if v, ok := prior[k]; ok && k != "widget_type" {
+				restore[k] = v
+			}
+		}
+		return fixedBatchUndo(&BatchOperation{ID: op.ID, Type: BatchOperationUpdate, Resource: widgetID, Target: restore,
+			Metadata: map[string]interface{}{"canvas_id": canvasID, "widget_type": widgetType}}), nil
+
+	case BatchOperationUpdateUser:
+		userID, err := batchUserID(op)
+		if err != nil {
+			return nil, err
+		}
+		req, err := batchPayload(op.Target)
+		if err != nil {
+			return nil, err
+		}
+		current, err := bp.session.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		prior := current.AsMap()
+		restore := map[string]interface{}{}
+		for k := range req {
+			// Fields the user record does not expose, such as the password, cannot be restored.
+			if v, ok := prior[k]; ok {
+				restore[k] = v
+			}
+		}
+		return fixedBatchUndo(&BatchOperation{ID: op.ID, Type: BatchOperationUpdateUser, Resource: userID, Target: restore}), nil
+
+	case BatchOperationUpdatePermissions:
+		return func(_ string, perms *PermissionRollback) (*BatchOperation, bool) {
+			if perms == nil || len(perms.Entries) == 0 {
+				return nil, false
+			}
+			return &BatchOperation{ID: op.ID, Type: batchOperationRestorePermissions, Resource: perms}, false
+		}, nil
+	}
+	return nil, nil
+}
+
+// executeRestorePermissions applies the permission rollback recorded by an update_permissions
+// operation.
+func (bp *BatchProcessor) executeRestorePermissions(ctx context.Context, op *BatchOperation) error {
+	rollback, ok := op.Resource.(*PermissionRollback)
+	if !ok {
+		return fmt.Errorf("restore_permissions operation requires a *PermissionRollback")
+	}
+	return bp.session.ApplyPermissionRollback(ctx, rollback)
+}
+
+// Rollback undoes the successful operations in results by applying their recorded inverses one
+// at a time, most recently completed first, with the processor's retry settings. The outcome is
+// summarized per operation; operations that cannot be undone (deletes, widget copies) count as
+// failed. The journal does not keep inverses, so operations resumed from a journal count as
+// failed too. Other operations without an inverse are left alone: they changed nothing, or ran
+// without RecordInverses. Results whose inverse was applied are marked RolledBack and, with a
+// Journal, recorded there as unsuccessful so that a rerun executes them again.
+//
+// Usage Example:
+//
+//	config := canvus.DefaultBatchConfig()
+//	config.RecordInverses = true
+//	bp := canvus.NewBatchProcessor(session, config)
+//	results, _ := bp.ExecuteBatch(ctx, ops)
+//	if !looksRight(results) {
+//		summary, err := bp.Rollback(ctx, results)
+//		fmt.Println(summary.Successful, "undone", err)
+//	}
+func (bp *BatchProcessor) Rollback(ctx context.Context, results []*BatchResult) (*BatchSummary, error) {
+	var done []*BatchResult
+	for _, r := range results {
+		if r != nil && r.Success && !r.RolledBack && (r.Inverse != nil || r.Irreversible || r.Resumed) {
+			done = append(done, r)
+		}
+	}
+	sort.SliceStable(done, func(i, j int) bool { return done[i].EndTime.After(done[j].EndTime) })
+
+	rollback := make([]*BatchResult, 0, len(done))
+	var journalErr error
+	for _, r := range done {
+		var err error
+		switch {
+		case r.Irreversible:
+			err = fmt.Errorf("operation %q cannot be rolled back", r.OperationID)
+		case r.Inverse == nil:
+			err = fmt.Errorf("operation %q was resumed from a journal; its inverse was not recorded", r.OperationID)
+		}
+		if err != nil {
+			now := time.Now()
+			rollback = append(rollback, &BatchResult{OperationID: r.OperationID, StartTime: now, EndTime: now, Error: err})
+			continue
+		}
+		res := bp.executeOperation(ctx, r.Inverse, false)
+		res.OperationID = r.OperationID
+		r.RolledBack = res.Success
+		rollback = append(rollback, res)
+		if r.RolledBack && bp.config.Journal != nil && journalErr == nil {
+			journalErr = bp.config.Journal.Record(&BatchResult{OperationID: r.OperationID, Fingerprint: r.Fingerprint,
+				ResourceID: r.ResourceID, Error: errBatchRolledBack, StartTime: res.StartTime, EndTime: res.EndTime, Duration: res.Duration})
+		}
+	}
+	summary := Summarize(rollback)
+	if summary.Failed > 0 {
+		return summary, fmt.Errorf("Rollback: %d of %d operations could not be rolled back", summary.Failed, summary.TotalOperations)
+	}
+	if journalErr != nil {
+		return summary, fmt.Errorf("Rollback: failed to write journal: %w", journalErr)
+	}
+	return summary, nil
+}
+
+// errBatchRolledBack is the error journaled for an operation whose inverse was applied.
+var errBatchRolledBack = errors.New("rolled back")
@@ -0,0 +1,205 @@
+package canvus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchRollbackOnError(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases", map[string]interface{}{"id": "c1", "name": "Board", "folder_id": "f-old"})
+	note := fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "original", "background_color": "FFFFFFFF"})
+	fake.handle("GET", "canvases/c1/widgets/n1", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, note)
+	})
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 1, RollbackOnError: true})
+
+	ops := NewBatchOperationBuilder().
+		Update("edit", "c1", &Note{ID: "n1", WidgetType: "Note"}, map[string]interface{}{"text": "changed", "background_color": "FFCC00FFF"}).
+		Create("add", "c1", map[string]interface{}{"widget_type": "note", "text": "new"}).After("edit").
+		PinWidget("pin", "c1", &Widget{ID: "n1"}).After("add").
+		Move("move", &Canvas{ID: "c1", FolderID: "f-stale"}, "f-new").After("pin").
+		Update("boom", "c1", &Note{ID: "missing", WidgetType: "Note"}, map[string]interface{}{"text": "x"}).After("move").
+		Create("never", "c1", map[string]interface{}{"widget_type": "note", "text": "later"}).After("boom").
+		Build()
+
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	var rb *BatchRollbackError
+	if !errors.As(err, &rb) {
+		t.Fatalf("err = %v, want *BatchRollbackError", err)
+	}
+	if !strings.Contains(err.Error(), `operation "boom" failed`) {
+		t.Errorf("err = %v", err)
+	}
+	if rb.Rollback.TotalOperations != 4 || rb.Rollback.Successful != 4 {
+		t.Errorf("rollback summary = %+v, failed %v", rb.Rollback, rb.Rollback.FailedOperations)
+	}
+	for _, r := range results[:4] {
+		if !r.Success || !r.RolledBack || r.Inverse == nil {
+			t.Errorf("%s = %+v", r.OperationID, r)
+		}
+	}
+	if results[5].Success {
+		t.Errorf("operation after the failure ran: %+v", results[5])
+	}
+
+	notes := fake.items("canvases/c1/notes")
+	if len(notes) != 1 || notes[0]["text"] != "original" || notes[0]["background_color"] != "FFFFFFFF" {
+		t.Errorf("notes after rollback = %v", notes)
+	}
+	if n := fake.callCount("POST widgets/n1/unpin"); n != 1 {
+		t.Errorf("unpin calls = %d", n)
+	}
+	if c := fake.items("canvases")[0]; c["folder_id"] != "f-old" {
+		t.Errorf("canvas folder after rollback = %v", c["folder_id"])
+	}
+}
+
+func TestBatchRollbackOnRequest(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle("GET", "canvases/c1/widgets/w1", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"id": "w1", "widget_type": "Note", "pinned": true})
+	})
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 2, RecordInverses: true, ContinueOnError: true})
+	ops := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		PinWidget("pinned", "c1", &Widget{ID: "w1"}).
+		DeleteWidget("gone", "c1", &Widget{ID: "w2", WidgetType: "Note"}).
+		Unpin("blind", &Widget{ID: "w3"}).
+		MoveWidget("moved", "c1", &Widget{ID: "w4"}, "c2").
+		Build()
+	var movedTo []interface{}
+	fake.handle("POST", "widgets/w4/move", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		movedTo = append(movedTo, body["canvas_id"])
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{})
+	})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "w2"})
+
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[1].Inverse != nil || !results[2].Irreversible || !results[3].Irreversible {
+		t.Errorf("pin inverse = %+v, delete irreversible = %v, unpin without canvas_id irreversible = %v",
+			results[1].Inverse, results[2].Irreversible, results[3].Irreversible)
+	}
+	summary, err := bp.Rollback(context.Background(), results)
+	if err == nil || summary.TotalOperations != 4 || summary.Successful != 2 || summary.Failed != 2 {
+		t.Errorf("summary = %+v, err = %v", summary, err)
+	}
+	if len(movedTo) != 2 || movedTo[1] != "c1" {
+		t.Errorf("widget moves = %v, want back to c1", movedTo)
+	}
+	if len(fake.items("canvases/c1/notes")) != 0 || !results[0].RolledBack {
+		t.Errorf("created note not removed: %v", fake.items("canvases/c1/notes"))
+	}
+}
+
+func TestBatchRollbackOnTimeout(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle("POST", "canvases/c1/browsers", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		<-r.Context().Done()
+	})
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 1, Timeout: 200 * time.Millisecond, RollbackOnError: true})
+	ops := NewBatchOperationBuilder().
+		Create("note", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		Create("slow", "c1", map[string]interface{}{"widget_type": "browser", "url": "https://example.com"}).After("note").
+		Build()
+
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	var rb *BatchRollbackError
+	if !errors.As(err, &rb) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a *BatchRollbackError wrapping the deadline", err)
+	}
+	if rb.Rollback.TotalOperations != 1 || rb.Rollback.Successful != 1 || !results[0].RolledBack {
+		t.Errorf("rollback summary = %+v, failed %v", rb.Rollback, rb.Rollback.FailedOperations)
+	}
+	if notes := fake.items("canvases/c1/notes"); len(notes) != 0 {
+		t.Errorf("notes after rollback = %v", notes)
+	}
+}
+
+func TestBatchRollbackResumedOperations(t *testing.T) {
+	fake := newFakeCanvus(t)
+	journal, err := OpenBatchJournal(filepath.Join(t.TempDir(), "batch.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 1, RecordInverses: true, ContinueOnError: true, Journal: journal})
+	first := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		Build()
+	if _, err := bp.ExecuteBatch(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		Create("b", "c1", map[string]interface{}{"widget_type": "note", "text": "B"}).
+		Build()
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Resumed || results[0].Inverse != nil {
+		t.Fatalf("resumed result = %+v", results[0])
+	}
+	summary, err := bp.Rollback(context.Background(), results)
+	if err == nil || summary.TotalOperations != 2 || summary.Successful != 1 || summary.FailedOperations[0].OperationID != "a" {
+		t.Fatalf("summary = %+v, err = %v", summary, err)
+	}
+	if !strings.Contains(summary.FailedOperations[0].Error.Error(), "resumed from a journal") {
+		t.Errorf("failure = %v", summary.FailedOperations[0].Error)
+	}
+	if notes := fake.items("canvases/c1/notes"); len(notes) != 1 {
+		t.Errorf("notes after rollback = %v", notes)
+	}
+}
+
+func TestBatchRollbackOnErrorJournaled(t *testing.T) {
+	fake := newFakeCanvus(t)
+	journal, err := OpenBatchJournal(filepath.Join(t.TempDir(), "batch.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	bp := NewBatchProcessor(fake.session(), &BatchConfig{MaxConcurrency: 1, RollbackOnError: true, Journal: journal})
+	ops := NewBatchOperationBuilder().
+		Create("a", "c1", map[string]interface{}{"widget_type": "note", "text": "A"}).
+		Update("edit", "c1", &Note{ID: "n1", WidgetType: "Note"}, map[string]interface{}{"text": "changed"}).After("a").
+		Build()
+	var rb *BatchRollbackError
+	if _, err := bp.ExecuteBatch(context.Background(), ops); !errors.As(err, &rb) || rb.Rollback.Successful != 1 {
+		t.Fatalf("err = %v", err)
+	}
+	recorded, err := journal.Load()
+	if err != nil || len(recorded) != 2 || recorded[0].Success || recorded[0].Error.Error() != "rolled back" {
+		t.Fatalf("journal = %+v, err = %v", recorded, err)
+	}
+
+	// Once the failure is fixed, a rerun creates the rolled-back note again.
+	note := fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "original"})
+	fake.handle("GET", "canvases/c1/widgets/n1", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, note)
+	})
+	results, err := bp.ExecuteBatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Resumed || !results[0].Success {
+		t.Errorf("rolled-back operation was resumed: %+v", results[0])
+	}
+	if notes := fake.items("canvases/c1/notes"); len(notes) != 2 || fake.callCount("POST canvases/c1/notes") != 2 {
+		t.Errorf("notes after rerun = %v", notes)
+	}
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 )
 
-// ListBrowsers retrieves all browsers for a given canvas.
-func (s *Session) ListBrowsers(ctx context.Context, canvasID string) ([]Browser, error) {
+// ListBrowsers retrieves all browsers for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListBrowsers(ctx context.Context, canvasID string, filter *Filter) ([]Browser, error) {
 	var browsers []Browser
 	path := fmt.Sprintf("canvases/%s/browsers", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &browsers, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListBrowsers: %w", err)
 	}
+	if filter != nil {
+		browsers = FilterSlice(browsers, filter)
+	}
 	return browsers, nil
 }
 
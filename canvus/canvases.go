@@ -103,17 +103,18 @@ func (c *Session) CopyCanvas(ctx context.Context, id string, req MoveOrCopyCanva
 	return &canvas, nil
 }
 
-// TrashCanvas moves a canvas to the trash folder.
-func (c *Session) TrashCanvas(ctx context.Context, id string, _ string) (*Canvas, error) {
-	userID := c.UserID()
-	if userID == 0 {
-		return nil, fmt.Errorf("TrashCanvas: user ID not set; must login first")
+// TrashCanvas moves a canvas to the trash folder of userID (the canvas owner).
+// If userID is empty, the logged-in user's trash is used; admin API-key sessions
+// should pass the owner's ID (or "trash.<id>") or use TrashService, which resolves the owner automatically.
+func (c *Session) TrashCanvas(ctx context.Context, id string, userID string) (*Canvas, error) {
+	trashID, err := c.trashFolderFor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("TrashCanvas: %w", err)
 	}
-	trashID := fmt.Sprintf("trash.%d", userID)
 	var canvas Canvas
 	path := fmt.Sprintf("canvases/%s/move", id)
 	req := MoveOrCopyCanvasRequest{FolderID: trashID}
-	err := c.doRequest(ctx, "POST", path, req, &canvas, nil, false)
+	err = c.doRequest(ctx, "POST", path, req, &canvas, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("TrashCanvas: %w", err)
 	}
@@ -0,0 +1,1030 @@
+package canvus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CanvasSpec is a declarative description of a canvas and its managed content.
+// Every widget carries a user-assigned Key that is stable across runs; keys must be
+// unique across all widget kinds so connectors can reference any of them.
+//
+// Example YAML:
+//
+//	canvas:
+//	  name: Workshop Board
+//	background:
+//	  type: solid_color
+//	  background_color: "#202020"
+//	anchors:
+//	  - key: intro
+//	    name: Introduction
+//	    location: {x: 0, y: 0}
+//	    size: {width: 1920, height: 1080}
+//	notes:
+//	  - key: welcome
+//	    text: Welcome!
+//	    background_color: FFEB3BFF
+//	    location: {x: 100, y: 100}
+//	connectors:
+//	  - key: welcome-to-intro
+//	    src: welcome
+//	    dst: intro
+type CanvasSpec struct {
+	Canvas       CanvasSpecCanvas       `yaml:"canvas" json:"canvas"`
+	Background   *BackgroundSpec        `yaml:"background,omitempty" json:"background,omitempty"`
+	ColorPresets *ColorPresetsSpec      `yaml:"color_presets,omitempty" json:"color_presets,omitempty"`
+	Anchors      []AnchorSpec           `yaml:"anchors,omitempty" json:"anchors,omitempty"`
+	Notes        []NoteSpec             `yaml:"notes,omitempty" json:"notes,omitempty"`
+	Browsers     []BrowserSpec          `yaml:"browsers,omitempty" json:"browsers,omitempty"`
+	Media        []MediaSpec            `yaml:"media,omitempty" json:"media,omitempty"`
+	Connectors   []ConnectorSpec        `yaml:"connectors,omitempty" json:"connectors,omitempty"`
+	Extra        map[string]interface{} `yaml:",inline" json:"-"` // unknown top-level keys, rejected by Validate
+}
+
+// CanvasSpecCanvas describes the canvas itself. If ID is set, the spec adopts that existing
+// canvas instead of creating a new one.
+type CanvasSpecCanvas struct {
+	ID       string `yaml:"id,omitempty" json:"id,omitempty"`
+	Name     string `yaml:"name" json:"name"`
+	FolderID string `yaml:"folder_id,omitempty" json:"folder_id,omitempty"`
+	Mode     string `yaml:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// BackgroundSpec describes the canvas background. Only non-empty fields are managed.
+type BackgroundSpec struct {
+	Type            string    `yaml:"type,omitempty" json:"type,omitempty"`
+	BackgroundColor string    `yaml:"background_color,omitempty" json:"background_color,omitempty"`
+	Haze            *HazeSpec `yaml:"haze,omitempty" json:"haze,omitempty"`
+	Grid            *GridSpec `yaml:"grid,omitempty" json:"grid,omitempty"`
+}
+
+// HazeSpec describes haze background settings.
+type HazeSpec struct {
+	Color1 string  `yaml:"color1" json:"color1"`
+	Color2 string  `yaml:"color2" json:"color2"`
+	Speed  float64 `yaml:"speed" json:"speed"`
+	Scale  float64 `yaml:"scale" json:"scale"`
+}
+
+// GridSpec describes the grid overlay.
+type GridSpec struct {
+	Visible bool   `yaml:"visible" json:"visible"`
+	Color   string `yaml:"color,omitempty" json:"color,omitempty"`
+}
+
+// ColorPresetsSpec describes the canvas colour presets. Nil lists are left unmanaged.
+type ColorPresetsSpec struct {
+	Annotation     []string `yaml:"annotation,omitempty" json:"annotation,omitempty"`
+	Connector      []string `yaml:"connector,omitempty" json:"connector,omitempty"`
+	NoteBackground []string `yaml:"note_background,omitempty" json:"note_background,omitempty"`
+	NoteText       []string `yaml:"note_text,omitempty" json:"note_text,omitempty"`
+}
+
+// WidgetSpec holds the placement fields shared by all widget specs.
+// Nil or zero values are left unmanaged.
+type WidgetSpec struct {
+	Key      string  `yaml:"key" json:"key"`
+	Location *Point  `yaml:"location,omitempty" json:"location,omitempty"`
+	Size     *Size   `yaml:"size,omitempty" json:"size,omitempty"`
+	Scale    float64 `yaml:"scale,omitempty" json:"scale,omitempty"`
+	Pinned   *bool   `yaml:"pinned,omitempty" json:"pinned,omitempty"`
+}
+
+// AnchorSpec describes an anchor widget.
+type AnchorSpec struct {
+	WidgetSpec `yaml:",inline"`
+	Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+}
+
+// NoteSpec describes a note widget.
+type NoteSpec struct {
+	WidgetSpec      `yaml:",inline"`
+	Text            string `yaml:"text" json:"text"`
+	BackgroundColor string `yaml:"background_color,omitempty" json:"background_color,omitempty"`
+}
+
+// BrowserSpec describes a browser widget.
+type BrowserSpec struct {
+	WidgetSpec `yaml:",inline"`
+	URL        string `yaml:"url" json:"url"`
+}
+
+// MediaSpec describes an image, PDF or video widget uploaded from a local file.
+// Relative File paths are resolved against the directory of the spec file.
+type MediaSpec struct {
+	WidgetSpec `yaml:",inline"`
+	Type       string `yaml:"type" json:"type"` // "image", "pdf" or "video"
+	File       string `yaml:"file" json:"file"`
+	Title      string `yaml:"title,omitempty" json:"title,omitempty"`
+}
+
+// ConnectorSpec describes a connector between two widgets identified by their spec keys.
+type ConnectorSpec struct {
+	Key       string `yaml:"key" json:"key"`
+	Src       string `yaml:"src" json:"src"`
+	Dst       string `yaml:"dst" json:"dst"`
+	SrcTip    string `yaml:"src_tip,omitempty" json:"src_tip,omitempty"`
+	DstTip    string `yaml:"dst_tip,omitempty" json:"dst_tip,omitempty"`
+	LineColor string `yaml:"line_color,omitempty" json:"line_color,omitempty"`
+	LineWidth int    `yaml:"line_width,omitempty" json:"line_width,omitempty"`
+	Type      string `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// CanvasSpecState records which live objects were created for which spec keys, in the
+// spirit of a Terraform state file. Keep it alongside the spec and pass it back to
+// PlanCanvasSpec so re-applying is idempotent.
+type CanvasSpecState struct {
+	CanvasID  string                       `json:"canvas_id"`
+	Resources map[string]SpecStateResource `json:"resources"`
+}
+
+// SpecStateResource is a single managed widget in a CanvasSpecState.
+type SpecStateResource struct {
+	Kind string `json:"kind"` // "anchor", "note", "browser", "image", "pdf", "video" or "connector"
+	ID   string `json:"id"`
+	File string `json:"file,omitempty"` // source file for media
+	// Hash is the SHA-256 of the uploaded media file, used to detect replacements regardless of
+	// the path the spec was loaded from.
+	Hash string `json:"hash,omitempty"`
+}
+
+// SpecActionType is the kind of change a planned action makes.
+type SpecActionType string
+
+const (
+	SpecActionCreate  SpecActionType = "create"
+	SpecActionUpdate  SpecActionType = "update"
+	SpecActionReplace SpecActionType = "replace" // delete and re-create (e.g. media file changed)
+	SpecActionDelete  SpecActionType = "delete"
+)
+
+// SpecAction is a single step of a CanvasPlan.
+type SpecAction struct {
+	Action SpecActionType `json:"action"`
+	Kind   string         `json:"kind"` // "canvas", "background", "color_presets" or a SpecStateResource kind
+	Key    string         `json:"key,omitempty"`
+	ID     string         `json:"id,omitempty"` // live ID for update, replace and delete
+	// Fields holds the request payload: the full desired state for create/replace,
+	// and only the differing fields for update.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// File is the media file to upload for create/replace of image, pdf and video.
+	File string `json:"file,omitempty"`
+	// Refs maps connector end fields ("src", "dst") to the spec keys they reference.
+	// They are resolved to widget IDs when the plan is applied.
+	Refs map[string]string `json:"refs,omitempty"`
+}
+
+// CanvasPlan is the result of comparing a CanvasSpec against a live canvas.
+type CanvasPlan struct {
+	Spec     *CanvasSpec      `json:"-"`
+	State    *CanvasSpecState `json:"state"`
+	CanvasID string           `json:"canvas_id,omitempty"` // empty if the canvas will be created
+	Actions  []SpecAction     `json:"actions"`
+}
+
+// LoadCanvasSpec reads and validates a YAML canvas spec from a file.
+// Relative media file paths are resolved against the spec file's directory.
+func LoadCanvasSpec(path string) (*CanvasSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCanvasSpec: %w", err)
+	}
+	spec, err := ParseCanvasSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCanvasSpec: %w", err)
+	}
+	dir := filepath.Dir(path)
+	for i := range spec.Media {
+		if spec.Media[i].File != "" && !filepath.IsAbs(spec.Media[i].File) {
+			spec.Media[i].File = filepath.Join(dir, spec.Media[i].File)
+		}
+	}
+	return spec, nil
+}
+
+// ParseCanvasSpec parses and validates a YAML canvas spec. Colours are normalized to RRGGBBAA.
+func ParseCanvasSpec(data []byte) (*CanvasSpec, error) {
+	var spec CanvasSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("ParseCanvasSpec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks keys, references, media types and colours, normalizing colours in place.
+func (spec *CanvasSpec) Validate() error {
+	var errs ValidationErrors
+	for k := range spec.Extra {
+		errs.Add(k, "unknown top-level key")
+	}
+	if spec.Canvas.Name == "" && spec.Canvas.ID == "" {
+		errs.Add("canvas.name", "name or id is required")
+	}
+	normalize := func(field string, c *string) {
+		if *c == "" {
+			return
+		}
+		n, err := NormalizeColor(*c)
+		if err != nil {
+			errs.Add(field, err.Error())
+			return
+		}
+		*c = n
+	}
+	if bg := spec.Background; bg != nil {
+		normalize("background.background_color", &bg.BackgroundColor)
+		if bg.Haze != nil {
+			normalize("background.haze.color1", &bg.Haze.Color1)
+			normalize("background.haze.color2", &bg.Haze.Color2)
+		}
+		if bg.Grid != nil {
+			normalize("background.grid.color", &bg.Grid.Color)
+		}
+	}
+	if cp := spec.ColorPresets; cp != nil {
+		for name, list := range map[string][]string{"annotation": cp.Annotation, "connector": cp.Connector, "note_background": cp.NoteBackground, "note_text": cp.NoteText} {
+			for i := range list {
+				normalize(fmt.Sprintf("color_presets.%s[%d]", name, i), &list[i])
+			}
+		}
+	}
+
+	keys := make(map[string]string)
+	addKey := func(field, key, kind string) {
+		if key == "" {
+			errs.Add(field, "key is required")
+			return
+		}
+		if prev, dup := keys[key]; dup {
+			errs.Add(field, fmt.Sprintf("duplicate key %q (already used by a %s)", key, prev))
+			return
+		}
+		keys[key] = kind
+	}
+	for i := range spec.Anchors {
+		addKey(fmt.Sprintf("anchors[%d].key", i), spec.Anchors[i].Key, "anchor")
+	}
+	for i := range spec.Notes {
+		addKey(fmt.Sprintf("notes[%d].key", i), spec.Notes[i].Key, "note")
+		normalize(fmt.Sprintf("notes[%d].background_color", i), &spec.Notes[i].BackgroundColor)
+	}
+	for i := range spec.Browsers {
+		addKey(fmt.Sprintf("browsers[%d].key", i), spec.Browsers[i].Key, "browser")
+		if spec.Browsers[i].URL == "" {
+			errs.Add(fmt.Sprintf("browsers[%d].url", i), "url is required")
+		}
+	}
+	for i := range spec.Media {
+		m := &spec.Media[i]
+		m.Type = strings.ToLower(m.Type)
+		addKey(fmt.Sprintf("media[%d].key", i), m.Key, m.Type)
+		switch m.Type {
+		case "image", "pdf", "video":
+		default:
+			errs.Add(fmt.Sprintf("media[%d].type", i), fmt.Sprintf("must be image, pdf or video, got %q", m.Type))
+		}
+		if m.File == "" {
+			errs.Add(fmt.Sprintf("media[%d].file", i), "file is required")
+		}
+	}
+	widgetKeys := make(map[string]string, len(keys))
+	for k, v := range keys {
+		widgetKeys[k] = v
+	}
+	for i := range spec.Connectors {
+		c := &spec.Connectors[i]
+		addKey(fmt.Sprintf("connectors[%d].key", i), c.Key, "connector")
+		if _, ok := widgetKeys[c.Src]; !ok {
+			errs.Add(fmt.Sprintf("connectors[%d].src", i), fmt.Sprintf("unknown widget key %q", c.Src))
+		}
+		if _, ok := widgetKeys[c.Dst]; !ok {
+			errs.Add(fmt.Sprintf("connectors[%d].dst", i), fmt.Sprintf("unknown widget key %q", c.Dst))
+		}
+		normalize(fmt.Sprintf("connectors[%d].line_color", i), &c.LineColor)
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// LoadCanvasSpecState reads a state file. A missing file yields an empty state.
+func LoadCanvasSpecState(path string) (*CanvasSpecState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &CanvasSpecState{Resources: map[string]SpecStateResource{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("LoadCanvasSpecState: %w", err)
+	}
+	var st CanvasSpecState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("LoadCanvasSpecState: %w", err)
+	}
+	if st.Resources == nil {
+		st.Resources = map[string]SpecStateResource{}
+	}
+	return &st, nil
+}
+
+// Save writes the state to a file as indented JSON.
+func (st *CanvasSpecState) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("CanvasSpecState.Save: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("CanvasSpecState.Save: %w", err)
+	}
+	return nil
+}
+
+// clone returns a deep copy of the state so plans never mutate the caller's state.
+func (st *CanvasSpecState) clone() *CanvasSpecState {
+	out := &CanvasSpecState{Resources: map[string]SpecStateResource{}}
+	if st == nil {
+		return out
+	}
+	out.CanvasID = st.CanvasID
+	for k, v := range st.Resources {
+		out.Resources[k] = v
+	}
+	return out
+}
+
+// specWidget is the kind-independent view of a widget spec used while planning.
+type specWidget struct {
+	key    string
+	kind   string
+	fields map[string]interface{}
+	file   string
+	refs   map[string]string
+}
+
+// desiredWidgets flattens the spec into create payloads, in apply order.
+func (spec *CanvasSpec) desiredWidgets() []specWidget {
+	var out []specWidget
+	placement := func(w WidgetSpec, m map[string]interface{}) map[string]interface{} {
+		if w.Location != nil {
+			m["location"] = map[string]interface{}{"x": w.Location.X, "y": w.Location.Y}
+		}
+		if w.Size != nil {
+			m["size"] = map[string]interface{}{"width": w.Size.Width, "height": w.Size.Height}
+		}
+		if w.Scale != 0 {
+			m["scale"] = w.Scale
+		}
+		if w.Pinned != nil {
+			m["pinned"] = *w.Pinned
+		}
+		return m
+	}
+	for _, a := range spec.Anchors {
+		m := map[string]interface{}{}
+		if a.Name != "" {
+			m["anchor_name"] = a.Name
+		}
+		out = append(out, specWidget{key: a.Key, kind: "anchor", fields: placement(a.WidgetSpec, m)})
+	}
+	for _, n := range spec.Notes {
+		m := map[string]interface{}{"text": n.Text}
+		if n.BackgroundColor != "" {
+			m["background_color"] = n.BackgroundColor
+		}
+		out = append(out, specWidget{key: n.Key, kind: "note", fields: placement(n.WidgetSpec, m)})
+	}
+	for _, b := range spec.Browsers {
+		m := map[string]interface{}{"url": b.URL}
+		out = append(out, specWidget{key: b.Key, kind: "browser", fields: placement(b.WidgetSpec, m)})
+	}
+	for _, md := range spec.Media {
+		m := map[string]interface{}{}
+		if md.Title != "" {
+			m["title"] = md.Title
+		}
+		out = append(out, specWidget{key: md.Key, kind: md.Type, fields: placement(md.WidgetSpec, m), file: md.File})
+	}
+	for _, c := range spec.Connectors {
+		m := map[string]interface{}{}
+		src := map[string]interface{}{}
+		dst := map[string]interface{}{}
+		if c.SrcTip != "" {
+			src["tip"] = c.SrcTip
+		}
+		if c.DstTip != "" {
+			dst["tip"] = c.DstTip
+		}
+		m["src"] = src
+		m["dst"] = dst
+		if c.LineColor != "" {
+			m["line_color"] = c.LineColor
+		}
+		if c.LineWidth != 0 {
+			m["line_width"] = c.LineWidth
+		}
+		if c.Type != "" {
+			m["type"] = c.Type
+		}
+		out = append(out, specWidget{key: c.Key, kind: "connector", fields: m, refs: map[string]string{"src": c.Src, "dst": c.Dst}})
+	}
+	return out
+}
+
+// PlanCanvasSpec compares a spec against the live canvas recorded in state and returns
+// the create/update/delete actions needed to converge. state may be nil for a first run.
+// Widgets on the canvas that are not recorded in state are never touched.
+//
+// Usage Example:
+//
+//	spec, _ := canvus.LoadCanvasSpec("board.yaml")
+//	state, _ := canvus.LoadCanvasSpecState("board.state.json")
+//	plan, err := session.PlanCanvasSpec(ctx, spec, state)
+//	fmt.Print(plan)
+//	newState, err := session.ApplyCanvasPlan(ctx, plan)
+//	_ = newState.Save("board.state.json")
+func (s *Session) PlanCanvasSpec(ctx context.Context, spec *CanvasSpec, state *CanvasSpecState) (*CanvasPlan, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("PlanCanvasSpec: spec is nil")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("PlanCanvasSpec: %w", err)
+	}
+	plan := &CanvasPlan{Spec: spec, State: state.clone()}
+
+	canvasID := spec.Canvas.ID
+	if canvasID == "" {
+		canvasID = plan.State.CanvasID
+	}
+	var live *liveCanvas
+	if canvasID != "" {
+		canvas, err := s.GetCanvas(ctx, canvasID)
+		if err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("PlanCanvasSpec: %w", err)
+		}
+		if canvas != nil {
+			plan.CanvasID = canvas.ID
+			if changes := diffFields(canvasDesired(spec.Canvas), canvas); len(changes) > 0 {
+				plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionUpdate, Kind: "canvas", ID: canvas.ID, Fields: changes})
+			}
+			if live, err = s.fetchLiveCanvas(ctx, canvas.ID, spec); err != nil {
+				return nil, fmt.Errorf("PlanCanvasSpec: %w", err)
+			}
+		} else if spec.Canvas.ID != "" {
+			return nil, fmt.Errorf("PlanCanvasSpec: canvas %s not found", spec.Canvas.ID)
+		}
+	}
+	if plan.CanvasID == "" {
+		// Canvas (re)created: everything recorded in the old state is gone with it.
+		plan.State.CanvasID = ""
+		plan.State.Resources = map[string]SpecStateResource{}
+		plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionCreate, Kind: "canvas", Fields: canvasDesired(spec.Canvas)})
+	}
+
+	if spec.Background != nil {
+		desired := jsonRoundTrip(spec.Background)
+		var changes map[string]interface{}
+		if live == nil || live.background == nil {
+			changes = desired
+		} else {
+			changes = diffFields(desired, live.background)
+		}
+		if len(changes) > 0 {
+			// The background endpoint expects the complete setting, not a partial patch.
+			plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionUpdate, Kind: "background", Fields: desired})
+		}
+	}
+	if spec.ColorPresets != nil {
+		desired := jsonRoundTrip(spec.ColorPresets)
+		changes := desired
+		if live != nil && live.colorPresets != nil {
+			changes = diffFields(desired, live.colorPresets)
+		}
+		if len(changes) > 0 {
+			plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionUpdate, Kind: "color_presets", Fields: changes})
+		}
+	}
+
+	wanted := make(map[string]struct{})
+	for _, w := range spec.desiredWidgets() {
+		wanted[w.key] = struct{}{}
+		prev, tracked := plan.State.Resources[w.key]
+		var liveObj interface{}
+		if tracked && live != nil {
+			liveObj = live.byID[prev.ID]
+		}
+		if liveObj != nil && prev.Kind != w.kind {
+			// The key changed kind: remove the old widget before creating the new one.
+			plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionDelete, Kind: prev.Kind, Key: w.key, ID: prev.ID})
+			liveObj = nil
+		}
+		mediaChanged := false
+		if liveObj != nil && w.file != "" {
+			changed, err := specMediaChanged(prev, w.file)
+			if err != nil {
+				return nil, fmt.Errorf("PlanCanvasSpec: %w", err)
+			}
+			mediaChanged = changed
+		}
+		switch {
+		case liveObj == nil:
+			plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionCreate, Kind: w.kind, Key: w.key, Fields: w.fields, File: w.file, Refs: w.refs})
+		case mediaChanged:
+			plan.Actions = append(plan.Actions, SpecAction{Action: SpecActionReplace, Kind: w.kind, Key: w.key, ID: prev.ID, Fields: w.fields, File: w.file})
+		default:
+			desired := w.fields
+			var refChanged bool
+			if w.refs != nil {
+				desired = resolveConnectorRefs(w.fields, w.refs, plan.State)
+				for _, ref := range w.refs {
+					// An end that is about to be created or replaced gets a new ID.
+					if plan.actionFor(ref) != nil {
+						refChanged = true
+					}
+				}
+			}
+			changes := diffFields(desired, liveObj)
+			if len(changes) > 0 || refChanged {
+				action := SpecAction{Action: SpecActionUpdate, Kind: w.kind, Key: w.key, ID: prev.ID, Fields: changes}
+				if w.refs != nil {
+					// Connector ends are always sent whole so src/dst IDs and tips stay consistent.
+					action.Fields = copyFields(changes)
+					action.Fields["src"] = w.fields["src"]
+					action.Fields["dst"] = w.fields["dst"]
+					action.Refs = w.refs
+				}
+				plan.Actions = append(plan.Actions, action)
+			}
+		}
+	}
+
+	// Delete tracked resources that left the spec; connectors first so their ends still exist.
+	var deletes []SpecAction
+	for key, res := range plan.State.Resources {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		if live == nil || live.byID[res.ID] == nil {
+			delete(plan.State.Resources, key) // already gone
+			continue
+		}
+		deletes = append(deletes, SpecAction{Action: SpecActionDelete, Kind: res.Kind, Key: key, ID: res.ID})
+	}
+	sort.Slice(deletes, func(i, j int) bool {
+		ci, cj := deletes[i].Kind == "connector", deletes[j].Kind == "connector"
+		if ci != cj {
+			return ci
+		}
+		return deletes[i].Key < deletes[j].Key
+	})
+	plan.Actions = append(plan.Actions, deletes...)
+	return plan, nil
+}
+
+// specMediaChanged reports whether file differs from the media recorded in prev. States
+// written before hashes were recorded fall back to comparing paths.
+func specMediaChanged(prev SpecStateResource, file string) (bool, error) {
+	if prev.Hash == "" {
+		return prev.File != file, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	return specFileHash(data) != prev.Hash, nil
+}
+
+func specFileHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// actionFor returns the create or replace action for a widget key, if planned.
+func (p *CanvasPlan) actionFor(key string) *SpecAction {
+	for i := range p.Actions {
+		a := &p.Actions[i]
+		if a.Key == key && (a.Action == SpecActionCreate || a.Action == SpecActionReplace) {
+			return a
+		}
+	}
+	return nil
+}
+
+// Empty returns true if applying the plan would change nothing.
+func (p *CanvasPlan) Empty() bool {
+	return p == nil || len(p.Actions) == 0
+}
+
+// String renders the plan in a Terraform-like format, one action per line.
+func (p *CanvasPlan) String() string {
+	if p.Empty() {
+		return "No changes. Canvas matches the spec.\n"
+	}
+	var b strings.Builder
+	counts := map[SpecActionType]int{}
+	for _, a := range p.Actions {
+		counts[a.Action]++
+		symbol := map[SpecActionType]string{SpecActionCreate: "+", SpecActionUpdate: "~", SpecActionReplace: "-/+", SpecActionDelete: "-"}[a.Action]
+		label := a.Kind
+		if a.Key != "" {
+			label += " " + a.Key
+		}
+		fmt.Fprintf(&b, "%s %s %s", symbol, a.Action, label)
+		if a.Action == SpecActionUpdate && len(a.Fields) > 0 {
+			fields := make([]string, 0, len(a.Fields))
+			for f := range a.Fields {
+				fields = append(fields, f)
+			}
+			sort.Strings(fields)
+			fmt.Fprintf(&b, " (%s)", strings.Join(fields, ", "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to replace, %d to delete.\n",
+		counts[SpecActionCreate], counts[SpecActionUpdate], counts[SpecActionReplace], counts[SpecActionDelete])
+	return b.String()
+}
+
+// ApplyCanvasPlan executes a plan through the regular Create*/Update*/Delete* methods and
+// returns the resulting state. On error, the returned state reflects the actions that
+// completed, so it should still be saved before retrying.
+func (s *Session) ApplyCanvasPlan(ctx context.Context, plan *CanvasPlan) (*CanvasSpecState, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("ApplyCanvasPlan: plan is nil")
+	}
+	state := plan.State.clone()
+	canvasID := plan.CanvasID
+	state.CanvasID = canvasID
+
+	for _, a := range plan.Actions {
+		if err := ctx.Err(); err != nil {
+			return state, fmt.Errorf("ApplyCanvasPlan: %w", err)
+		}
+		var err error
+		switch a.Kind {
+		case "canvas":
+			canvasID, err = s.applyCanvasAction(ctx, a)
+			state.CanvasID = canvasID
+		case "background":
+			err = s.PatchCanvasBackground(ctx, canvasID, a.Fields)
+		case "color_presets":
+			_, err = s.PatchColorPresets(ctx, canvasID, a.Fields)
+		default:
+			err = s.applyWidgetAction(ctx, canvasID, a, state)
+		}
+		if err != nil {
+			label := a.Kind
+			if a.Key != "" {
+				label += " " + a.Key
+			}
+			return state, fmt.Errorf("ApplyCanvasPlan: %s %s: %w", a.Action, label, err)
+		}
+	}
+	return state, nil
+}
+
+func (s *Session) applyCanvasAction(ctx context.Context, a SpecAction) (string, error) {
+	switch a.Action {
+	case SpecActionCreate:
+		req := CreateCanvasRequest{}
+		req.Name, _ = a.Fields["name"].(string)
+		req.FolderID, _ = a.Fields["folder_id"].(string)
+		canvas, err := s.CreateCanvas(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		if mode, _ := a.Fields["mode"].(string); mode != "" && mode != canvas.Mode {
+			if _, err := s.UpdateCanvas(ctx, canvas.ID, UpdateCanvasRequest{Mode: mode}); err != nil {
+				return canvas.ID, err
+			}
+		}
+		return canvas.ID, nil
+	case SpecActionUpdate:
+		update := copyFields(a.Fields)
+		if folderID, ok := update["folder_id"].(string); ok {
+			delete(update, "folder_id")
+			if _, err := s.MoveCanvas(ctx, a.ID, MoveOrCopyCanvasRequest{FolderID: folderID}); err != nil {
+				return a.ID, err
+			}
+		}
+		if len(update) > 0 {
+			if _, err := s.UpdateCanvas(ctx, a.ID, update); err != nil {
+				return a.ID, err
+			}
+		}
+		return a.ID, nil
+	}
+	return "", fmt.Errorf("unsupported canvas action %q", a.Action)
+}
+
+func (s *Session) applyWidgetAction(ctx context.Context, canvasID string, a SpecAction, state *CanvasSpecState) error {
+	if a.Action == SpecActionDelete || a.Action == SpecActionReplace {
+		if err := s.DeleteWidget(ctx, canvasID, a.ID, a.Kind); err != nil && !isNotFound(err) {
+			return err
+		}
+		delete(state.Resources, a.Key)
+		if a.Action == SpecActionDelete {
+			return nil
+		}
+	}
+	fields := a.Fields
+	if a.Refs != nil {
+		fields = resolveConnectorRefs(a.Fields, a.Refs, state)
+		for field, ref := range a.Refs {
+			if _, ok := state.Resources[ref]; !ok {
+				return fmt.Errorf("%s references %q, which has not been created", field, ref)
+			}
+		}
+	}
+	if a.Action == SpecActionUpdate {
+		_, err := s.UpdateWidget(ctx, canvasID, a.ID, withWidgetType(fields, a.Kind))
+		return err
+	}
+
+	var id, hash string
+	switch a.Kind {
+	case "anchor":
+		w, err := s.CreateAnchor(ctx, canvasID, fields)
+		if err != nil {
+			return err
+		}
+		id = w.ID
+	case "note":
+		w, err := s.CreateNote(ctx, canvasID, fields)
+		if err != nil {
+			return err
+		}
+		id = w.ID
+	case "browser":
+		w, err := s.CreateBrowser(ctx, canvasID, fields)
+		if err != nil {
+			return err
+		}
+		id = w.ID
+	case "connector":
+		w, err := s.CreateConnector(ctx, canvasID, fields)
+		if err != nil {
+			return err
+		}
+		id = w.ID
+	case "image", "pdf", "video":
+		data, err := os.ReadFile(a.File)
+		if err != nil {
+			return err
+		}
+		hash = specFileHash(data)
+		body, contentType, err := buildMultipartBody(fields, "data", filepath.Base(a.File), data)
+		if err != nil {
+			return err
+		}
+		switch a.Kind {
+		case "image":
+			w, err := s.CreateImage(ctx, canvasID, body, contentType)
+			if err != nil {
+				return err
+			}
+			id = w.ID
+		case "pdf":
+			w, err := s.CreatePDF(ctx, canvasID, body, contentType)
+			if err != nil {
+				return err
+			}
+			id = w.ID
+		case "video":
+			w, err := s.CreateVideo(ctx, canvasID, body, contentType)
+			if err != nil {
+				return err
+			}
+			id = w.ID
+		}
+	default:
+		return fmt.Errorf("unsupported widget kind %q", a.Kind)
+	}
+	state.Resources[a.Key] = SpecStateResource{Kind: a.Kind, ID: id, File: a.File, Hash: hash}
+	return nil
+}
+
+// liveCanvas is the current state of a canvas, fetched once per plan.
+type liveCanvas struct {
+	background   interface{}
+	colorPresets interface{}
+	byID         map[string]interface{}
+}
+
+func (s *Session) fetchLiveCanvas(ctx context.Context, canvasID string, spec *CanvasSpec) (*liveCanvas, error) {
+	live := &liveCanvas{byID: make(map[string]interface{})}
+	if spec.Background != nil {
+		bg, err := s.GetCanvasBackground(ctx, canvasID)
+		if err != nil {
+			return nil, err
+		}
+		live.background = bg
+	}
+	if spec.ColorPresets != nil {
+		cp, err := s.GetColorPresets(ctx, canvasID)
+		if err != nil {
+			return nil, err
+		}
+		live.colorPresets = cp
+	}
+	anchors, err := s.ListAnchors(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range anchors {
+		live.byID[anchors[i].ID] = anchors[i]
+	}
+	notes, err := s.ListNotes(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range notes {
+		live.byID[notes[i].ID] = notes[i]
+	}
+	browsers, err := s.ListBrowsers(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range browsers {
+		live.byID[browsers[i].ID] = browsers[i]
+	}
+	images, err := s.ListImages(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		live.byID[images[i].ID] = images[i]
+	}
+	pdfs, err := s.ListPDFs(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pdfs {
+		live.byID[pdfs[i].ID] = pdfs[i]
+	}
+	videos, err := s.ListVideos(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range videos {
+		live.byID[videos[i].ID] = videos[i]
+	}
+	connectors, err := s.ListConnectors(ctx, canvasID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range connectors {
+		live.byID[connectors[i].ID] = connectors[i]
+	}
+	return live, nil
+}
+
+// canvasDesired returns the managed canvas fields. Folder is only compared when set.
+func canvasDesired(c CanvasSpecCanvas) map[string]interface{} {
+	m := map[string]interface{}{}
+	if c.Name != "" {
+		m["name"] = c.Name
+	}
+	if c.Mode != "" {
+		m["mode"] = c.Mode
+	}
+	if c.FolderID != "" {
+		m["folder_id"] = c.FolderID
+	}
+	return m
+}
+
+// resolveConnectorRefs fills the "id" of connector ends from the state.
+func resolveConnectorRefs(fields map[string]interface{}, refs map[string]string, state *CanvasSpecState) map[string]interface{} {
+	out := copyFields(fields)
+	for field, key := range refs {
+		end := map[string]interface{}{}
+		if m, ok := fields[field].(map[string]interface{}); ok {
+			for k, v := range m {
+				end[k] = v
+			}
+		}
+		if res, ok := state.Resources[key]; ok {
+			end["id"] = res.ID
+		}
+		out[field] = end
+	}
+	return out
+}
+
+// withWidgetType returns a copy of fields with the widget_type UpdateWidget dispatches on.
+func withWidgetType(fields map[string]interface{}, kind string) map[string]interface{} {
+	out := copyFields(fields)
+	out["widget_type"] = kind
+	return out
+}
+
+func copyFields(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// diffFields returns the entries of desired whose values differ from the live object.
+// Nested maps are compared as subsets, so unmanaged live fields never cause a diff.
+func diffFields(desired map[string]interface{}, live interface{}) map[string]interface{} {
+	liveMap := jsonRoundTrip(live)
+	norm := jsonRoundTrip(desired)
+	changes := map[string]interface{}{}
+	for k, v := range norm {
+		if !jsonSubsetEqual(k, v, liveMap[k]) {
+			changes[k] = desired[k]
+		}
+	}
+	return changes
+}
+
+// jsonRoundTrip converts any JSON-serializable value to a generic map.
+func jsonRoundTrip(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+// jsonSubsetEqual reports whether want matches got, treating maps in want as subsets. key is
+// the field being compared; colours are compared after NormalizeColor, other strings exactly.
+func jsonSubsetEqual(key string, want, got interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return len(w) == 0 && got == nil
+		}
+		for k, v := range w {
+			if !jsonSubsetEqual(k, v, g[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !jsonSubsetEqual(key, w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	case float64:
+		g, ok := got.(float64)
+		return ok && floatEqual(w, g)
+	case string:
+		g, ok := got.(string)
+		if !ok {
+			return false
+		}
+		if isSpecColorField(key) {
+			wc, err1 := NormalizeColor(w)
+			gc, err2 := NormalizeColor(g)
+			if err1 == nil && err2 == nil {
+				return wc == gc
+			}
+		}
+		return w == g
+	default:
+		return want == got
+	}
+}
+
+// isSpecColorField reports whether a spec field holds colours: background_color, line_color,
+// the grid color and the color preset lists.
+func isSpecColorField(key string) bool {
+	switch key {
+	case "color", "annotation", "connector", "note_background", "note_text":
+		return true
+	}
+	return strings.HasSuffix(key, "_color")
+}
+
+// isNotFound reports whether err is an API 404.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,285 @@
+package canvus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCanvasSpec = `
+canvas:
+  name: Workshop
+background:
+  type: solid_color
+  background_color: "#202020"
+anchors:
+  - key: intro
+    name: Introduction
+    location: {x: 0, y: 0}
+    size: {width: 1920, height: 1080}
+notes:
+  - key: welcome
+    text: Welcome!
+    background_color: "#FFEB3B"
+    location: {x: 100, y: 100}
+  - key: agenda
+    text: Agenda
+    location: {x: 400, y: 100}
+connectors:
+  - key: welcome-agenda
+    src: welcome
+    dst: agenda
+    dst_tip: solid-equilateral-triangle
+`
+
+func TestParseCanvasSpecValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", testCanvasSpec, ""},
+		{"missing name", "notes: []", "canvas.name"},
+		{"duplicate key", "canvas: {name: x}\nnotes: [{key: a, text: a}]\nanchors: [{key: a}]", "duplicate key"},
+		{"unknown ref", "canvas: {name: x}\nnotes: [{key: a, text: a}]\nconnectors: [{key: c, src: a, dst: b}]", "unknown widget key \"b\""},
+		{"bad color", "canvas: {name: x}\nnotes: [{key: a, text: a, background_color: nope}]", "background_color"},
+		{"bad media", "canvas: {name: x}\nmedia: [{key: m, type: gif, file: a.gif}]", "must be image, pdf or video"},
+		{"unknown key", "canvas: {name: x}\nwidgets: []", "unknown top-level key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCanvasSpec([]byte(tt.yaml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if spec.Notes[0].BackgroundColor != "FFEB3BFF" {
+					t.Errorf("color not normalized: %q", spec.Notes[0].BackgroundColor)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanApplyCanvasSpecIdempotent(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+
+	spec, err := ParseCanvasSpec([]byte(testCanvasSpec))
+	if err != nil {
+		t.Fatalf("ParseCanvasSpec: %v", err)
+	}
+	plan, err := s.PlanCanvasSpec(ctx, spec, nil)
+	if err != nil {
+		t.Fatalf("PlanCanvasSpec: %v", err)
+	}
+	if !strings.Contains(plan.String(), "Plan: 5 to create, 1 to update, 0 to replace, 0 to delete.") {
+		t.Fatalf("unexpected first plan:\n%s", plan)
+	}
+	state, err := s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatalf("ApplyCanvasPlan: %v", err)
+	}
+	if state.CanvasID == "" || len(state.Resources) != 4 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	conns := fake.items("canvases/" + state.CanvasID + "/connectors")
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 connector, got %d", len(conns))
+	}
+	dst := conns[0]["dst"].(map[string]interface{})
+	if dst["id"] != state.Resources["agenda"].ID || dst["tip"] != "solid-equilateral-triangle" {
+		t.Errorf("connector dst = %v", dst)
+	}
+
+	// Round-trip the state through a file, then re-plan: nothing should change.
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := state.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadCanvasSpecState(path)
+	if err != nil {
+		t.Fatalf("LoadCanvasSpecState: %v", err)
+	}
+	again, err := s.PlanCanvasSpec(ctx, spec, loaded)
+	if err != nil {
+		t.Fatalf("PlanCanvasSpec: %v", err)
+	}
+	if !again.Empty() {
+		t.Fatalf("expected empty plan on re-run, got:\n%s", again)
+	}
+}
+
+func TestPlanCanvasSpecUpdateAndDelete(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+
+	spec, _ := ParseCanvasSpec([]byte(testCanvasSpec))
+	plan, _ := s.PlanCanvasSpec(ctx, spec, nil)
+	state, err := s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatalf("ApplyCanvasPlan: %v", err)
+	}
+
+	spec.Notes[0].Text = "Hello!"
+	spec.Notes = spec.Notes[:1]
+	spec.Connectors = nil
+	plan, err = s.PlanCanvasSpec(ctx, spec, state)
+	if err != nil {
+		t.Fatalf("PlanCanvasSpec: %v", err)
+	}
+	want := "~ update note welcome (text)\n- delete connector welcome-agenda\n- delete note agenda\n"
+	if !strings.HasPrefix(plan.String(), want) {
+		t.Fatalf("plan =\n%s\nwant prefix\n%s", plan, want)
+	}
+	state, err = s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatalf("ApplyCanvasPlan: %v", err)
+	}
+	if _, ok := state.Resources["agenda"]; ok {
+		t.Error("deleted note still in state")
+	}
+	notes := fake.items("canvases/" + state.CanvasID + "/notes")
+	if len(notes) != 1 || notes[0]["text"] != "Hello!" {
+		t.Errorf("unexpected notes: %v", notes)
+	}
+	if n := len(fake.items("canvases/" + state.CanvasID + "/connectors")); n != 0 {
+		t.Errorf("expected connector deleted, %d left", n)
+	}
+}
+
+func TestPlanCanvasSpecCaseOnlyChanges(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+
+	spec, _ := ParseCanvasSpec([]byte(testCanvasSpec))
+	plan, _ := s.PlanCanvasSpec(ctx, spec, nil)
+	state, err := s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatalf("ApplyCanvasPlan: %v", err)
+	}
+
+	// A colour echoed in another case is unchanged; text that differs only in case is not.
+	fake.mu.Lock()
+	for _, n := range fake.store["canvases/"+state.CanvasID+"/notes"] {
+		if n["id"] == state.Resources["welcome"].ID {
+			n["background_color"] = "ffeb3bff"
+		}
+	}
+	fake.mu.Unlock()
+	spec.Notes[0].Text = "WELCOME!"
+	plan, err = s.PlanCanvasSpec(ctx, spec, state)
+	if err != nil {
+		t.Fatalf("PlanCanvasSpec: %v", err)
+	}
+	if got, want := plan.String(), "~ update note welcome (text)\n"; !strings.HasPrefix(got, want) || strings.Count(got, "~ update") != 1 {
+		t.Fatalf("plan =\n%s\nwant a single action\n%s", got, want)
+	}
+}
+
+func TestLoadCanvasSpecResolvesMediaPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "board.yaml")
+	data := "canvas: {name: x}\nmedia: [{key: logo, type: Image, file: logo.png}]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := LoadCanvasSpec(path)
+	if err != nil {
+		t.Fatalf("LoadCanvasSpec: %v", err)
+	}
+	if spec.Media[0].File != filepath.Join(dir, "logo.png") || spec.Media[0].Type != "image" {
+		t.Errorf("unexpected media %+v", spec.Media[0])
+	}
+}
+
+func TestPlanCanvasSpecKindChange(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+
+	spec, _ := ParseCanvasSpec([]byte("canvas: {name: x}\nnotes: [{key: a, text: A}]\n"))
+	plan, err := s.PlanCanvasSpec(ctx, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, _ = ParseCanvasSpec([]byte("canvas: {name: x}\nanchors: [{key: a, name: A}]\n"))
+	plan, err = s.PlanCanvasSpec(ctx, spec, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plan.String(), "Plan: 1 to create, 0 to update, 0 to replace, 1 to delete.") {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if state, err = s.ApplyCanvasPlan(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	if notes := fake.items("canvases/" + state.CanvasID + "/notes"); len(notes) != 0 {
+		t.Errorf("old note left on the canvas: %v", notes)
+	}
+	if res := state.Resources["a"]; res.Kind != "anchor" {
+		t.Errorf("state = %+v", state.Resources)
+	}
+	if again, err := s.PlanCanvasSpec(ctx, spec, state); err != nil || !again.Empty() {
+		t.Errorf("re-plan = %v, err = %v", again, err)
+	}
+}
+
+func TestPlanCanvasSpecMediaByContent(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.png")
+	specPath := filepath.Join(dir, "spec.yaml")
+	if err := os.WriteFile(logo, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(specPath, []byte("canvas: {name: x}\nmedia: [{key: logo, type: image, file: logo.png}]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := LoadCanvasSpec(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := s.PlanCanvasSpec(ctx, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := s.ApplyCanvasPlan(ctx, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same spec loaded through another path resolves the file differently.
+	t.Chdir(dir)
+	spec, err = LoadCanvasSpec("spec.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan, err = s.PlanCanvasSpec(ctx, spec, state); err != nil || !plan.Empty() {
+		t.Fatalf("plan = %v, err = %v", plan, err)
+	}
+
+	if err := os.WriteFile(logo, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = s.PlanCanvasSpec(ctx, spec, state)
+	if err != nil || !strings.Contains(plan.String(), "0 to create, 0 to update, 1 to replace") {
+		t.Fatalf("plan = %v, err = %v", plan, err)
+	}
+}
@@ -20,7 +20,7 @@ func main() {
 	}
 
 	// Clean up test users
-	users, err := admin.ListUsers(ctx)
+	users, err := admin.ListUsers(ctx, nil)
 	if err != nil {
 		fmt.Printf("Failed to list users: %v\n", err)
 		os.Exit(1)
@@ -35,7 +35,7 @@ func main() {
 	}
 
 	// Clean up test folders
-	folders, err := admin.ListFolders(ctx)
+	folders, err := admin.ListFolders(ctx, nil)
 	if err != nil {
 		fmt.Printf("Failed to list folders: %v\n", err)
 		os.Exit(1)
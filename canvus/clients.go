@@ -27,13 +27,16 @@ type UpdateClientRequest struct {
 	// Add other fields as needed
 }
 
-// ListClients retrieves all clients from the Canvus API.
-func (c *Session) ListClients(ctx context.Context) ([]ClientInfo, error) {
+// ListClients retrieves all clients from the Canvus API. If filter is non-nil, results are filtered client-side.
+func (c *Session) ListClients(ctx context.Context, filter *Filter) ([]ClientInfo, error) {
 	var clients []ClientInfo
 	err := c.doRequest(ctx, "GET", "clients", nil, &clients, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListClients: %w", err)
 	}
+	if filter != nil {
+		clients = FilterSlice(clients, filter)
+	}
 	return clients, nil
 }
 
@@ -184,7 +187,7 @@ func NewSessionFromConfig(baseURL, apiKey string) *Session {
 	config.BaseURL = baseURL
 	session := NewSession(config)
 	if apiKey != "" {
-		session.authenticator = &APIKeyAuthenticator{Header: "Private-Token", APIKey: apiKey}
+		session.setAuthenticator(&APIKeyAuthenticator{Header: "Private-Token", APIKey: apiKey})
 	}
 	return session
 }
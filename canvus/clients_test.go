@@ -90,7 +90,7 @@ func TestNewClientFromConfig(t *testing.T) {
 	}
 	// Optionally, test a simple action (e.g., list users)
 	ctx := context.Background()
-	_, err = client.ListUsers(ctx)
+	_, err = client.ListUsers(ctx, nil)
 	if err != nil {
 		t.Errorf("ListUsers failed: %v", err)
 	}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 )
 
-// ListConnectors retrieves all connectors for a given canvas.
-func (s *Session) ListConnectors(ctx context.Context, canvasID string) ([]Connector, error) {
+// ListConnectors retrieves all connectors for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListConnectors(ctx context.Context, canvasID string, filter *Filter) ([]Connector, error) {
 	var connectors []Connector
 	path := fmt.Sprintf("canvases/%s/connectors", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &connectors, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListConnectors: %w", err)
 	}
+	if filter != nil {
+		connectors = FilterSlice(connectors, filter)
+	}
 	return connectors, nil
 }
 
@@ -29,44 +32,48 @@ func (s *Session) GetConnector(ctx context.Context, canvasID, connectorID string
 
 // CreateConnector creates a new connector on a canvas.
 // If req["src"] or req["dst"] is a map (widget JSON), the widget is created first and its ID is used.
+// A map with an "id" and no "widget_type" is treated as a connector end (e.g. {"id": ..., "tip": "solid-equilateral-triangle"}) and sent as-is.
 func (s *Session) CreateConnector(ctx context.Context, canvasID string, req interface{}) (*Connector, error) {
 	m, ok := req.(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("CreateConnector: req must be a map[string]interface{}")
 	}
 	// Helper to create widget if needed
-	resolveEnd := func(key string) (string, error) {
+	resolveEnd := func(key string) (map[string]interface{}, error) {
 		v, ok := m[key]
 		if !ok {
-			return "", fmt.Errorf("CreateConnector: missing %s", key)
+			return nil, fmt.Errorf("CreateConnector: missing %s", key)
 		}
 		// If already a string, treat as ID
 		if id, ok := v.(string); ok {
-			return id, nil
+			return map[string]interface{}{"id": id}, nil
 		}
-		// If map, create widget
+		// If map, either a connector end or widget JSON to create
 		if widgetData, ok := v.(map[string]interface{}); ok {
+			if _, isEnd := widgetData["id"].(string); isEnd && widgetData["widget_type"] == nil {
+				return widgetData, nil
+			}
 			widget, err := s.CreateWidget(ctx, canvasID, widgetData)
 			if err != nil {
-				return "", fmt.Errorf("CreateConnector: failed to create widget for %s: %w", key, err)
+				return nil, fmt.Errorf("CreateConnector: failed to create widget for %s: %w", key, err)
 			}
-			return widget.ID, nil
+			return map[string]interface{}{"id": widget.ID}, nil
 		}
-		return "", fmt.Errorf("CreateConnector: %s must be string or widget JSON", key)
+		return nil, fmt.Errorf("CreateConnector: %s must be string or widget JSON", key)
 	}
 	// Resolve src
-	srcID, err := resolveEnd("src")
+	src, err := resolveEnd("src")
 	if err != nil {
 		return nil, err
 	}
 	// Resolve dst
-	dstID, err := resolveEnd("dst")
+	dst, err := resolveEnd("dst")
 	if err != nil {
 		return nil, err
 	}
 	// Build connector request
-	m["src"] = map[string]interface{}{"id": srcID}
-	m["dst"] = map[string]interface{}{"id": dstID}
+	m["src"] = src
+	m["dst"] = dst
 	var connector Connector
 	path := fmt.Sprintf("canvases/%s/connectors", canvasID)
 	err = s.doRequest(ctx, "POST", path, m, &connector, nil, false)
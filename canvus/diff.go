@@ -0,0 +1,452 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ChangeKind identifies what changed about a widget between two points in time.
+type ChangeKind string
+
+const (
+	ChangeAdded      ChangeKind = "added"
+	ChangeRemoved    ChangeKind = "removed"
+	ChangeMoved      ChangeKind = "moved"      // location changed
+	ChangeResized    ChangeKind = "resized"    // size or scale changed
+	ChangeReparented ChangeKind = "reparented" // parent_id changed
+	ChangeRestyled   ChangeKind = "restyled"   // depth, pinned or background_color changed
+	ChangeText       ChangeKind = "text"       // note text changed
+)
+
+// WidgetChange describes a single change to a widget.
+// For ChangeAdded and ChangeRemoved, Field is empty and New/Old hold the whole Widget.
+// For all other kinds, Field is the JSON field name and Old/New hold its values.
+type WidgetChange struct {
+	Kind       ChangeKind  `json:"kind"`
+	WidgetID   string      `json:"widget_id"`
+	WidgetType string      `json:"widget_type"`
+	Field      string      `json:"field,omitempty"`
+	Old        interface{} `json:"old,omitempty"`
+	New        interface{} `json:"new,omitempty"`
+}
+
+// CanvasDiff is the structured result of comparing two sets of widgets.
+// Additions and field changes follow the order of the "after" set; removals follow
+// the order of the "before" set and come last.
+type CanvasDiff struct {
+	Changes []WidgetChange `json:"changes"`
+
+	// documents holds the JSON Patch documents of the "after" widgets when they carry more
+	// than the Widget encoding, as notes in a DiffSnapshots diff do.
+	documents map[string]map[string]interface{}
+}
+
+// CanvasSnapshot captures the state of a canvas at a point in time.
+// Snapshots are JSON-serializable so they can be stored and diffed later.
+type CanvasSnapshot struct {
+	CanvasID string    `json:"canvas_id"`
+	TakenAt  time.Time `json:"taken_at"`
+	Widgets  []Widget  `json:"widgets"`
+	Notes    []Note    `json:"notes,omitempty"`
+}
+
+// SnapshotCanvas captures the widgets and notes of a canvas for later diffing.
+func (s *Session) SnapshotCanvas(ctx context.Context, canvasID string) (*CanvasSnapshot, error) {
+	widgets, err := s.ListWidgets(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SnapshotCanvas: %w", err)
+	}
+	notes, err := s.ListNotes(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SnapshotCanvas: %w", err)
+	}
+	return &CanvasSnapshot{
+		CanvasID: canvasID,
+		TakenAt:  time.Now().UTC(),
+		Widgets:  widgets,
+		Notes:    notes,
+	}, nil
+}
+
+// DiffCanvasSince compares the live state of a canvas against an earlier snapshot.
+//
+// Usage Example:
+//
+//	snap, _ := session.SnapshotCanvas(ctx, canvasID)
+//	// ... time passes ...
+//	diff, err := session.DiffCanvasSince(ctx, canvasID, snap)
+//	fmt.Println(diff.Summary())
+func (s *Session) DiffCanvasSince(ctx context.Context, canvasID string, snap *CanvasSnapshot) (*CanvasDiff, error) {
+	if snap == nil {
+		return nil, fmt.Errorf("DiffCanvasSince: snapshot is nil")
+	}
+	current, err := s.SnapshotCanvas(ctx, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("DiffCanvasSince: %w", err)
+	}
+	return DiffSnapshots(snap, current), nil
+}
+
+// DiffSnapshots compares two canvas snapshots. In addition to the widget-level changes
+// reported by DiffWidgets, it reports note text and background colour changes.
+func DiffSnapshots(before, after *CanvasSnapshot) *CanvasDiff {
+	var bw, aw []Widget
+	beforeNotes := make(map[string]Note)
+	afterNotes := make(map[string]Note)
+	if before != nil {
+		bw = before.Widgets
+		for _, n := range before.Notes {
+			beforeNotes[n.ID] = n
+		}
+	}
+	if after != nil {
+		aw = after.Widgets
+		for _, n := range after.Notes {
+			afterNotes[n.ID] = n
+		}
+	}
+	d := diffWidgets(bw, aw, func(old, cur Widget) []WidgetChange {
+		o, ok1 := beforeNotes[cur.ID]
+		n, ok2 := afterNotes[cur.ID]
+		if !ok1 || !ok2 {
+			return nil
+		}
+		var changes []WidgetChange
+		if o.Text != n.Text {
+			changes = append(changes, WidgetChange{Kind: ChangeText, WidgetID: cur.ID, WidgetType: cur.WidgetType, Field: "text", Old: o.Text, New: n.Text})
+		}
+		if o.BackgroundColor != n.BackgroundColor {
+			changes = append(changes, WidgetChange{Kind: ChangeRestyled, WidgetID: cur.ID, WidgetType: cur.WidgetType, Field: "background_color", Old: o.BackgroundColor, New: n.BackgroundColor})
+		}
+		return changes
+	})
+	if after != nil {
+		d.documents = snapshotDocuments(after)
+	}
+	return d
+}
+
+// DiffWidgets compares two widget lists (typically from ListWidgets) by widget ID.
+//
+// Usage Example:
+//
+//	before, _ := session.ListWidgets(ctx, canvasID, nil)
+//	// ... time passes ...
+//	after, _ := session.ListWidgets(ctx, canvasID, nil)
+//	diff := canvus.DiffWidgets(before, after)
+//	fmt.Print(diff.String())
+func DiffWidgets(before, after []Widget) *CanvasDiff {
+	return diffWidgets(before, after, nil)
+}
+
+// diffWidgets implements DiffWidgets. If extra is non-nil, its changes are appended
+// after the generic field changes of each widget present in both sets.
+func diffWidgets(before, after []Widget, extra func(old, cur Widget) []WidgetChange) *CanvasDiff {
+	d := &CanvasDiff{}
+	beforeByID := make(map[string]Widget, len(before))
+	for _, w := range before {
+		beforeByID[w.ID] = w
+	}
+	seen := make(map[string]struct{}, len(after))
+	for _, w := range after {
+		seen[w.ID] = struct{}{}
+		old, ok := beforeByID[w.ID]
+		if !ok {
+			d.Changes = append(d.Changes, WidgetChange{Kind: ChangeAdded, WidgetID: w.ID, WidgetType: w.WidgetType, New: w})
+			continue
+		}
+		d.Changes = append(d.Changes, diffWidget(old, w)...)
+		if extra != nil {
+			d.Changes = append(d.Changes, extra(old, w)...)
+		}
+	}
+	for _, w := range before {
+		if _, ok := seen[w.ID]; ok {
+			continue
+		}
+		d.Changes = append(d.Changes, WidgetChange{Kind: ChangeRemoved, WidgetID: w.ID, WidgetType: w.WidgetType, Old: w})
+	}
+	return d
+}
+
+// diffWidget returns the field-level changes between two versions of the same widget.
+func diffWidget(old, cur Widget) []WidgetChange {
+	var changes []WidgetChange
+	add := func(kind ChangeKind, field string, o, n interface{}) {
+		changes = append(changes, WidgetChange{Kind: kind, WidgetID: cur.ID, WidgetType: cur.WidgetType, Field: field, Old: o, New: n})
+	}
+	if !pointEqual(old.Location, cur.Location) {
+		add(ChangeMoved, "location", old.Location, cur.Location)
+	}
+	if !sizeEqual(old.Size, cur.Size) {
+		add(ChangeResized, "size", old.Size, cur.Size)
+	}
+	if !floatEqual(old.Scale, cur.Scale) {
+		add(ChangeResized, "scale", old.Scale, cur.Scale)
+	}
+	if old.ParentID != cur.ParentID {
+		add(ChangeReparented, "parent_id", old.ParentID, cur.ParentID)
+	}
+	if !floatEqual(old.Depth, cur.Depth) {
+		add(ChangeRestyled, "depth", old.Depth, cur.Depth)
+	}
+	if old.Pinned != cur.Pinned {
+		add(ChangeRestyled, "pinned", old.Pinned, cur.Pinned)
+	}
+	return changes
+}
+
+// Empty returns true if the diff contains no changes.
+func (d *CanvasDiff) Empty() bool {
+	return d == nil || len(d.Changes) == 0
+}
+
+// ByKind returns the changes of the given kind, in diff order.
+func (d *CanvasDiff) ByKind(kind ChangeKind) []WidgetChange {
+	if d == nil {
+		return nil
+	}
+	var out []WidgetChange
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// DiffSummary counts changes per kind and the number of distinct widgets affected.
+type DiffSummary struct {
+	Counts          map[ChangeKind]int `json:"counts"`
+	WidgetsAffected int                `json:"widgets_affected"`
+}
+
+// Summary returns per-kind change counts for the diff.
+func (d *CanvasDiff) Summary() DiffSummary {
+	summary := DiffSummary{Counts: make(map[ChangeKind]int)}
+	if d == nil {
+		return summary
+	}
+	widgets := make(map[string]struct{})
+	for _, c := range d.Changes {
+		summary.Counts[c.Kind]++
+		widgets[c.WidgetID] = struct{}{}
+	}
+	summary.WidgetsAffected = len(widgets)
+	return summary
+}
+
+// String renders the summary as a single line, e.g. "3 widgets changed: 1 added, 2 moved".
+func (s DiffSummary) String() string {
+	if s.WidgetsAffected == 0 {
+		return "no changes"
+	}
+	kinds := []ChangeKind{ChangeAdded, ChangeRemoved, ChangeMoved, ChangeResized, ChangeReparented, ChangeRestyled, ChangeText}
+	var parts []string
+	for _, k := range kinds {
+		if n := s.Counts[k]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, k))
+		}
+	}
+	noun := "widgets"
+	if s.WidgetsAffected == 1 {
+		noun = "widget"
+	}
+	return fmt.Sprintf("%d %s changed: %s", s.WidgetsAffected, noun, strings.Join(parts, ", "))
+}
+
+// String renders the diff as human-readable text, one change per line. Lines are
+// prefixed with "+" for added widgets, "-" for removed widgets and "~" for field changes,
+// e.g. `~ Note n2 location: (0, 0) -> (100, 50)`.
+func (d *CanvasDiff) String() string {
+	if d.Empty() {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+ %s %s added", c.WidgetType, c.WidgetID)
+			if w, ok := c.New.(Widget); ok && w.Location != nil {
+				fmt.Fprintf(&b, " at %s", formatDiffValue(w.Location))
+			}
+			b.WriteString("\n")
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- %s %s removed\n", c.WidgetType, c.WidgetID)
+		default:
+			fmt.Fprintf(&b, "~ %s %s %s: %s -> %s\n", c.WidgetType, c.WidgetID, c.Field, formatDiffValue(c.Old), formatDiffValue(c.New))
+		}
+	}
+	return b.String()
+}
+
+// JSONPatchOp is a single RFC 6902 JSON Patch operation.
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch renders the diff as RFC 6902 operations against a document of the form
+// {"widgets": {"<widget id>": <widget>}}, as produced by WidgetsByID for a DiffWidgets diff
+// and by SnapshotByID for a DiffSnapshots diff.
+func (d *CanvasDiff) JSONPatch() []JSONPatchOp {
+	var ops []JSONPatchOp
+	if d == nil {
+		return ops
+	}
+	for _, c := range d.Changes {
+		path := "/widgets/" + escapeJSONPointer(c.WidgetID)
+		switch c.Kind {
+		case ChangeAdded:
+			var value interface{} = c.New
+			if doc, ok := d.documents[c.WidgetID]; ok {
+				value = doc
+			} else if w, ok := c.New.(Widget); ok {
+				value = widgetDocument(w)
+			}
+			ops = append(ops, JSONPatchOp{Op: "add", Path: path, Value: value})
+		case ChangeRemoved:
+			ops = append(ops, JSONPatchOp{Op: "remove", Path: path})
+		default:
+			fieldPath := path + "/" + escapeJSONPointer(c.Field)
+			if isNilValue(c.New) {
+				ops = append(ops, JSONPatchOp{Op: "remove", Path: fieldPath})
+			} else if isNilValue(c.Old) {
+				ops = append(ops, JSONPatchOp{Op: "add", Path: fieldPath, Value: c.New})
+			} else {
+				ops = append(ops, JSONPatchOp{Op: "replace", Path: fieldPath, Value: c.New})
+			}
+		}
+	}
+	return ops
+}
+
+// MarshalJSONPatch renders the diff as an RFC 6902 JSON Patch document.
+func (d *CanvasDiff) MarshalJSONPatch() ([]byte, error) {
+	ops := d.JSONPatch()
+	if ops == nil {
+		ops = []JSONPatchOp{}
+	}
+	return json.Marshal(ops)
+}
+
+// WidgetsByID returns the document that the JSONPatch of a DiffWidgets diff applies to. Each
+// widget is its JSON encoding plus the type-specific fields it was decoded with.
+func WidgetsByID(widgets []Widget) map[string]map[string]map[string]interface{} {
+	byID := make(map[string]map[string]interface{}, len(widgets))
+	for _, w := range widgets {
+		byID[w.ID] = widgetDocument(w)
+	}
+	return map[string]map[string]map[string]interface{}{"widgets": byID}
+}
+
+// SnapshotByID returns the document that the JSONPatch of a DiffSnapshots diff applies to:
+// the snapshot's widgets as in WidgetsByID, with each note's text and background colour.
+//
+// Usage Example:
+//
+//	doc := canvus.SnapshotByID(before)
+//	patch, _ := canvus.DiffSnapshots(before, after).MarshalJSONPatch()
+//	// applying patch to doc yields canvus.SnapshotByID(after)
+func SnapshotByID(snap *CanvasSnapshot) map[string]map[string]map[string]interface{} {
+	if snap == nil {
+		return WidgetsByID(nil)
+	}
+	return map[string]map[string]map[string]interface{}{"widgets": snapshotDocuments(snap)}
+}
+
+// snapshotDocuments returns the widget documents of a snapshot by ID, with the text and
+// background colour of the matching notes added.
+func snapshotDocuments(snap *CanvasSnapshot) map[string]map[string]interface{} {
+	notes := make(map[string]Note, len(snap.Notes))
+	for _, n := range snap.Notes {
+		notes[n.ID] = n
+	}
+	byID := make(map[string]map[string]interface{}, len(snap.Widgets))
+	for _, w := range snap.Widgets {
+		doc := widgetDocument(w)
+		if n, ok := notes[w.ID]; ok {
+			doc["text"] = n.Text
+			doc["background_color"] = n.BackgroundColor
+		}
+		byID[w.ID] = doc
+	}
+	return byID
+}
+
+// widgetDocument returns w as a JSON object: its encoded fields, plus the type-specific fields
+// kept from the JSON it was decoded from.
+func widgetDocument(w Widget) map[string]interface{} {
+	doc := map[string]interface{}{}
+	if data, err := json.Marshal(w); err == nil {
+		_ = json.Unmarshal(data, &doc)
+	}
+	for k, v := range w.extra {
+		if _, ok := doc[k]; !ok {
+			doc[k] = v
+		}
+	}
+	return doc
+}
+
+// escapeJSONPointer escapes a reference token per RFC 6901.
+func escapeJSONPointer(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
+
+// formatDiffValue renders a change value compactly for text output.
+func formatDiffValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "<none>"
+	case *Point:
+		if val == nil {
+			return "<none>"
+		}
+		return fmt.Sprintf("(%g, %g)", val.X, val.Y)
+	case *Size:
+		if val == nil {
+			return "<none>"
+		}
+		return fmt.Sprintf("%gx%g", val.Width, val.Height)
+	case string:
+		return fmt.Sprintf("%q", val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// isNilValue reports whether v is nil or a typed nil pointer.
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// floatEqual compares two floats with a small tolerance to absorb JSON round-tripping noise.
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9
+}
+
+func pointEqual(a, b *Point) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return floatEqual(a.X, b.X) && floatEqual(a.Y, b.Y)
+}
+
+func sizeEqual(a, b *Size) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return floatEqual(a.Width, b.Width) && floatEqual(a.Height, b.Height)
+}
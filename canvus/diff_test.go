@@ -0,0 +1,190 @@
+package canvus
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffWidgets(t *testing.T) {
+	before := []Widget{
+		{ID: "n1", WidgetType: "Note", Location: &Point{X: 0, Y: 0}, Size: &Size{Width: 100, Height: 100}, Scale: 1},
+		{ID: "n2", WidgetType: "Note", ParentID: "sc", Location: &Point{X: 10, Y: 10}, Size: &Size{Width: 50, Height: 50}, Scale: 1},
+		{ID: "i1", WidgetType: "Image", Location: &Point{X: 5, Y: 5}, Scale: 1},
+	}
+	after := []Widget{
+		{ID: "n1", WidgetType: "Note", Location: &Point{X: 20, Y: 30}, Size: &Size{Width: 200, Height: 100}, Scale: 1},
+		{ID: "n2", WidgetType: "Note", ParentID: "a1", Location: &Point{X: 10, Y: 10}, Size: &Size{Width: 50, Height: 50}, Scale: 1, Pinned: true},
+		{ID: "b1", WidgetType: "Browser", Location: &Point{X: 1, Y: 2}, Scale: 1},
+	}
+
+	d := DiffWidgets(before, after)
+
+	var kinds []string
+	for _, c := range d.Changes {
+		kinds = append(kinds, c.WidgetID+":"+string(c.Kind)+":"+c.Field)
+	}
+	want := []string{
+		"n1:moved:location",
+		"n1:resized:size",
+		"n2:reparented:parent_id",
+		"n2:restyled:pinned",
+		"b1:added:",
+		"i1:removed:",
+	}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("changes = %v, want %v", kinds, want)
+	}
+
+	if got := d.Summary().String(); got != "4 widgets changed: 1 added, 1 removed, 1 moved, 1 resized, 1 reparented, 1 restyled" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if !strings.Contains(d.String(), "~ Note n1 location: (0, 0) -> (20, 30)") {
+		t.Errorf("String() missing move line:\n%s", d.String())
+	}
+	if !strings.Contains(d.String(), "+ Browser b1 added at (1, 2)") {
+		t.Errorf("String() missing add line:\n%s", d.String())
+	}
+}
+
+func TestDiffWidgetsNoChanges(t *testing.T) {
+	ws := []Widget{{ID: "n1", WidgetType: "Note", Location: &Point{X: 1, Y: 1}}}
+	d := DiffWidgets(ws, ws)
+	if !d.Empty() {
+		t.Errorf("expected empty diff, got %+v", d.Changes)
+	}
+	if d.Summary().String() != "no changes" {
+		t.Errorf("unexpected summary %q", d.Summary().String())
+	}
+}
+
+func TestDiffSnapshotsNoteText(t *testing.T) {
+	w := Widget{ID: "n1", WidgetType: "Note"}
+	before := &CanvasSnapshot{Widgets: []Widget{w}, Notes: []Note{{ID: "n1", Text: "old", BackgroundColor: "FFFFFFFF"}}}
+	after := &CanvasSnapshot{Widgets: []Widget{w}, Notes: []Note{{ID: "n1", Text: "new", BackgroundColor: "FF0000FF"}}}
+
+	d := DiffSnapshots(before, after)
+	if len(d.ByKind(ChangeText)) != 1 {
+		t.Fatalf("expected 1 text change, got %+v", d.Changes)
+	}
+	restyled := d.ByKind(ChangeRestyled)
+	if len(restyled) != 1 || restyled[0].Field != "background_color" {
+		t.Fatalf("expected background_color restyle, got %+v", restyled)
+	}
+}
+
+func TestCanvasDiffJSONPatch(t *testing.T) {
+	before := []Widget{
+		{ID: "a/b", WidgetType: "Note", Location: &Point{X: 0, Y: 0}},
+		{ID: "gone", WidgetType: "Note"},
+	}
+	after := []Widget{
+		{ID: "a/b", WidgetType: "Note", Location: &Point{X: 5, Y: 5}},
+		{ID: "new", WidgetType: "Note"},
+	}
+	b, err := DiffWidgets(before, after).MarshalJSONPatch()
+	if err != nil {
+		t.Fatalf("MarshalJSONPatch: %v", err)
+	}
+	var ops []JSONPatchOp
+	if err := json.Unmarshal(b, &ops); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(ops) != 3 {
+		t.Fatalf("expected 3 ops, got %d: %s", len(ops), b)
+	}
+	if ops[0].Op != "replace" || ops[0].Path != "/widgets/a~1b/location" {
+		t.Errorf("unexpected first op %+v", ops[0])
+	}
+	if ops[1].Op != "add" || ops[1].Path != "/widgets/new" {
+		t.Errorf("unexpected second op %+v", ops[1])
+	}
+	if ops[2].Op != "remove" || ops[2].Path != "/widgets/gone" {
+		t.Errorf("unexpected third op %+v", ops[2])
+	}
+}
+
+func TestCanvasDiffJSONPatchApplies(t *testing.T) {
+	before := &CanvasSnapshot{
+		Widgets: []Widget{
+			{ID: "n1", WidgetType: "Note", Location: &Point{X: 0, Y: 0}, Scale: 1},
+			{ID: "i1", WidgetType: "Image", Scale: 1},
+		},
+		Notes: []Note{{ID: "n1", Text: "old", BackgroundColor: "FFFFFFFF"}},
+	}
+	after := &CanvasSnapshot{
+		Widgets: []Widget{
+			{ID: "n1", WidgetType: "Note", Location: &Point{X: 5, Y: 5}, Scale: 1, Pinned: true},
+			{ID: "n2", WidgetType: "Note", Scale: 1},
+		},
+		Notes: []Note{
+			{ID: "n1", Text: "new", BackgroundColor: "FF0000FF"},
+			{ID: "n2", Text: "added", BackgroundColor: "FFFFFFFF"},
+		},
+	}
+	patch, err := DiffSnapshots(before, after).MarshalJSONPatch()
+	if err != nil {
+		t.Fatalf("MarshalJSONPatch: %v", err)
+	}
+	got := applyTestJSONPatch(t, SnapshotByID(before), patch)
+	want := applyTestJSONPatch(t, SnapshotByID(after), []byte("[]"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patched document = %v\nwant %v\npatch %s", got, want, patch)
+	}
+}
+
+// applyTestJSONPatch applies the add, remove and replace operations of an RFC 6902 patch to
+// the JSON encoding of doc, failing the test if a path does not exist.
+func applyTestJSONPatch(t *testing.T, doc interface{}, patch []byte) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatal(err)
+	}
+	var ops []struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range ops {
+		tokens := strings.Split(op.Path, "/")[1:]
+		parent := root
+		for _, tok := range tokens[:len(tokens)-1] {
+			next, ok := parent[unescapeTestJSONPointer(tok)].(map[string]interface{})
+			if !ok {
+				t.Fatalf("%s %s: parent does not exist", op.Op, op.Path)
+			}
+			parent = next
+		}
+		key := unescapeTestJSONPointer(tokens[len(tokens)-1])
+		_, exists := parent[key]
+		switch op.Op {
+		case "add":
+			parent[key] = op.Value
+		case "replace", "remove":
+			if !exists {
+				t.Fatalf("%s %s: path does not exist", op.Op, op.Path)
+			}
+			if op.Op == "remove" {
+				delete(parent, key)
+			} else {
+				parent[key] = op.Value
+			}
+		default:
+			t.Fatalf("unexpected op %s", op.Op)
+		}
+	}
+	return root
+}
+
+func unescapeTestJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
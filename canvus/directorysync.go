@@ -0,0 +1,540 @@
+package canvus
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirectoryPerson is one person in an HR/directory export. Email is the matching key
+// and is compared case-insensitively.
+type DirectoryPerson struct {
+	Email  string   `json:"email"`
+	Name   string   `json:"name"`
+	Admin  bool     `json:"admin"`
+	Groups []string `json:"groups,omitempty"` // group names
+	// Active defaults to true; inactive people are treated like people missing from the export.
+	Active *bool `json:"active,omitempty"`
+}
+
+func (p DirectoryPerson) active() bool {
+	return p.Active == nil || *p.Active
+}
+
+// DirectorySource supplies the people to synchronise.
+type DirectorySource interface {
+	People(ctx context.Context) ([]DirectoryPerson, error)
+}
+
+// StaticDirectory is an in-memory DirectorySource.
+type StaticDirectory []DirectoryPerson
+
+// People implements DirectorySource.
+func (d StaticDirectory) People(ctx context.Context) ([]DirectoryPerson, error) {
+	return d, nil
+}
+
+// DirectoryFile is a DirectorySource backed by a .csv or .json export on disk.
+type DirectoryFile string
+
+// People implements DirectorySource, choosing the parser from the file extension.
+func (path DirectoryFile) People(ctx context.Context) ([]DirectoryPerson, error) {
+	f, err := os.Open(string(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.HasSuffix(strings.ToLower(string(path)), ".json") {
+		return ParseDirectoryJSON(f)
+	}
+	return ParseDirectoryCSV(f)
+}
+
+// ParseDirectoryCSV reads people from CSV with a header row. Recognised columns (any order,
+// case-insensitive) are email (required), name, admin, groups and active. Groups are separated
+// by ";" and booleans accept true/false, yes/no and 1/0.
+func ParseDirectoryCSV(r io.Reader) ([]DirectoryPerson, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ParseDirectoryCSV: reading header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["email"]; !ok {
+		return nil, fmt.Errorf("ParseDirectoryCSV: missing email column")
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var people []DirectoryPerson
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ParseDirectoryCSV: line %d: %w", line, err)
+		}
+		p := DirectoryPerson{Email: get(rec, "email"), Name: get(rec, "name")}
+		if p.Email == "" {
+			return nil, fmt.Errorf("ParseDirectoryCSV: line %d: empty email", line)
+		}
+		if p.Admin, err = parseDirectoryBool(get(rec, "admin"), false); err != nil {
+			return nil, fmt.Errorf("ParseDirectoryCSV: line %d: admin: %w", line, err)
+		}
+		active, err := parseDirectoryBool(get(rec, "active"), true)
+		if err != nil {
+			return nil, fmt.Errorf("ParseDirectoryCSV: line %d: active: %w", line, err)
+		}
+		p.Active = &active
+		for _, g := range strings.Split(get(rec, "groups"), ";") {
+			if g = strings.TrimSpace(g); g != "" {
+				p.Groups = append(p.Groups, g)
+			}
+		}
+		people = append(people, p)
+	}
+	return people, nil
+}
+
+func parseDirectoryBool(s string, def bool) (bool, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return def, nil
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// ParseDirectoryJSON reads people from a JSON array of DirectoryPerson objects.
+func ParseDirectoryJSON(r io.Reader) ([]DirectoryPerson, error) {
+	var people []DirectoryPerson
+	if err := json.NewDecoder(r).Decode(&people); err != nil {
+		return nil, fmt.Errorf("ParseDirectoryJSON: %w", err)
+	}
+	for i, p := range people {
+		if p.Email == "" {
+			return nil, fmt.Errorf("ParseDirectoryJSON: entry %d: empty email", i)
+		}
+	}
+	return people, nil
+}
+
+// DirectorySyncOptions controls SyncDirectory. A nil *DirectorySyncOptions uses the zero value.
+type DirectorySyncOptions struct {
+	DryRun bool // plan and report without changing anything
+	// CreateGroups creates groups named in the export that do not exist yet. Without it,
+	// memberships for unknown groups are reported as skipped.
+	CreateGroups bool
+	// ManagedGroups are groups whose membership is reconciled even if nobody in the export lists them.
+	// Groups named in the export are always managed; all other groups are left untouched.
+	ManagedGroups []string
+	// Protected lists emails that are never blocked or demoted (e.g. break-glass admin accounts).
+	// The logged-in user is never blocked either.
+	Protected []string
+	// ApproveNewUsers marks created users as approved.
+	ApproveNewUsers bool
+}
+
+// DirectorySyncActionType is the kind of change in a DirectorySyncPlan.
+type DirectorySyncActionType string
+
+const (
+	SyncCreateUser   DirectorySyncActionType = "create_user"
+	SyncUpdateUser   DirectorySyncActionType = "update_user"
+	SyncBlockUser    DirectorySyncActionType = "block_user"
+	SyncUnblockUser  DirectorySyncActionType = "unblock_user"
+	SyncCreateGroup  DirectorySyncActionType = "create_group"
+	SyncAddMember    DirectorySyncActionType = "add_member"
+	SyncRemoveMember DirectorySyncActionType = "remove_member"
+)
+
+// DirectorySyncAction is one planned change. Users and groups are identified by email and name
+// so that actions can refer to users and groups created earlier in the same plan.
+type DirectorySyncAction struct {
+	Type    DirectorySyncActionType `json:"type"`
+	Email   string                  `json:"email,omitempty"`
+	UserID  int64                   `json:"user_id,omitempty"` // 0 for users created by this plan
+	Group   string                  `json:"group,omitempty"`
+	GroupID int                     `json:"group_id,omitempty"` // 0 for groups created by this plan
+	Name    *string                 `json:"name,omitempty"`     // create/update
+	Admin   *bool                   `json:"admin,omitempty"`    // create/update
+	Reason  string                  `json:"reason"`
+}
+
+func (a DirectorySyncAction) String() string {
+	switch a.Type {
+	case SyncCreateGroup:
+		return fmt.Sprintf("%s %q: %s", a.Type, a.Group, a.Reason)
+	case SyncAddMember, SyncRemoveMember:
+		return fmt.Sprintf("%s %s -> %q: %s", a.Type, a.Email, a.Group, a.Reason)
+	}
+	return fmt.Sprintf("%s %s: %s", a.Type, a.Email, a.Reason)
+}
+
+// DirectorySyncPlan is the ordered list of changes needed to match the directory.
+type DirectorySyncPlan struct {
+	Actions []DirectorySyncAction `json:"actions"`
+	Skipped []string              `json:"skipped,omitempty"` // notes about things deliberately not changed
+	opts    DirectorySyncOptions
+}
+
+// String renders the plan one action per line.
+func (p *DirectorySyncPlan) String() string {
+	var b strings.Builder
+	for _, a := range p.Actions {
+		b.WriteString(a.String())
+		b.WriteString("\n")
+	}
+	for _, s := range p.Skipped {
+		fmt.Fprintf(&b, "skip: %s\n", s)
+	}
+	if len(p.Actions) == 0 {
+		b.WriteString("directory is in sync\n")
+	}
+	return b.String()
+}
+
+// DirectorySyncResult is the outcome of one action.
+type DirectorySyncResult struct {
+	DirectorySyncAction
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DirectorySyncReport is an audit record of a SyncDirectory run.
+type DirectorySyncReport struct {
+	StartedAt  time.Time                       `json:"started_at"`
+	FinishedAt time.Time                       `json:"finished_at"`
+	DryRun     bool                            `json:"dry_run"`
+	Results    []DirectorySyncResult           `json:"results"`
+	Skipped    []string                        `json:"skipped,omitempty"`
+	Counts     map[DirectorySyncActionType]int `json:"counts"` // applied (or, in a dry run, planned) actions by type
+	Failed     int                             `json:"failed"`
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *DirectorySyncReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Summary returns a one-line summary such as "create_user=2 block_user=1 (0 failed)".
+func (r *DirectorySyncReport) Summary() string {
+	keys := make([]string, 0, len(r.Counts))
+	for k := range r.Counts {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, r.Counts[DirectorySyncActionType(k)]))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no changes")
+	}
+	return fmt.Sprintf("%s (%d failed)", strings.Join(parts, " "), r.Failed)
+}
+
+// PlanDirectorySync compares the directory with the server's users, groups and memberships.
+func (s *Session) PlanDirectorySync(ctx context.Context, source DirectorySource, opts *DirectorySyncOptions) (*DirectorySyncPlan, error) {
+	plan := &DirectorySyncPlan{}
+	if opts != nil {
+		plan.opts = *opts
+	}
+	people, err := source.People(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PlanDirectorySync: reading source: %w", err)
+	}
+	users, err := s.ListUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("PlanDirectorySync: %w", err)
+	}
+	groups, err := s.ListGroups(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("PlanDirectorySync: %w", err)
+	}
+
+	key := func(email string) string { return strings.ToLower(strings.TrimSpace(email)) }
+	protected := map[string]bool{}
+	for _, e := range plan.opts.Protected {
+		protected[key(e)] = true
+	}
+	selfID := s.UserID()
+	wanted := map[string]DirectoryPerson{}
+	for _, p := range people {
+		if _, dup := wanted[key(p.Email)]; dup {
+			return nil, fmt.Errorf("PlanDirectorySync: duplicate email %q in source", p.Email)
+		}
+		wanted[key(p.Email)] = p
+	}
+	byEmail := map[string]User{}
+	emailByID := map[int64]string{}
+	for _, u := range users {
+		byEmail[key(u.Email)] = u
+		emailByID[u.ID] = u.Email
+	}
+
+	// Users: create, update, unblock, block.
+	emails := make([]string, 0, len(wanted))
+	for e := range wanted {
+		emails = append(emails, e)
+	}
+	sort.Strings(emails)
+	for _, e := range emails {
+		p := wanted[e]
+		u, exists := byEmail[e]
+		if !p.active() {
+			if exists && !u.Blocked {
+				plan.addBlock(u, "marked inactive in directory", protected[e] || (selfID != 0 && u.ID == selfID))
+			}
+			continue
+		}
+		if !exists {
+			name, admin := p.Name, p.Admin
+			plan.Actions = append(plan.Actions, DirectorySyncAction{Type: SyncCreateUser, Email: p.Email, Name: &name, Admin: &admin, Reason: "in directory, missing on server"})
+			continue
+		}
+		a := DirectorySyncAction{Type: SyncUpdateUser, Email: u.Email, UserID: u.ID}
+		var why []string
+		if p.Name != "" && p.Name != u.Name {
+			name := p.Name
+			a.Name = &name
+			why = append(why, fmt.Sprintf("name %q -> %q", u.Name, p.Name))
+		}
+		if p.Admin != u.Admin {
+			if !p.Admin && protected[e] {
+				plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s is protected; admin flag kept", u.Email))
+			} else {
+				admin := p.Admin
+				a.Admin = &admin
+				why = append(why, fmt.Sprintf("admin %t -> %t", u.Admin, p.Admin))
+			}
+		}
+		if len(why) > 0 {
+			a.Reason = strings.Join(why, ", ")
+			plan.Actions = append(plan.Actions, a)
+		}
+		if u.Blocked {
+			plan.Actions = append(plan.Actions, DirectorySyncAction{Type: SyncUnblockUser, Email: u.Email, UserID: u.ID, Reason: "active in directory"})
+		}
+	}
+	for _, u := range users {
+		if _, ok := wanted[key(u.Email)]; !ok && !u.Blocked {
+			plan.addBlock(u, "not in directory", protected[key(u.Email)] || (selfID != 0 && u.ID == selfID))
+		}
+	}
+
+	// Groups: every group named in the export, plus ManagedGroups.
+	groupByName := map[string]Group{}
+	for _, g := range groups {
+		groupByName[g.Name] = g
+	}
+	desired := map[string]map[string]bool{} // group name -> member emails (lowercased)
+	for _, name := range plan.opts.ManagedGroups {
+		desired[name] = map[string]bool{}
+	}
+	for e, p := range wanted {
+		if !p.active() {
+			continue
+		}
+		for _, g := range p.Groups {
+			if desired[g] == nil {
+				desired[g] = map[string]bool{}
+			}
+			desired[g][e] = true
+		}
+	}
+	names := make([]string, 0, len(desired))
+	for n := range desired {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g, exists := groupByName[name]
+		current := map[string]bool{}
+		if exists {
+			members, err := s.ListGroupMembers(ctx, g.ID)
+			if err != nil {
+				return nil, fmt.Errorf("PlanDirectorySync: %w", err)
+			}
+			for _, m := range members {
+				email := m.Email
+				if email == "" {
+					email = emailByID[int64(m.ID)]
+				}
+				current[key(email)] = true
+				if _, known := byEmail[key(email)]; known && !desired[name][key(email)] {
+					plan.Actions = append(plan.Actions, DirectorySyncAction{Type: SyncRemoveMember, Email: email, UserID: int64(m.ID), Group: name, GroupID: g.ID, Reason: "not a member in directory"})
+				}
+			}
+		} else if plan.opts.CreateGroups {
+			plan.Actions = append(plan.Actions, DirectorySyncAction{Type: SyncCreateGroup, Group: name, Reason: "named in directory, missing on server"})
+		} else {
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf("group %q does not exist; enable CreateGroups to create it", name))
+			continue
+		}
+		members := make([]string, 0, len(desired[name]))
+		for e := range desired[name] {
+			members = append(members, e)
+		}
+		sort.Strings(members)
+		for _, e := range members {
+			if current[e] {
+				continue
+			}
+			a := DirectorySyncAction{Type: SyncAddMember, Email: wanted[e].Email, Group: name, GroupID: g.ID, Reason: "member in directory"}
+			if u, ok := byEmail[e]; ok {
+				a.UserID = u.ID
+			}
+			plan.Actions = append(plan.Actions, a)
+		}
+	}
+	plan.sortActions()
+	return plan, nil
+}
+
+func (p *DirectorySyncPlan) addBlock(u User, reason string, protected bool) {
+	if protected {
+		p.Skipped = append(p.Skipped, fmt.Sprintf("%s is protected; not blocked (%s)", u.Email, reason))
+		return
+	}
+	p.Actions = append(p.Actions, DirectorySyncAction{Type: SyncBlockUser, Email: u.Email, UserID: u.ID, Reason: reason})
+}
+
+// sortActions orders actions so that users and groups exist before memberships reference them.
+func (p *DirectorySyncPlan) sortActions() {
+	order := map[DirectorySyncActionType]int{
+		SyncCreateUser: 0, SyncUpdateUser: 1, SyncUnblockUser: 2, SyncCreateGroup: 3,
+		SyncAddMember: 4, SyncRemoveMember: 5, SyncBlockUser: 6,
+	}
+	sort.SliceStable(p.Actions, func(i, j int) bool {
+		return order[p.Actions[i].Type] < order[p.Actions[j].Type]
+	})
+}
+
+// ApplyDirectorySync executes a plan. Failed actions are recorded in the report and do not stop
+// the run; memberships for a user or group that failed to be created fail as well.
+func (s *Session) ApplyDirectorySync(ctx context.Context, plan *DirectorySyncPlan) *DirectorySyncReport {
+	report := &DirectorySyncReport{StartedAt: time.Now().UTC(), Skipped: plan.Skipped, Counts: map[DirectorySyncActionType]int{}}
+	userIDs := map[string]int64{}
+	groupIDs := map[string]int{}
+	for _, a := range plan.Actions {
+		res := DirectorySyncResult{DirectorySyncAction: a}
+		err := ctx.Err()
+		if err == nil {
+			err = s.applySyncAction(ctx, &res.DirectorySyncAction, plan.opts, userIDs, groupIDs)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			report.Failed++
+		} else {
+			res.Applied = true
+			report.Counts[a.Type]++
+		}
+		report.Results = append(report.Results, res)
+	}
+	report.FinishedAt = time.Now().UTC()
+	return report
+}
+
+func (s *Session) applySyncAction(ctx context.Context, a *DirectorySyncAction, opts DirectorySyncOptions, userIDs map[string]int64, groupIDs map[string]int) error {
+	key := strings.ToLower(a.Email)
+	if a.UserID == 0 && a.Email != "" {
+		a.UserID = userIDs[key]
+	}
+	if a.GroupID == 0 && a.Group != "" {
+		a.GroupID = groupIDs[a.Group]
+	}
+	switch a.Type {
+	case SyncCreateUser:
+		req := CreateUserRequest{Email: a.Email, Admin: a.Admin}
+		if a.Name != nil {
+			req.Name = *a.Name
+		}
+		if opts.ApproveNewUsers {
+			approved := true
+			req.Approved = &approved
+		}
+		u, err := s.CreateUser(ctx, req)
+		if err != nil {
+			return err
+		}
+		a.UserID = u.ID
+		userIDs[key] = u.ID
+		return nil
+	case SyncUpdateUser:
+		_, err := s.UpdateUser(ctx, a.UserID, UpdateUserRequest{Name: a.Name, Admin: a.Admin})
+		return err
+	case SyncBlockUser:
+		return s.BlockUser(ctx, a.UserID)
+	case SyncUnblockUser:
+		return s.UnblockUser(ctx, a.UserID)
+	case SyncCreateGroup:
+		g, err := s.CreateGroup(ctx, CreateGroupRequest{Name: a.Group})
+		if err != nil {
+			return err
+		}
+		a.GroupID = g.ID
+		groupIDs[a.Group] = g.ID
+		return nil
+	case SyncAddMember, SyncRemoveMember:
+		if a.UserID == 0 {
+			return fmt.Errorf("user %s was not created", a.Email)
+		}
+		if a.GroupID == 0 {
+			return fmt.Errorf("group %q was not created", a.Group)
+		}
+		if a.Type == SyncAddMember {
+			return s.AddUserToGroup(ctx, a.GroupID, a.UserID)
+		}
+		return s.RemoveUserFromGroup(ctx, a.GroupID, a.UserID)
+	}
+	return fmt.Errorf("unknown action %q", a.Type)
+}
+
+// SyncDirectory reconciles users and groups with a directory export: it creates missing users,
+// updates names and admin flags, blocks departed users with BlockUser (users are never deleted),
+// and fixes membership of managed groups. With opts.DryRun, the plan is reported without changes.
+//
+// Usage Example:
+//
+//	report, err := session.SyncDirectory(ctx, canvus.DirectoryFile("hr-export.csv"), &canvus.DirectorySyncOptions{
+//		DryRun:    true,
+//		Protected: []string{"admin@example.com"},
+//	})
+//	fmt.Println(report.Summary())
+//	_ = report.WriteJSON(os.Stdout)
+func (s *Session) SyncDirectory(ctx context.Context, source DirectorySource, opts *DirectorySyncOptions) (*DirectorySyncReport, error) {
+	plan, err := s.PlanDirectorySync(ctx, source, opts)
+	if err != nil {
+		return nil, fmt.Errorf("SyncDirectory: %w", err)
+	}
+	if !plan.opts.DryRun {
+		return s.ApplyDirectorySync(ctx, plan), nil
+	}
+	now := time.Now().UTC()
+	report := &DirectorySyncReport{StartedAt: now, FinishedAt: now, DryRun: true, Skipped: plan.Skipped, Counts: map[DirectorySyncActionType]int{}}
+	for _, a := range plan.Actions {
+		report.Results = append(report.Results, DirectorySyncResult{DirectorySyncAction: a})
+		report.Counts[a.Type]++
+	}
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package canvus
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const directoryCSV = `Email,Name,Admin,Groups,Active
+alice@example.com,Alice Liddell,yes,Design;Ops,
+BOB@example.com,Bob,no,Design,true
+carol@example.com,Carol,no,,false
+`
+
+func seedDirectory(f *fakeCanvus) {
+	f.seed("users", map[string]interface{}{"id": float64(1), "email": "admin@example.com", "name": "Admin", "admin": true})
+	f.seed("users", map[string]interface{}{"id": float64(2), "email": "alice@example.com", "name": "Alice", "admin": false})
+	f.seed("users", map[string]interface{}{"id": float64(3), "email": "carol@example.com", "name": "Carol"})
+	f.seed("users", map[string]interface{}{"id": float64(4), "email": "dave@example.com", "name": "Dave", "blocked": false})
+	f.seed("groups", map[string]interface{}{"id": float64(10), "name": "Design"})
+	f.seed("groups", map[string]interface{}{"id": float64(11), "name": "Sales"})
+	f.seed("groups/10/members", map[string]interface{}{"id": float64(4), "email": "dave@example.com"})
+	f.seed("groups/11/members", map[string]interface{}{"id": float64(4), "email": "dave@example.com"})
+}
+
+func TestParseDirectoryCSV(t *testing.T) {
+	people, err := ParseDirectoryCSV(strings.NewReader(directoryCSV))
+	if err != nil {
+		t.Fatalf("ParseDirectoryCSV: %v", err)
+	}
+	if len(people) != 3 || !people[0].Admin || len(people[0].Groups) != 2 || people[2].active() {
+		t.Errorf("unexpected people: %+v", people)
+	}
+	if _, err := ParseDirectoryCSV(strings.NewReader("name\nx\n")); err == nil {
+		t.Error("expected error for missing email column")
+	}
+}
+
+func TestSyncDirectory(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedDirectory(fake)
+	s := fake.session()
+	ctx := context.Background()
+	people, _ := ParseDirectoryCSV(strings.NewReader(directoryCSV))
+	opts := &DirectorySyncOptions{DryRun: true, CreateGroups: true, Protected: []string{"Admin@example.com"}}
+
+	report, err := s.SyncDirectory(ctx, StaticDirectory(people), opts)
+	if err != nil {
+		t.Fatalf("SyncDirectory dry run: %v", err)
+	}
+	if got := report.Summary(); got != "add_member=3 block_user=2 create_group=1 create_user=1 remove_member=1 update_user=1 (0 failed)" {
+		t.Errorf("dry-run summary = %s", got)
+	}
+	if fake.callCount("POST") != 0 || fake.callCount("PATCH") != 0 {
+		t.Fatal("dry run must not change anything")
+	}
+
+	opts.DryRun = false
+	report, err = s.SyncDirectory(ctx, StaticDirectory(people), opts)
+	if err != nil {
+		t.Fatalf("SyncDirectory: %v", err)
+	}
+	if report.Failed != 0 {
+		var buf bytes.Buffer
+		_ = report.WriteJSON(&buf)
+		t.Fatalf("failures:\n%s", buf.String())
+	}
+
+	users := map[string]map[string]interface{}{}
+	for _, u := range fake.items("users") {
+		users[u["email"].(string)] = u
+	}
+	if u := users["alice@example.com"]; u["name"] != "Alice Liddell" || u["admin"] != true {
+		t.Errorf("alice not updated: %v", u)
+	}
+	if users["BOB@example.com"] == nil {
+		t.Error("bob not created")
+	}
+	if users["carol@example.com"]["blocked"] != true || users["dave@example.com"]["blocked"] != true {
+		t.Error("departed users not blocked")
+	}
+	if users["admin@example.com"]["blocked"] == true {
+		t.Error("protected user blocked")
+	}
+	if n := len(fake.items("groups/10/members")); n != 2 {
+		t.Errorf("Design members = %d, want alice and bob", n)
+	}
+	if n := len(fake.items("groups/11/members")); n != 1 {
+		t.Error("unmanaged group Sales must be left alone")
+	}
+
+	plan, err := s.PlanDirectorySync(ctx, StaticDirectory(people), opts)
+	if err != nil {
+		t.Fatalf("PlanDirectorySync: %v", err)
+	}
+	if len(plan.Actions) != 0 {
+		t.Errorf("second run not idempotent:\n%s", plan)
+	}
+}
@@ -0,0 +1,421 @@
+package canvus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var dotHTMLTag = regexp.MustCompile(`<[^>]*>`)
+
+// ParseDOT parses a Graphviz DOT graph or digraph. Nodes take their label and fill colour
+// ("fillcolor", or "color" with style=filled) from node statements and "node [...]" defaults;
+// edges take "label", "color", "dir", "arrowhead" and "arrowtail". "rankdir" sets the layout
+// direction. Subgraphs are flattened, ports are ignored.
+//
+// Usage Example:
+//
+//	g, err := canvus.ParseDOT(`digraph { rankdir=LR; a [label="Start" fillcolor=lightblue style=filled]; a -> b [label="next"] }`)
+func ParseDOT(src string) (*Graph, error) {
+	toks, err := dotTokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("ParseDOT: %w", err)
+	}
+	p := &dotParser{toks: toks, g: &Graph{Direction: "TB"}, nodeAttrs: map[string]map[string]string{}}
+	if err := p.graph(); err != nil {
+		return nil, fmt.Errorf("ParseDOT: %w", err)
+	}
+	return p.g, nil
+}
+
+type dotToken struct {
+	text   string
+	quoted bool // an ID from a quoted or HTML string, never a keyword or operator
+}
+
+func dotTokenize(src string) ([]dotToken, error) {
+	var toks []dotToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(rs) && rs[i+1] == '/', c == '#' && (i == 0 || rs[i-1] == '\n'):
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			for i += 2; i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(rs) {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(rs) && rs[i] != '"'; i++ {
+				if rs[i] == '\\' && i+1 < len(rs) {
+					i++
+					switch rs[i] {
+					case 'n', 'l', 'r':
+						b.WriteRune('\n')
+					case 'N':
+						b.WriteString(`\N`) // the node's own name; see finish
+					case '\n':
+					default:
+						b.WriteRune(rs[i])
+					}
+					continue
+				}
+				b.WriteRune(rs[i])
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			toks = append(toks, dotToken{text: b.String(), quoted: true})
+		case c == '<':
+			depth, start := 0, i
+			for ; i < len(rs); i++ {
+				if rs[i] == '<' {
+					depth++
+				} else if rs[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("unterminated HTML string")
+			}
+			html := string(rs[start+1 : i])
+			i++
+			html = strings.NewReplacer("<br/>", "\n", "<br>", "\n", "<BR/>", "\n", "<BR>", "\n").Replace(html)
+			toks = append(toks, dotToken{text: strings.TrimSpace(dotHTMLTag.ReplaceAllString(html, "")), quoted: true})
+		case c == '-' && i+1 < len(rs) && (rs[i+1] == '>' || rs[i+1] == '-'):
+			toks = append(toks, dotToken{text: string(rs[i : i+2])})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:", c):
+			toks = append(toks, dotToken{text: string(c)})
+			i++
+		case c == '_' || c == '.' || c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+			for i < len(rs) && (rs[i] == '_' || rs[i] == '.' || unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) ||
+				(rs[i] == '-' && i == start)) {
+				i++
+			}
+			toks = append(toks, dotToken{text: string(rs[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return toks, nil
+}
+
+type dotParser struct {
+	toks      []dotToken
+	pos       int
+	g         *Graph
+	nodeAttrs map[string]map[string]string // explicit attributes per node, merged over defaults
+}
+
+type dotScope struct {
+	node map[string]string
+	edge map[string]string
+}
+
+func (p *dotParser) peek() (dotToken, bool) {
+	if p.pos >= len(p.toks) {
+		return dotToken{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+// isOp reports whether the next token is the unquoted operator or keyword op (case-insensitive).
+func (p *dotParser) isOp(op string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.text, op)
+}
+
+func (p *dotParser) expect(op string) error {
+	if !p.isOp(op) {
+		t, _ := p.peek()
+		return fmt.Errorf("expected %q, got %q", op, t.text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) id() (string, error) {
+	t, ok := p.peek()
+	if !ok || (!t.quoted && strings.ContainsAny(t.text, "{}[]=;,:") && len(t.text) == 1) || (!t.quoted && (t.text == "->" || t.text == "--")) {
+		return "", fmt.Errorf("expected identifier, got %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *dotParser) graph() error {
+	if p.isOp("strict") {
+		p.pos++
+	}
+	switch {
+	case p.isOp("digraph"):
+		p.g.Directed = true
+	case p.isOp("graph"):
+	default:
+		t, _ := p.peek()
+		return fmt.Errorf("expected graph or digraph, got %q", t.text)
+	}
+	p.pos++
+	if !p.isOp("{") {
+		if _, err := p.id(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if _, err := p.stmts(dotScope{node: map[string]string{}, edge: map[string]string{}}); err != nil {
+		return err
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+	p.finish()
+	return nil
+}
+
+// stmts parses statements up to a closing brace and returns the nodes they mention.
+func (p *dotParser) stmts(scope dotScope) ([]string, error) {
+	var nodes []string
+	for {
+		if _, ok := p.peek(); !ok {
+			return nil, fmt.Errorf("unexpected end of input")
+		}
+		if p.isOp("}") {
+			return nodes, nil
+		}
+		if p.isOp(";") || p.isOp(",") {
+			p.pos++
+			continue
+		}
+		switch {
+		case p.isOp("graph") || p.isOp("node") || p.isOp("edge"):
+			kind := strings.ToLower(p.toks[p.pos].text)
+			p.pos++
+			attrs, err := p.attrList()
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "graph":
+				p.graphAttrs(attrs)
+			case "node":
+				mergeAttrs(scope.node, attrs)
+			case "edge":
+				mergeAttrs(scope.edge, attrs)
+			}
+			continue
+		}
+		if p.pos+1 < len(p.toks) && !p.toks[p.pos].quoted && p.toks[p.pos+1].text == "=" && !p.toks[p.pos+1].quoted {
+			key := p.toks[p.pos].text
+			p.pos += 2
+			val, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			p.graphAttrs(map[string]string{key: val})
+			continue
+		}
+		mentioned, err := p.edgeOrNode(scope)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, mentioned...)
+	}
+}
+
+// operand parses a node ID or a subgraph and returns the node IDs it stands for.
+func (p *dotParser) operand(scope dotScope) ([]string, bool, error) {
+	if p.isOp("subgraph") || p.isOp("{") {
+		if p.isOp("subgraph") {
+			p.pos++
+			if !p.isOp("{") {
+				if _, err := p.id(); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, false, err
+		}
+		inner := dotScope{node: copyAttrs(scope.node), edge: copyAttrs(scope.edge)}
+		nodes, err := p.stmts(inner)
+		if err != nil {
+			return nil, false, err
+		}
+		return nodes, true, p.expect("}")
+	}
+	id, err := p.id()
+	if err != nil {
+		return nil, false, err
+	}
+	for p.isOp(":") { // port and compass point
+		p.pos++
+		if _, err := p.id(); err != nil {
+			return nil, false, err
+		}
+	}
+	p.declare(id, scope.node)
+	return []string{id}, false, nil
+}
+
+func (p *dotParser) edgeOrNode(scope dotScope) ([]string, error) {
+	left, sub, err := p.operand(scope)
+	if err != nil {
+		return nil, err
+	}
+	all := append([]string(nil), left...)
+	var hops [][2][]string
+	for p.isOp("->") || p.isOp("--") {
+		p.pos++
+		right, _, err := p.operand(scope)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, [2][]string{left, right})
+		all = append(all, right...)
+		left = right
+	}
+	var attrs map[string]string
+	if p.isOp("[") {
+		if attrs, err = p.attrList(); err != nil {
+			return nil, err
+		}
+	}
+	if len(hops) == 0 {
+		if !sub && attrs != nil {
+			mergeAttrs(p.nodeAttrs[left[0]], attrs)
+		}
+		return all, nil
+	}
+	ea := copyAttrs(scope.edge)
+	mergeAttrs(ea, attrs)
+	for _, h := range hops {
+		for _, from := range h[0] {
+			for _, to := range h[1] {
+				p.g.Edges = append(p.g.Edges, p.edge(from, to, ea))
+			}
+		}
+	}
+	return all, nil
+}
+
+func (p *dotParser) attrList() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.isOp("[") {
+		p.pos++
+		for !p.isOp("]") {
+			if p.isOp(",") || p.isOp(";") {
+				p.pos++
+				continue
+			}
+			key, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			val := "true"
+			if p.isOp("=") {
+				p.pos++
+				if val, err = p.id(); err != nil {
+					return nil, err
+				}
+			}
+			attrs[strings.ToLower(key)] = val
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
+
+// declare adds a node the first time it is seen, snapshotting the node defaults in scope.
+func (p *dotParser) declare(id string, defaults map[string]string) {
+	if _, ok := p.nodeAttrs[id]; ok {
+		return
+	}
+	p.g.node(id)
+	p.nodeAttrs[id] = copyAttrs(defaults)
+}
+
+func (p *dotParser) graphAttrs(attrs map[string]string) {
+	if d, ok := attrs["rankdir"]; ok {
+		switch strings.ToUpper(d) {
+		case "LR", "RL", "BT", "TB":
+			p.g.Direction = strings.ToUpper(d)
+		}
+	}
+}
+
+func (p *dotParser) edge(from, to string, attrs map[string]string) GraphEdge {
+	e := GraphEdge{From: from, To: to, Label: attrs["label"], SrcTip: tipNone, DstTip: tipNone}
+	if c, ok := graphColor(attrs["color"]); ok {
+		e.Color = c
+	}
+	dir := attrs["dir"]
+	if dir == "" {
+		dir = "none"
+		if p.g.Directed {
+			dir = "forward"
+		}
+	}
+	if dir == "forward" || dir == "both" {
+		e.DstTip = tipArrow
+	}
+	if dir == "back" || dir == "both" {
+		e.SrcTip = tipArrow
+	}
+	if attrs["arrowhead"] == "none" {
+		e.DstTip = tipNone
+	}
+	if attrs["arrowtail"] == "none" {
+		e.SrcTip = tipNone
+	}
+	return e
+}
+
+// finish applies the collected node attributes.
+func (p *dotParser) finish() {
+	for i := range p.g.Nodes {
+		n := &p.g.Nodes[i]
+		attrs := p.nodeAttrs[n.ID]
+		if l, ok := attrs["label"]; ok && l != `\N` {
+			n.Label = l
+		}
+		color := attrs["fillcolor"]
+		if color == "" && strings.Contains(attrs["style"], "filled") {
+			color = attrs["color"]
+		}
+		if c, ok := graphColor(color); ok {
+			n.Color = c
+		}
+	}
+}
+
+func copyAttrs(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func mergeAttrs(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
@@ -0,0 +1,311 @@
+package canvus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCanvus is a minimal in-memory Canvus API used by unit tests.
+// Resources are stored per collection path (e.g. "canvases/c1/notes") and support
+// generic list/get/create/patch/delete. Routes registered with handle take precedence.
+type fakeCanvus struct {
+	mu     sync.Mutex
+	nextID int
+	store  map[string][]map[string]interface{}
+	routes map[string]func(w http.ResponseWriter, r *http.Request, body map[string]interface{})
+	calls  []string
+	server *httptest.Server
+}
+
+// fakeSingletons are sub-resources that hold a single object rather than a list.
+var fakeSingletons = map[string]bool{
+	"background":   true,
+	"colorpresets": true,
+	"permissions":  true,
+}
+
+// fakeWidgetCollections maps canvas sub-collections to the widget_type reported by ListWidgets.
+var fakeWidgetCollections = map[string]string{
+	"notes":      "Note",
+	"anchors":    "Anchor",
+	"browsers":   "Browser",
+	"images":     "Image",
+	"pdfs":       "PDF",
+	"videos":     "Video",
+	"connectors": "Connector",
+}
+
+func newFakeCanvus(t *testing.T) *fakeCanvus {
+	f := &fakeCanvus{
+		store:  make(map[string][]map[string]interface{}),
+		routes: make(map[string]func(http.ResponseWriter, *http.Request, map[string]interface{})),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// session returns a Session pointed at the fake server with retries disabled.
+func (f *fakeCanvus) session() *Session {
+	cfg := DefaultSessionConfig()
+	cfg.BaseURL = f.server.URL
+	cfg.MaxRetries = 0
+	cfg.HTTPClient = &http.Client{}
+	return NewSession(cfg)
+}
+
+// handle registers a custom handler for an exact "METHOD path" pair.
+func (f *fakeCanvus) handle(method, path string, h func(w http.ResponseWriter, r *http.Request, body map[string]interface{})) {
+	f.routes[method+" "+path] = h
+}
+
+// seed adds a resource to a collection and returns it.
+func (f *fakeCanvus) seed(collection string, obj map[string]interface{}) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := obj["id"]; !ok {
+		obj["id"] = f.newID(collection)
+	}
+	f.decorate(collection, obj)
+	f.store[collection] = append(f.store[collection], obj)
+	return obj
+}
+
+// items returns a copy of the resources in a collection.
+func (f *fakeCanvus) items(collection string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}(nil), f.store[collection]...)
+}
+
+// callCount returns how many requests matched the "METHOD path" prefix.
+func (f *fakeCanvus) callCount(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeCanvus) newID(collection string) interface{} {
+	f.nextID++
+	last := collection[strings.LastIndex(collection, "/")+1:]
+	switch last {
+	case "users", "groups":
+		return float64(100 + f.nextID)
+	}
+	return fmt.Sprintf("%s-%d", strings.TrimSuffix(last, "s"), f.nextID)
+}
+
+func (f *fakeCanvus) decorate(collection string, obj map[string]interface{}) {
+	last := collection[strings.LastIndex(collection, "/")+1:]
+	if wt, ok := fakeWidgetCollections[last]; ok {
+		obj["widget_type"] = wt
+	}
+	if last == "canvases" || last == "access-tokens" {
+		if _, ok := obj["created_at"]; !ok {
+			obj["created_at"] = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	if last == "access-tokens" {
+		if _, ok := obj["plain_token"]; !ok {
+			obj["plain_token"] = fmt.Sprintf("secret-%v", obj["id"])
+		}
+	}
+}
+
+func (f *fakeCanvus) serve(w http.ResponseWriter, r *http.Request) {
+	p := strings.Trim(r.URL.Path, "/")
+	body := decodeFakeBody(r)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, r.Method+" "+p)
+	h, ok := f.routes[r.Method+" "+p]
+	f.mu.Unlock()
+	if ok {
+		h(w, r, body)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(p, "/")
+
+	// GET canvases/{id}/widgets aggregates the typed widget collections.
+	if r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "canvases" && parts[2] == "widgets" {
+		out := []map[string]interface{}{}
+		names := make([]string, 0, len(fakeWidgetCollections))
+		for name := range fakeWidgetCollections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			out = append(out, f.store["canvases/"+parts[1]+"/"+name]...)
+		}
+		writeFakeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	// Singleton resources such as canvases/{id}/background are stored as a one-element collection.
+	if fakeSingletons[parts[len(parts)-1]] {
+		obj := map[string]interface{}{}
+		if len(f.store[p]) > 0 {
+			obj = f.store[p][0]
+		}
+		switch r.Method {
+		case http.MethodPatch:
+			for k, v := range body {
+				obj[k] = v
+			}
+		case http.MethodPost, http.MethodPut:
+			obj = body
+		}
+		f.store[p] = []map[string]interface{}{obj}
+		writeFakeJSON(w, http.StatusOK, obj)
+		return
+	}
+
+	// POST {collection}/{id}/move re-parents an item; moving into "trash.*" marks it in_trash.
+	if r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "move" {
+		for _, obj := range f.store[parts[0]] {
+			if fakeIDString(obj["id"]) == parts[1] {
+				dst, _ := body["folder_id"].(string)
+				obj["folder_id"] = dst
+				obj["in_trash"] = strings.HasPrefix(dst, "trash.")
+				writeFakeJSON(w, http.StatusOK, obj)
+				return
+			}
+		}
+		writeFakeJSON(w, http.StatusNotFound, map[string]interface{}{"code": "not_found", "message": "not found: " + p})
+		return
+	}
+
+	// POST users/{id}/block|unblock|approve toggles the matching user flag.
+	if r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "users" {
+		flags := map[string][2]interface{}{"block": {"blocked", true}, "unblock": {"blocked", false}, "approve": {"approved", true}}
+		if flag, ok := flags[parts[2]]; ok {
+			for _, obj := range f.store["users"] {
+				if fakeIDString(obj["id"]) == parts[1] {
+					obj[flag[0].(string)] = flag[1]
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+			}
+			writeFakeJSON(w, http.StatusNotFound, map[string]interface{}{"code": "not_found", "message": "not found: " + p})
+			return
+		}
+	}
+
+	if len(parts)%2 == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			items := f.store[p]
+			if items == nil {
+				items = []map[string]interface{}{}
+			}
+			writeFakeJSON(w, http.StatusOK, items)
+		case http.MethodPost:
+			if body == nil {
+				body = map[string]interface{}{}
+			}
+			// Group members are added by user ID, so keep the ID the client sent.
+			if _, ok := body["id"]; !ok || !strings.HasSuffix(p, "/members") {
+				body["id"] = f.newID(p)
+			}
+			f.decorate(p, body)
+			f.store[p] = append(f.store[p], body)
+			writeFakeJSON(w, http.StatusOK, body)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	collection := strings.Join(parts[:len(parts)-1], "/")
+	id := parts[len(parts)-1]
+	idx := -1
+	for i, obj := range f.store[collection] {
+		if fakeIDString(obj["id"]) == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		writeFakeJSON(w, http.StatusNotFound, map[string]interface{}{"code": "not_found", "message": "not found: " + p})
+		return
+	}
+	obj := f.store[collection][idx]
+	switch r.Method {
+	case http.MethodGet:
+		writeFakeJSON(w, http.StatusOK, obj)
+	case http.MethodPatch:
+		for k, v := range body {
+			obj[k] = v
+		}
+		writeFakeJSON(w, http.StatusOK, obj)
+	case http.MethodDelete:
+		f.store[collection] = append(f.store[collection][:idx], f.store[collection][idx+1:]...)
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// fakeIDString renders numeric JSON IDs without a decimal point.
+func fakeIDString(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+func decodeFakeBody(r *http.Request) map[string]interface{} {
+	if r.Body == nil {
+		return nil
+	}
+	ct, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var raw []byte
+	if strings.HasPrefix(ct, "multipart/") {
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FormName() == "json" {
+				raw, _ = io.ReadAll(part)
+			}
+		}
+	} else {
+		raw, _ = io.ReadAll(r.Body)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil
+	}
+	return body
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,146 @@
+package canvus
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// jsonFields returns the exported fields of a struct as a map keyed by their JSON names, for
+// AsMap. Nested structs become maps, so dotted paths such as "location.x" reach into them;
+// slices become []interface{} and nil pointers, maps and slices are left out.
+func jsonFields(v interface{}) map[string]interface{} {
+	m, _ := jsonFieldValue(reflect.ValueOf(v)).(map[string]interface{})
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m
+}
+
+func jsonFieldValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonFieldValue(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		addJSONFields(m, v)
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = jsonFieldValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			m[it.Key().String()] = jsonFieldValue(it.Value())
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// addJSONFields adds the fields of struct v to m, flattening embedded structs as
+// encoding/json does.
+func addJSONFields(m map[string]interface{}, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				addJSONFields(m, fv)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if val := jsonFieldValue(fv); val != nil {
+			m[name] = val
+		}
+	}
+}
+
+// UnmarshalJSON decodes a widget and keeps the type-specific fields Widget has no field for
+// (a note's text, a browser's url, an image's hash, ...) so that AsMap can filter on them.
+func (w *Widget) UnmarshalJSON(data []byte) error {
+	type plain Widget
+	if err := json.Unmarshal(data, (*plain)(w)); err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	w.extra = raw
+	return nil
+}
+
+// AsMap returns the Note as a map[string]interface{} for filtering.
+func (n Note) AsMap() map[string]interface{} { return jsonFields(n) }
+
+// AsMap returns the Image as a map[string]interface{} for filtering.
+func (i Image) AsMap() map[string]interface{} { return jsonFields(i) }
+
+// AsMap returns the PDF as a map[string]interface{} for filtering.
+func (p PDF) AsMap() map[string]interface{} { return jsonFields(p) }
+
+// AsMap returns the Video as a map[string]interface{} for filtering.
+func (v Video) AsMap() map[string]interface{} { return jsonFields(v) }
+
+// AsMap returns the Browser as a map[string]interface{} for filtering.
+func (b Browser) AsMap() map[string]interface{} { return jsonFields(b) }
+
+// AsMap returns the Anchor as a map[string]interface{} for filtering.
+func (a Anchor) AsMap() map[string]interface{} { return jsonFields(a) }
+
+// AsMap returns the Connector as a map[string]interface{} for filtering; the ends are
+// addressed as "src.id", "dst.tip" and so on.
+func (c Connector) AsMap() map[string]interface{} { return jsonFields(c) }
+
+// AsMap returns the User as a map[string]interface{} for filtering.
+func (u User) AsMap() map[string]interface{} { return jsonFields(u) }
+
+// AsMap returns the Group as a map[string]interface{} for filtering.
+func (g Group) AsMap() map[string]interface{} { return jsonFields(g) }
+
+// AsMap returns the Folder as a map[string]interface{} for filtering. The parent folder is
+// "folder_id", as in the API.
+func (f Folder) AsMap() map[string]interface{} { return jsonFields(f) }
+
+// AsMap returns the ClientInfo as a map[string]interface{} for filtering.
+func (c ClientInfo) AsMap() map[string]interface{} { return jsonFields(c) }
+
+// AsMap returns the Workspace as a map[string]interface{} for filtering.
+func (w Workspace) AsMap() map[string]interface{} { return jsonFields(w) }
+
+// AsMap returns the AccessToken as a map[string]interface{} for filtering.
+func (t AccessToken) AsMap() map[string]interface{} { return jsonFields(t) }
@@ -0,0 +1,88 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestAsMapUsesJSONNames(t *testing.T) {
+	note := Note{ID: "n1", BackgroundColor: "FFCC00FF", Location: &Point{X: 10, Y: 20}, Scale: 2}
+	m := note.AsMap()
+	if m["background_color"] != "FFCC00FF" || m["scale"] != 2.0 {
+		t.Fatalf("note map = %v", m)
+	}
+	if _, ok := m["size"]; ok {
+		t.Errorf("nil size should be left out: %v", m)
+	}
+	if !MustParseFilter(`location.x == 10 && !exists(size)`).Match(m) {
+		t.Errorf("expression did not match %v", m)
+	}
+
+	conn := Connector{ID: "c1", Src: &ConnectorEnd{ID: "a", Tip: tipNone}, Dst: &ConnectorEnd{ID: "b", Tip: tipArrow}}
+	if !MustParseFilter(`src.id == "a" && dst.tip == "` + tipArrow + `"`).Match(conn.AsMap()) {
+		t.Errorf("connector ends not addressable: %v", conn.AsMap())
+	}
+	if got := (Folder{ID: "f2", ParentID: "f1"}).AsMap()["folder_id"]; got != "f1" {
+		t.Errorf("folder_id = %v", got)
+	}
+
+	users := []User{{ID: 1, Name: "Admin", Admin: true}, {ID: 2, Name: "Alice"}, {ID: 3, Name: "Root", Admin: true, Blocked: true}}
+	admins := FilterSlice(users, MustParseFilter(`admin && !blocked`))
+	if len(admins) != 1 || admins[0].ID != 1 {
+		t.Errorf("admins = %+v", admins)
+	}
+	if got := FilterSlice(users, &Filter{Criteria: map[string]interface{}{"id": 2}}); len(got) != 1 || got[0].Name != "Alice" {
+		t.Errorf("id criterion = %+v", got)
+	}
+}
+
+func TestWidgetAsMapKeepsTypeSpecificFields(t *testing.T) {
+	var widgets []Widget
+	data := `[
+		{"id": "n1", "widget_type": "Note", "text": "Hello", "background_color": "FFCC00FF", "scale": 1},
+		{"id": "b1", "widget_type": "Browser", "url": "https://example.com", "scale": 1,
+		 "annotations": [{"id": "a1", "widget_type": "Annotation", "line_color": "FF0000FF"}]}
+	]`
+	if err := json.Unmarshal([]byte(data), &widgets); err != nil {
+		t.Fatal(err)
+	}
+	if widgets[0].ID != "n1" || widgets[1].WidgetType != "Browser" || len(widgets[1].Annotations) != 1 {
+		t.Fatalf("decoded widgets = %+v", widgets)
+	}
+	if got := FilterSlice(widgets, &Filter{Criteria: map[string]interface{}{"background_color": "FFCC00FF"}}); len(got) != 1 || got[0].ID != "n1" {
+		t.Errorf("background_color filter = %+v", got)
+	}
+	if got := FilterSlice(widgets, MustParseFilter(`url =~ "example" && exists(annotations)`)); len(got) != 1 || got[0].ID != "b1" {
+		t.Errorf("url/annotations filter = %+v", got)
+	}
+}
+
+func TestListMethodsApplyFilter(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "a", "background_color": "FFCC00FF"})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n2", "text": "b", "background_color": "81D4FAFF"})
+	fake.seed("users", map[string]interface{}{"id": float64(1), "email": "admin@example.com", "admin": true})
+	fake.seed("users", map[string]interface{}{"id": float64(2), "email": "alice@example.com"})
+	s := fake.session()
+	ctx := context.Background()
+
+	notes, err := s.ListNotes(ctx, "c1", &Filter{Criteria: map[string]interface{}{"background_color": "81D4FAFF"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].ID != "n2" {
+		t.Errorf("ListNotes = %+v", notes)
+	}
+	users, err := s.ListUsers(ctx, MustParseFilter("admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Email != "admin@example.com" {
+		t.Errorf("ListUsers = %+v", users)
+	}
+	all, err := s.ListUsers(ctx, nil)
+	if err != nil || len(all) != 2 {
+		t.Errorf("unfiltered ListUsers = %+v, %v", all, err)
+	}
+}
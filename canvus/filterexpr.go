@@ -0,0 +1,569 @@
+package canvus
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParseFilter compiles a filter expression into a Filter usable anywhere a *Filter is accepted.
+//
+// Expressions compare fields of the object's AsMap, addressed by name or dotted path
+// ("location.x", "$.size.width"):
+//
+//	widget_type == "Note" && scale > 1.5
+//	name =~ "^Sprint [0-9]+$" || state in ["archived", "hidden"]
+//	!exists(parent_id) and not pinned
+//	modified_at >= "2024-06-01" && created_at < now-7d
+//
+// Operators are == (or =), !=, <, <=, >, >=, =~ and !~ (regular expressions), in and not in
+// (lists), with &&/and, ||/or, !/not and parentheses. exists(path) tests presence and a bare
+// path tests truthiness. Numbers compare numerically whatever their Go type; strings that are
+// dates (RFC 3339 or YYYY-MM-DD) compare as times, as do now and now±duration (s, m, h, d, w).
+// A comparison on a missing field is false, except == null.
+//
+// Usage Example:
+//
+//	f, err := canvus.ParseFilter(`widget_type == "Note" && scale > 1.5`)
+//	widgets, err := session.ListWidgets(ctx, canvasID, f)
+func ParseFilter(expr string) (*Filter, error) {
+	toks, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("ParseFilter: %w", err)
+	}
+	p := &filterParser{toks: toks}
+	node, err := p.or()
+	if err == nil && p.peek().kind != filterEOF {
+		err = fmt.Errorf("unexpected %s at offset %d", p.peek(), p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ParseFilter: %w", err)
+	}
+	return &Filter{expr: node, source: expr}, nil
+}
+
+// MustParseFilter is like ParseFilter but panics on error. Use it for constant expressions.
+func MustParseFilter(expr string) *Filter {
+	f, err := ParseFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the filter's expression, if it was built with ParseFilter.
+func (f *Filter) String() string {
+	return f.source
+}
+
+type filterNode interface {
+	eval(obj map[string]interface{}) bool
+}
+
+type filterAnd struct{ l, r filterNode }
+type filterOr struct{ l, r filterNode }
+type filterNot struct{ n filterNode }
+type filterExists struct{ path string }
+type filterTruthy struct{ path string }
+
+type filterCompare struct {
+	path  string
+	op    string // ==, !=, <, <=, >, >=, =~, !~, in, not in
+	value interface{}
+	list  []interface{}
+	re    *regexp.Regexp
+}
+
+// filterNow is now plus an offset, evaluated when the filter runs.
+type filterNow struct{ offset time.Duration }
+
+func (n filterAnd) eval(o map[string]interface{}) bool { return n.l.eval(o) && n.r.eval(o) }
+func (n filterOr) eval(o map[string]interface{}) bool  { return n.l.eval(o) || n.r.eval(o) }
+func (n filterNot) eval(o map[string]interface{}) bool { return !n.n.eval(o) }
+
+func (n filterExists) eval(o map[string]interface{}) bool {
+	_, ok := filterLookup(o, n.path)
+	return ok
+}
+
+func (n filterTruthy) eval(o map[string]interface{}) bool {
+	v, ok := filterLookup(o, n.path)
+	if !ok || v == nil {
+		return false
+	}
+	if f, ok := filterNumber(v); ok {
+		return f != 0
+	}
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+func (n filterCompare) eval(o map[string]interface{}) bool {
+	actual, ok := filterLookup(o, n.path)
+	if n.value == nil && n.list == nil && n.re == nil {
+		// == null and != null
+		isNull := !ok || actual == nil
+		return isNull == (n.op == "==")
+	}
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "=~", "!~":
+		s, isStr := actual.(string)
+		return isStr && n.re.MatchString(s) == (n.op == "=~")
+	case "in", "not in":
+		found := false
+		for _, v := range n.list {
+			if filterEqual(actual, v) {
+				found = true
+				break
+			}
+		}
+		return found == (n.op == "in")
+	case "==":
+		return filterEqual(actual, n.value)
+	case "!=":
+		return !filterEqual(actual, n.value)
+	}
+	c, ok := filterCompareValues(actual, n.value)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// filterLookup resolves a field name or dotted path.
+func filterLookup(obj map[string]interface{}, path string) (interface{}, bool) {
+	if v, ok := obj[path]; ok {
+		return v, true
+	}
+	if !strings.HasPrefix(path, "$.") {
+		path = "$." + path
+	}
+	return getByJSONPath(obj, path)
+}
+
+// filterNumber converts any Go numeric type to float64.
+func filterNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+var filterTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func filterTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case filterNow:
+		return time.Now().Add(t.offset), true
+	case string:
+		for _, layout := range filterTimeLayouts {
+			if tm, err := time.Parse(layout, t); err == nil {
+				return tm, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// filterCompareValues orders two values as numbers, times or strings.
+func filterCompareValues(a, b interface{}) (int, bool) {
+	if af, ok := filterNumber(a); ok {
+		bf, ok := filterNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	}
+	if at, ok := filterTime(a); ok {
+		if bt, ok := filterTime(b); ok {
+			return at.Compare(bt), true
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return strings.Compare(as, bs), true
+		}
+	}
+	return 0, false
+}
+
+// filterEqual compares values, treating all numeric types alike and dates by instant.
+func filterEqual(a, b interface{}) bool {
+	if c, ok := filterCompareValues(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Lexer
+
+type filterTokKind int
+
+const (
+	filterEOF filterTokKind = iota
+	filterIdent
+	filterString
+	filterNum
+	filterDuration
+	filterOp
+)
+
+type filterTok struct {
+	kind filterTokKind
+	text string
+	pos  int
+}
+
+func (t filterTok) String() string {
+	if t.kind == filterEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "=", "!", "(", ")", "[", "]", ","}
+
+func lexFilter(s string) ([]filterTok, error) {
+	var toks []filterTok
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(s) && s[end] != byte(c) {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			body := s[i+1 : end]
+			text := body
+			if c == '"' {
+				var err error
+				if text, err = strconv.Unquote(`"` + body + `"`); err != nil {
+					return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+				}
+			} else {
+				text = strings.ReplaceAll(body, `\'`, `'`)
+			}
+			toks = append(toks, filterTok{kind: filterString, text: text, pos: i})
+			i = end + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			end := i
+			for end < len(s) && (unicode.IsDigit(rune(s[end])) || s[end] == '.' || s[end] == 'e' || s[end] == 'E') {
+				end++
+			}
+			kind := filterNum
+			if end < len(s) && unicode.IsLetter(rune(s[end])) {
+				for end < len(s) && (unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end]))) {
+					end++
+				}
+				kind = filterDuration
+			}
+			toks = append(toks, filterTok{kind: kind, text: s[i:end], pos: i})
+			i = end
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			end := i
+			for end < len(s) && (unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end])) || strings.ContainsRune("_$.-", rune(s[end]))) {
+				if s[end] == '-' && !(end+1 < len(s) && (unicode.IsLetter(rune(s[end+1])) || s[end+1] == '_')) {
+					break
+				}
+				end++
+			}
+			toks = append(toks, filterTok{kind: filterIdent, text: s[i:end], pos: i})
+			i = end
+		default:
+			matched := false
+			for _, op := range filterOps {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, filterTok{kind: filterOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				if c == '-' || c == '+' {
+					toks = append(toks, filterTok{kind: filterOp, text: string(c), pos: i})
+					i++
+					continue
+				}
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(toks, filterTok{kind: filterEOF, pos: len(s)}), nil
+}
+
+// Parser
+
+type filterParser struct {
+	toks []filterTok
+	i    int
+}
+
+func (p *filterParser) peek() filterTok { return p.toks[p.i] }
+
+func (p *filterParser) next() filterTok {
+	t := p.toks[p.i]
+	if t.kind != filterEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators or keywords.
+func (p *filterParser) accept(words ...string) bool {
+	t := p.peek()
+	if t.kind != filterOp && t.kind != filterIdent {
+		return false
+	}
+	for _, w := range words {
+		if t.text == w || (t.kind == filterIdent && strings.EqualFold(t.text, w)) {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *filterParser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q, found %s at offset %d", op, p.peek(), p.peek().pos)
+	}
+	return nil
+}
+
+func (p *filterParser) or() (filterNode, error) {
+	l, err := p.and()
+	for err == nil && p.accept("||", "or") {
+		var r filterNode
+		if r, err = p.and(); err == nil {
+			l = filterOr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *filterParser) and() (filterNode, error) {
+	l, err := p.unary()
+	for err == nil && p.accept("&&", "and") {
+		var r filterNode
+		if r, err = p.unary(); err == nil {
+			l = filterAnd{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *filterParser) unary() (filterNode, error) {
+	if p.accept("!", "not") {
+		n, err := p.unary()
+		return filterNot{n}, err
+	}
+	if p.accept("(") {
+		n, err := p.or()
+		if err == nil {
+			err = p.expect(")")
+		}
+		return n, err
+	}
+	t := p.next()
+	if t.kind != filterIdent {
+		return nil, fmt.Errorf("expected a field, found %s at offset %d", t, t.pos)
+	}
+	if strings.EqualFold(t.text, "exists") && p.accept("(") {
+		f := p.next()
+		if f.kind != filterIdent {
+			return nil, fmt.Errorf("expected a field, found %s at offset %d", f, f.pos)
+		}
+		return filterExists{f.text}, p.expect(")")
+	}
+	return p.comparison(t.text)
+}
+
+func (p *filterParser) comparison(path string) (filterNode, error) {
+	op := p.peek()
+	switch {
+	case p.accept("==", "="):
+		return p.compareValue(path, "==")
+	case p.accept("!=", "<", "<=", ">", ">="):
+		return p.compareValue(path, op.text)
+	case p.accept("=~", "!~"):
+		t := p.next()
+		if t.kind != filterString {
+			return nil, fmt.Errorf("expected a regular expression string, found %s at offset %d", t, t.pos)
+		}
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at offset %d: %w", t.pos, err)
+		}
+		return filterCompare{path: path, op: op.text, re: re}, nil
+	case p.accept("in"):
+		list, err := p.list()
+		return filterCompare{path: path, op: "in", list: list}, err
+	case op.kind == filterIdent && strings.EqualFold(op.text, "not") && p.i+1 < len(p.toks) && strings.EqualFold(p.toks[p.i+1].text, "in"):
+		p.i += 2
+		list, err := p.list()
+		return filterCompare{path: path, op: "not in", list: list}, err
+	}
+	return filterTruthy{path}, nil
+}
+
+func (p *filterParser) compareValue(path, op string) (filterNode, error) {
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("null can only be compared with == or !=")
+	}
+	return filterCompare{path: path, op: op, value: v}, nil
+}
+
+func (p *filterParser) list() ([]interface{}, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	list := []interface{}{}
+	if p.accept("]") {
+		return list, nil
+	}
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+		if p.accept("]") {
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// value parses a literal: string, number, true, false, null or now[±duration].
+func (p *filterParser) value() (interface{}, error) {
+	neg := false
+	if p.accept("-") {
+		neg = true
+	}
+	t := p.next()
+	switch t.kind {
+	case filterString:
+		if !neg {
+			return t.text, nil
+		}
+	case filterNum:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at offset %d", t, t.pos)
+		}
+		if neg {
+			f = -f
+		}
+		return f, nil
+	case filterIdent:
+		switch strings.ToLower(t.text) {
+		case "true", "false":
+			if !neg {
+				return strings.EqualFold(t.text, "true"), nil
+			}
+		case "null":
+			if !neg {
+				return nil, nil
+			}
+		case "now":
+			if neg {
+				break
+			}
+			n := filterNow{}
+			if sign := p.peek(); p.accept("+", "-") {
+				d := p.next()
+				if d.kind != filterDuration {
+					return nil, fmt.Errorf("expected a duration such as 7d, found %s at offset %d", d, d.pos)
+				}
+				dur, err := parseFilterDuration(d.text)
+				if err != nil {
+					return nil, fmt.Errorf("invalid duration %s at offset %d", d, d.pos)
+				}
+				if sign.text == "-" {
+					dur = -dur
+				}
+				n.offset = dur
+			}
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value, found %s at offset %d", t, t.pos)
+}
+
+// parseFilterDuration extends time.ParseDuration with days (d) and weeks (w).
+func parseFilterDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if f, err := strconv.ParseFloat(n, 64); err == nil {
+				return time.Duration(f * float64(unit)), nil
+			}
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,105 @@
+package canvus
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	recent := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	obj := map[string]interface{}{
+		"id":          "w1",
+		"widget_type": "Note",
+		"name":        "Sprint 12",
+		"scale":       2.0,
+		"depth":       float64(3),
+		"pinned":      false,
+		"parent_id":   "",
+		"location":    map[string]interface{}{"x": 100.0, "y": -5.0},
+		"created_at":  "2024-01-15T09:30:00Z",
+		"modified_at": recent,
+	}
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`widget_type == "Note" && scale > 1.5`, true},
+		{`widget_type = 'Note' and scale <= 1.5`, false},
+		{`depth == 3`, true},
+		{`depth != 3`, false},
+		{`location.x >= 100 && $.location.y < -1`, true},
+		{`name =~ "^Sprint [0-9]+$"`, true},
+		{`name !~ "^Sprint"`, false},
+		{`widget_type in ["Image", "Note"]`, true},
+		{`widget_type not in ["Image", "Note"]`, false},
+		{`depth in [1, 2, 3]`, true},
+		{`exists(location.x) && !exists(size)`, true},
+		{`pinned || parent_id`, false},
+		{`not pinned`, true},
+		{`size == null && id != null`, true},
+		{`size.width > 0`, false},
+		{`size.width != 0`, false},
+		{`created_at >= "2024-01-01" && created_at < "2024-02-01"`, true},
+		{`created_at == "2024-01-15T10:30:00+01:00"`, true},
+		{`modified_at > now-7d && modified_at < now`, true},
+		{`modified_at > now-1d`, false},
+		{`(scale > 3 || depth == 3) && !(name == "x")`, true},
+		{`scale > 3 || depth == 3 && name == "x"`, false},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", c.expr, err)
+			continue
+		}
+		if got := f.Match(obj); got != c.want {
+			t.Errorf("%q = %v, want %v", c.expr, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{
+		``, `scale >`, `scale > 1 extra`, `name =~ 5`, `name =~ "("`, `(scale > 1`, `"x" == 1`,
+		`scale < null`, `modified_at > now-7x`, `name == "open`, `scale # 1`,
+	} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q): expected error", bad)
+		}
+	}
+
+	// Criteria and expression combine; the expression shows up in String.
+	f := MustParseFilter(`scale > 1`)
+	f.Criteria = map[string]interface{}{"widget_type": "Note"}
+	if !f.Match(obj) || f.String() != `scale > 1` {
+		t.Errorf("combined filter failed")
+	}
+	f.Criteria["widget_type"] = "Image"
+	if f.Match(obj) {
+		t.Errorf("criteria ignored")
+	}
+	if !strings.Contains(func() (msg string) {
+		defer func() { msg = recover().(error).Error() }()
+		MustParseFilter("((")
+		return ""
+	}(), "ParseFilter") {
+		t.Error("MustParseFilter did not panic with a ParseFilter error")
+	}
+}
+
+func TestFilterMatchNumericCriteria(t *testing.T) {
+	// JSON decodes numbers as float64; an int criterion must still match.
+	obj := map[string]interface{}{"depth": float64(3), "location": map[string]interface{}{"x": 10.0}}
+	f := &Filter{Criteria: map[string]interface{}{"depth": 3, "$.location.x": int64(10)}}
+	if !f.Match(obj) {
+		t.Error("int criteria did not match float64 values")
+	}
+	f.Criteria["depth"] = 4
+	if f.Match(obj) {
+		t.Error("depth 4 matched 3")
+	}
+	// Map values no longer panic on comparison.
+	f = &Filter{Criteria: map[string]interface{}{"location": map[string]interface{}{"x": 10.0}}}
+	if !f.Match(obj) {
+		t.Error("map criterion did not match")
+	}
+}
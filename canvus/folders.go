@@ -3,6 +3,7 @@ package canvus
 import (
 	"context"
 	"fmt"
+	"strconv"
 )
 
 // Folder represents a canvas folder in the Canvus system.
@@ -51,13 +52,16 @@ type FolderGroupPermission struct {
 	Inherited  bool   `json:"inherited"`
 }
 
-// ListFolders retrieves all folders from the Canvus API.
-func (s *Session) ListFolders(ctx context.Context) ([]Folder, error) {
+// ListFolders retrieves all folders from the Canvus API. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListFolders(ctx context.Context, filter *Filter) ([]Folder, error) {
 	var folders []Folder
 	err := s.doRequest(ctx, "GET", "canvas-folders", nil, &folders, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListFolders: %w", err)
 	}
+	if filter != nil {
+		folders = FilterSlice(folders, filter)
+	}
 	return folders, nil
 }
 
@@ -119,23 +123,43 @@ func (s *Session) CopyFolder(ctx context.Context, id string, parentID string, co
 	return &folder, nil
 }
 
-// TrashFolder moves a folder to the trash folder.
-func (s *Session) TrashFolder(ctx context.Context, id string, _ string) (*Folder, error) {
-	userID := s.UserID()
-	if userID == 0 {
-		return nil, fmt.Errorf("TrashFolder: user ID not set; must login first")
+// TrashFolder moves a folder to the trash folder of userID (the folder owner).
+// If userID is empty, the logged-in user's trash is used; admin API-key sessions
+// should pass the owner's ID (or "trash.<id>") or use TrashService, which resolves the owner automatically.
+func (s *Session) TrashFolder(ctx context.Context, id string, userID string) (*Folder, error) {
+	trashID, err := s.trashFolderFor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("TrashFolder: %w", err)
 	}
-	trashID := fmt.Sprintf("trash.%d", userID)
 	var folder Folder
 	path := fmt.Sprintf("canvas-folders/%s/move", id)
 	req := MoveOrCopyFolderRequest{ParentID: trashID}
-	err := s.doRequest(ctx, "POST", path, req, &folder, nil, false)
+	err = s.doRequest(ctx, "POST", path, req, &folder, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("TrashFolder: %w", err)
 	}
 	return &folder, nil
 }
 
+// trashFolderFor returns the trash folder ID for userID, or for the logged-in user if userID is empty.
+// A full trash folder ID such as "trash.1000" is accepted as-is.
+func (s *Session) trashFolderFor(userID string) (string, error) {
+	if isTrashFolderID(userID) {
+		return userID, nil
+	}
+	if userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid user ID %q", userID)
+		}
+		return TrashFolderID(id), nil
+	}
+	if s.UserID() == 0 {
+		return "", fmt.Errorf("user ID not set; pass the owner's user ID or login first")
+	}
+	return TrashFolderID(s.UserID()), nil
+}
+
 // DeleteFolder permanently deletes a folder by ID.
 func (s *Session) DeleteFolder(ctx context.Context, id string) error {
 	path := fmt.Sprintf("canvas-folders/%s", id)
@@ -51,7 +51,7 @@ func TestFolderLifecycle(t *testing.T) {
 	defer func() { _ = client.DeleteFolder(ctx, folder.ID) }()
 
 	// List folders and check the new folder is present
-	folders, err := client.ListFolders(ctx)
+	folders, err := client.ListFolders(ctx, nil)
 	if err != nil {
 		t.Errorf("failed to list folders: %v", err)
 	}
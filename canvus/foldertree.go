@@ -0,0 +1,410 @@
+package canvus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Conflict strategies accepted by MoveFolder, CopyFolder, MoveCanvas and CopyCanvas.
+const (
+	ConflictSkip    = "skip"    // skip conflicting items (server default)
+	ConflictCancel  = "cancel"  // cancel the whole operation if any conflict would happen
+	ConflictReplace = "replace" // replace the destination item with the source one
+)
+
+// SkipFolder can be returned from a FolderWalkFunc to skip the children of the current folder.
+var SkipFolder = errors.New("skip this folder")
+
+// FolderWalkFunc is called for each folder visited by FolderTree.Walk.
+type FolderWalkFunc func(node *FolderNode) error
+
+// FolderNode is a folder in a FolderTree, with its children and the canvases it contains.
+type FolderNode struct {
+	Folder
+	Path     string        // absolute slash-separated path, "/" for the root
+	Parent   *FolderNode   // nil for the root
+	Children []*FolderNode // sorted by name
+	Canvases []Canvas      // sorted by name
+}
+
+// FolderTree is an in-memory view of the folder hierarchy built from ListFolders and ListCanvases.
+// Paths are slash-separated folder names starting at the server root folder, e.g. "/Team/Project/Board".
+// Trash folders are not part of the tree. Names containing "/" cannot be addressed by path.
+type FolderTree struct {
+	Root *FolderNode
+
+	session *Session
+	byID    map[string]*FolderNode
+}
+
+// TreeEntry is the result of resolving a path: either a folder or a canvas.
+type TreeEntry struct {
+	Path   string
+	Folder *FolderNode // set if the path names a folder
+	Canvas *Canvas     // set if the path names a canvas
+	Parent *FolderNode // the containing folder (nil for the root)
+}
+
+// LoadFolderTree fetches all folders and canvases and builds a FolderTree.
+//
+// Usage Example:
+//
+//	tree, err := session.LoadFolderTree(ctx)
+//	entry, err := tree.Resolve("/Team/Project/Board")
+//	fmt.Println(entry.Canvas.ID)
+func (s *Session) LoadFolderTree(ctx context.Context) (*FolderTree, error) {
+	folders, err := s.ListFolders(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("LoadFolderTree: %w", err)
+	}
+	canvases, err := s.ListCanvases(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("LoadFolderTree: %w", err)
+	}
+	tree := BuildFolderTree(folders, canvases)
+	tree.session = s
+	return tree, nil
+}
+
+// BuildFolderTree builds a FolderTree from already-fetched folders and canvases.
+// The root is the parentless folder with an empty name; if there is none, a virtual root is used.
+// Folders whose parent is unknown are attached to the root. Trashed items are skipped.
+// Trees built this way have no session, so MkdirAll, Move and Refresh are unavailable.
+func BuildFolderTree(folders []Folder, canvases []Canvas) *FolderTree {
+	tree := &FolderTree{byID: make(map[string]*FolderNode)}
+	for _, f := range folders {
+		if f.InTrash || isTrashFolderID(f.ID) {
+			continue
+		}
+		tree.byID[f.ID] = &FolderNode{Folder: f}
+	}
+	for _, n := range tree.byID {
+		if n.ParentID == "" && n.Name == "" {
+			tree.Root = n
+			break
+		}
+	}
+	if tree.Root == nil {
+		tree.Root = &FolderNode{}
+	}
+	for _, n := range tree.byID {
+		if n == tree.Root {
+			continue
+		}
+		parent := tree.byID[n.ParentID]
+		if parent == nil {
+			parent = tree.Root
+		}
+		n.Parent = parent
+		parent.Children = append(parent.Children, n)
+	}
+	for _, c := range canvases {
+		if c.InTrash || isTrashFolderID(c.FolderID) {
+			continue
+		}
+		parent := tree.byID[c.FolderID]
+		if parent == nil {
+			parent = tree.Root
+		}
+		parent.Canvases = append(parent.Canvases, c)
+	}
+	tree.Root.Path = "/"
+	tree.Root.index()
+	return tree
+}
+
+// index sorts children and canvases by name and recomputes paths below n.
+func (n *FolderNode) index() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Name != n.Children[j].Name {
+			return n.Children[i].Name < n.Children[j].Name
+		}
+		return n.Children[i].ID < n.Children[j].ID
+	})
+	sort.Slice(n.Canvases, func(i, j int) bool {
+		if n.Canvases[i].Name != n.Canvases[j].Name {
+			return n.Canvases[i].Name < n.Canvases[j].Name
+		}
+		return n.Canvases[i].ID < n.Canvases[j].ID
+	})
+	for _, c := range n.Children {
+		c.Path = JoinFolderPath(n.Path, c.Name)
+		c.index()
+	}
+}
+
+// isTrashFolderID reports whether id is a per-user trash folder such as "trash.1000".
+func isTrashFolderID(id string) bool {
+	return strings.HasPrefix(id, "trash.")
+}
+
+// JoinFolderPath joins path elements into a clean absolute tree path.
+func JoinFolderPath(elem ...string) string {
+	var parts []string
+	for _, e := range elem {
+		parts = append(parts, splitFolderPath(e)...)
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// splitFolderPath splits a path into its non-empty name segments.
+func splitFolderPath(p string) []string {
+	var parts []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" && s != "." {
+			parts = append(parts, s)
+		}
+	}
+	return parts
+}
+
+// Folder returns the node for a folder ID, or nil if it is not in the tree.
+func (t *FolderTree) Folder(id string) *FolderNode {
+	if t.Root.ID == id {
+		return t.Root
+	}
+	return t.byID[id]
+}
+
+// Resolve looks up a path and returns the folder or canvas it names.
+// It returns an error if the path does not exist or if a segment is ambiguous
+// (several folders, or a folder and a canvas, share the same name).
+func (t *FolderTree) Resolve(path string) (*TreeEntry, error) {
+	parts := splitFolderPath(path)
+	if len(parts) == 0 {
+		return &TreeEntry{Path: "/", Folder: t.Root}, nil
+	}
+	parent, err := t.walkPath(parts[:len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("Resolve %q: %w", path, err)
+	}
+	name := parts[len(parts)-1]
+	folders := parent.childrenNamed(name)
+	canvases := parent.canvasesNamed(name)
+	switch {
+	case len(folders)+len(canvases) == 0:
+		return nil, fmt.Errorf("Resolve %q: %q not found in %s", path, name, parent.Path)
+	case len(folders)+len(canvases) > 1:
+		return nil, fmt.Errorf("Resolve %q: %q is ambiguous in %s (%d folders, %d canvases)", path, name, parent.Path, len(folders), len(canvases))
+	case len(folders) == 1:
+		return &TreeEntry{Path: folders[0].Path, Folder: folders[0], Parent: parent}, nil
+	default:
+		c := canvases[0]
+		return &TreeEntry{Path: JoinFolderPath(parent.Path, name), Canvas: &c, Parent: parent}, nil
+	}
+}
+
+// ResolveFolder looks up a folder by path, ignoring canvases with the same name.
+func (t *FolderTree) ResolveFolder(path string) (*FolderNode, error) {
+	node, err := t.walkPath(splitFolderPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("ResolveFolder %q: %w", path, err)
+	}
+	return node, nil
+}
+
+// ResolveCanvas looks up a canvas by path, ignoring folders with the same name.
+func (t *FolderTree) ResolveCanvas(path string) (*Canvas, error) {
+	parts := splitFolderPath(path)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("ResolveCanvas %q: path names the root folder", path)
+	}
+	parent, err := t.walkPath(parts[:len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("ResolveCanvas %q: %w", path, err)
+	}
+	canvases := parent.canvasesNamed(parts[len(parts)-1])
+	switch len(canvases) {
+	case 0:
+		return nil, fmt.Errorf("ResolveCanvas %q: canvas not found", path)
+	case 1:
+		c := canvases[0]
+		return &c, nil
+	default:
+		return nil, fmt.Errorf("ResolveCanvas %q: %d canvases share this name", path, len(canvases))
+	}
+}
+
+// walkPath follows folder names from the root, failing on missing or duplicate names.
+func (t *FolderTree) walkPath(parts []string) (*FolderNode, error) {
+	node := t.Root
+	for _, name := range parts {
+		matches := node.childrenNamed(name)
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("folder %q not found in %s", name, node.Path)
+		case 1:
+			node = matches[0]
+		default:
+			return nil, fmt.Errorf("folder %q is ambiguous in %s (%d folders share this name)", name, node.Path, len(matches))
+		}
+	}
+	return node, nil
+}
+
+func (n *FolderNode) childrenNamed(name string) []*FolderNode {
+	var out []*FolderNode
+	for _, c := range n.Children {
+		if c.Name == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (n *FolderNode) canvasesNamed(name string) []Canvas {
+	var out []Canvas
+	for _, c := range n.Canvases {
+		if c.Name == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Walk visits the folder at path and all folders below it, depth first in name order.
+// If fn returns SkipFolder, the children of that folder are skipped; any other error stops the walk.
+func (t *FolderTree) Walk(path string, fn FolderWalkFunc) error {
+	start, err := t.ResolveFolder(path)
+	if err != nil {
+		return fmt.Errorf("Walk: %w", err)
+	}
+	err = walkFolder(start, fn)
+	if err == SkipFolder {
+		return nil
+	}
+	return err
+}
+
+func walkFolder(n *FolderNode, fn FolderWalkFunc) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+	for _, c := range n.Children {
+		if err := walkFolder(c, fn); err != nil && err != SkipFolder {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCanvases returns the canvases in the folder at path. If recursive is true,
+// canvases in all subfolders are included as well, in walk order.
+func (t *FolderTree) ListCanvases(path string, recursive bool) ([]Canvas, error) {
+	start, err := t.ResolveFolder(path)
+	if err != nil {
+		return nil, fmt.Errorf("ListCanvases: %w", err)
+	}
+	if !recursive {
+		return append([]Canvas(nil), start.Canvases...), nil
+	}
+	var out []Canvas
+	_ = walkFolder(start, func(n *FolderNode) error {
+		out = append(out, n.Canvases...)
+		return nil
+	})
+	return out, nil
+}
+
+// CanvasPath returns the tree path of a canvas, or an error if it is not in the tree.
+func (t *FolderTree) CanvasPath(canvasID string) (string, error) {
+	nodes := []*FolderNode{t.Root}
+	for len(nodes) > 0 {
+		n := nodes[0]
+		nodes = append(nodes[1:], n.Children...)
+		for _, c := range n.Canvases {
+			if c.ID == canvasID {
+				return JoinFolderPath(n.Path, c.Name), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("CanvasPath: canvas %s not found", canvasID)
+}
+
+// MkdirAll returns the folder at path, creating any missing folders along the way with CreateFolder.
+// Existing folders are reused; an ambiguous segment is an error.
+func (t *FolderTree) MkdirAll(ctx context.Context, path string) (*FolderNode, error) {
+	if t.session == nil {
+		return nil, fmt.Errorf("MkdirAll: tree has no session; use Session.LoadFolderTree")
+	}
+	node := t.Root
+	for _, name := range splitFolderPath(path) {
+		matches := node.childrenNamed(name)
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("MkdirAll %q: folder %q is ambiguous in %s", path, name, node.Path)
+		}
+		if len(matches) == 1 {
+			node = matches[0]
+			continue
+		}
+		f, err := t.session.CreateFolder(ctx, CreateFolderRequest{Name: name, ParentID: node.ID})
+		if err != nil {
+			return nil, fmt.Errorf("MkdirAll %q: %w", path, err)
+		}
+		child := &FolderNode{Folder: *f, Parent: node}
+		if child.ParentID == "" {
+			child.ParentID = node.ID
+		}
+		t.byID[child.ID] = child
+		node.Children = append(node.Children, child)
+		node.index()
+		node = child
+	}
+	return node, nil
+}
+
+// Move moves the folder or canvas at srcPath into the folder at dstPath, using MoveFolder or
+// MoveCanvas with the given conflict strategy (ConflictSkip, ConflictCancel or ConflictReplace).
+// Moving a folder moves its whole subtree. The tree is refreshed from the server afterwards,
+// since the outcome of a conflict is decided server-side.
+func (t *FolderTree) Move(ctx context.Context, srcPath, dstPath, conflicts string) error {
+	if t.session == nil {
+		return fmt.Errorf("Move: tree has no session; use Session.LoadFolderTree")
+	}
+	src, err := t.Resolve(srcPath)
+	if err != nil {
+		return fmt.Errorf("Move: %w", err)
+	}
+	dst, err := t.ResolveFolder(dstPath)
+	if err != nil {
+		return fmt.Errorf("Move: %w", err)
+	}
+	if src.Folder != nil {
+		if src.Folder == t.Root {
+			return fmt.Errorf("Move: cannot move the root folder")
+		}
+		for n := dst; n != nil; n = n.Parent {
+			if n == src.Folder {
+				return fmt.Errorf("Move: cannot move %s into its own subtree %s", src.Path, dst.Path)
+			}
+		}
+		if _, err := t.session.MoveFolder(ctx, src.Folder.ID, dst.ID, conflicts); err != nil {
+			return fmt.Errorf("Move: %w", err)
+		}
+	} else {
+		req := MoveOrCopyCanvasRequest{FolderID: dst.ID, Conflicts: conflicts}
+		if _, err := t.session.MoveCanvas(ctx, src.Canvas.ID, req); err != nil {
+			return fmt.Errorf("Move: %w", err)
+		}
+	}
+	if err := t.Refresh(ctx); err != nil {
+		return fmt.Errorf("Move: %w", err)
+	}
+	return nil
+}
+
+// Refresh reloads the tree from the server in place.
+func (t *FolderTree) Refresh(ctx context.Context) error {
+	if t.session == nil {
+		return fmt.Errorf("Refresh: tree has no session; use Session.LoadFolderTree")
+	}
+	fresh, err := t.session.LoadFolderTree(ctx)
+	if err != nil {
+		return err
+	}
+	*t = *fresh
+	return nil
+}
@@ -0,0 +1,115 @@
+package canvus
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func seedFolderTree(f *fakeCanvus) {
+	f.seed("canvas-folders", map[string]interface{}{"id": "root", "name": "", "folder_id": ""})
+	f.seed("canvas-folders", map[string]interface{}{"id": "team", "name": "Team", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "proj", "name": "Project", "folder_id": "team"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "archive", "name": "Archive", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "dup1", "name": "Dup", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "dup2", "name": "Dup", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "gone", "name": "Old", "folder_id": "trash.1000", "in_trash": true})
+	f.seed("canvases", map[string]interface{}{"id": "c1", "name": "Board", "folder_id": "proj"})
+	f.seed("canvases", map[string]interface{}{"id": "c2", "name": "Overview", "folder_id": "team"})
+	f.seed("canvases", map[string]interface{}{"id": "c3", "name": "Trashed", "folder_id": "trash.1000", "in_trash": true})
+}
+
+func TestFolderTreeResolveAndWalk(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedFolderTree(fake)
+	tree, err := fake.session().LoadFolderTree(context.Background())
+	if err != nil {
+		t.Fatalf("LoadFolderTree: %v", err)
+	}
+
+	entry, err := tree.Resolve("/Team/Project/Board")
+	if err != nil || entry.Canvas == nil || entry.Canvas.ID != "c1" {
+		t.Fatalf("Resolve board = %+v, %v", entry, err)
+	}
+	entry, err = tree.Resolve("Team/Project/")
+	if err != nil || entry.Folder == nil || entry.Folder.ID != "proj" || entry.Path != "/Team/Project" {
+		t.Fatalf("Resolve project = %+v, %v", entry, err)
+	}
+	if _, err := tree.Resolve("/Dup"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguity error, got %v", err)
+	}
+	if _, err := tree.Resolve("/Team/Missing"); err == nil {
+		t.Error("expected not found error")
+	}
+	if _, err := tree.Resolve("/Old"); err == nil {
+		t.Error("trashed folder should not be in the tree")
+	}
+
+	var visited []string
+	err = tree.Walk("/", func(n *FolderNode) error {
+		visited = append(visited, n.Path)
+		if n.Name == "Team" {
+			return SkipFolder
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if got := strings.Join(visited, ","); got != "/,/Archive,/Dup,/Dup,/Team" {
+		t.Errorf("Walk visited %s", got)
+	}
+
+	canvases, err := tree.ListCanvases("/Team", true)
+	if err != nil || len(canvases) != 2 || canvases[0].ID != "c2" || canvases[1].ID != "c1" {
+		t.Errorf("ListCanvases recursive = %+v, %v", canvases, err)
+	}
+	canvases, _ = tree.ListCanvases("/Team", false)
+	if len(canvases) != 1 {
+		t.Errorf("ListCanvases non-recursive = %+v", canvases)
+	}
+	if p, _ := tree.CanvasPath("c1"); p != "/Team/Project/Board" {
+		t.Errorf("CanvasPath = %q", p)
+	}
+}
+
+func TestFolderTreeMkdirAllAndMove(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedFolderTree(fake)
+	ctx := context.Background()
+	tree, err := fake.session().LoadFolderTree(ctx)
+	if err != nil {
+		t.Fatalf("LoadFolderTree: %v", err)
+	}
+
+	node, err := tree.MkdirAll(ctx, "/Team/Project/2025/Q1")
+	if err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if node.Path != "/Team/Project/2025/Q1" {
+		t.Errorf("path = %q", node.Path)
+	}
+	if n := fake.callCount("POST canvas-folders"); n != 2 {
+		t.Errorf("expected 2 folders created, got %d", n)
+	}
+	again, err := tree.MkdirAll(ctx, "/Team/Project/2025/Q1")
+	if err != nil || again != node || fake.callCount("POST canvas-folders") != 2 {
+		t.Errorf("MkdirAll should be idempotent: %v", err)
+	}
+
+	if err := tree.Move(ctx, "/Team/Project", "/Archive", ConflictCancel); err != nil {
+		t.Fatalf("Move folder: %v", err)
+	}
+	if _, err := tree.ResolveFolder("/Archive/Project/2025/Q1"); err != nil {
+		t.Errorf("subtree not moved: %v", err)
+	}
+	if err := tree.Move(ctx, "/Archive/Project/Board", "/Team", ConflictReplace); err != nil {
+		t.Fatalf("Move canvas: %v", err)
+	}
+	if c, err := tree.ResolveCanvas("/Team/Board"); err != nil || c.ID != "c1" {
+		t.Errorf("canvas not moved: %+v, %v", c, err)
+	}
+	if err := tree.Move(ctx, "/Archive", "/Archive/Project", ConflictSkip); err == nil {
+		t.Error("expected error moving a folder into its own subtree")
+	}
+}
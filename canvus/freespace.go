@@ -0,0 +1,172 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CanvasBounds returns the canvas area of a workspace, from (0,0) to its CanvasSize.
+func CanvasBounds(ws Workspace) Rectangle {
+	if ws.CanvasSize == nil {
+		return Rectangle{}
+	}
+	return Rectangle{Width: ws.CanvasSize.Width, Height: ws.CanvasSize.Height}
+}
+
+// overlaps reports whether a and b share interior area; rectangles that only touch do not overlap.
+func overlaps(a, b Rectangle) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width &&
+		a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+// FindFreeSpace returns the rectangle of the given size inside bounds (in canvas coordinates) that
+// overlaps no widget and whose centre is nearest to near. Widgets are placed through their parent
+// chain with CanvasGeometry. Widgets without a size, connectors and widgets covering the whole of
+// bounds (the SharedCanvas, or the container the bounds came from) are not obstacles.
+// It reports false if no such rectangle exists.
+//
+// Usage Example:
+//
+//	widgets, _ := session.ListWidgets(ctx, canvasID, nil)
+//	spot, ok := canvus.FindFreeSpace(widgets, canvus.Size{Width: 300, Height: 300}, canvus.Point{X: 1000, Y: 800}, canvus.CanvasBounds(*ws))
+func FindFreeSpace(widgets []Widget, size Size, near Point, bounds Rectangle) (Rectangle, bool) {
+	if size.Width > bounds.Width || size.Height > bounds.Height {
+		return Rectangle{}, false
+	}
+	geom := NewCanvasGeometry(widgets)
+	var obstacles []Rectangle
+	for _, w := range widgets {
+		if w.Size == nil || w.WidgetType == "Connector" || w.WidgetType == "SharedCanvas" {
+			continue
+		}
+		r := geom.CanvasRect(w)
+		if r.Width <= 0 || r.Height <= 0 || !overlaps(r, bounds) || Contains(r, bounds) {
+			continue
+		}
+		obstacles = append(obstacles, r)
+	}
+
+	// A free spot, if any exists, can be slid left and up until it meets an obstacle or the bounds,
+	// so obstacle edges and bounds edges (plus the spot centred on near) are enough candidates.
+	xs := []float64{near.X - size.Width/2, bounds.X, bounds.X + bounds.Width - size.Width}
+	ys := []float64{near.Y - size.Height/2, bounds.Y, bounds.Y + bounds.Height - size.Height}
+	for _, o := range obstacles {
+		xs = append(xs, o.X+o.Width, o.X-size.Width)
+		ys = append(ys, o.Y+o.Height, o.Y-size.Height)
+	}
+	type candidate struct {
+		r    Rectangle
+		dist float64
+	}
+	var cands []candidate
+	for _, x := range dedupeFloats(xs) {
+		if x < bounds.X || x+size.Width > bounds.X+bounds.Width {
+			continue
+		}
+		for _, y := range dedupeFloats(ys) {
+			if y < bounds.Y || y+size.Height > bounds.Y+bounds.Height {
+				continue
+			}
+			dx, dy := x+size.Width/2-near.X, y+size.Height/2-near.Y
+			cands = append(cands, candidate{Rectangle{X: x, Y: y, Width: size.Width, Height: size.Height}, math.Hypot(dx, dy)})
+		}
+	}
+	// Prefer the nearest spot; break ties top-to-bottom, then left-to-right, for stable results.
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		if a.r.Y != b.r.Y {
+			return a.r.Y < b.r.Y
+		}
+		return a.r.X < b.r.X
+	})
+	for _, c := range cands {
+		free := true
+		for _, o := range obstacles {
+			if overlaps(c.r, o) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return c.r, true
+		}
+	}
+	return Rectangle{}, false
+}
+
+func dedupeFloats(vs []float64) []float64 {
+	seen := make(map[float64]bool, len(vs))
+	out := vs[:0:0]
+	for _, v := range vs {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// PlaceWidget creates a widget at the free spot nearest to near. req is a CreateWidget request
+// map that must include "widget_type" and "size"; its "location" is set by PlaceWidget. near and
+// bounds are canvas coordinates; bounds limits the search (e.g. CanvasBounds or a container's
+// CanvasGeometry.CanvasRect) and nil means the SharedCanvas area. If req has a "parent_id", the
+// size and location are taken to be in that parent's local space.
+//
+// Usage Example:
+//
+//	note, err := session.PlaceWidget(ctx, canvasID, map[string]interface{}{
+//		"widget_type": "note",
+//		"text":        "Bot reply",
+//		"size":        map[string]interface{}{"width": 300, "height": 300},
+//	}, canvus.Point{X: 1000, Y: 800}, nil)
+func (s *Session) PlaceWidget(ctx context.Context, canvasID string, req map[string]interface{}, near Point, bounds *Rectangle) (*Widget, error) {
+	var size Size
+	raw, err := json.Marshal(req["size"])
+	if err == nil {
+		err = json.Unmarshal(raw, &size)
+	}
+	if err != nil || size.Width <= 0 || size.Height <= 0 {
+		return nil, fmt.Errorf("PlaceWidget: req must include a positive size")
+	}
+	widgets, err := s.ListWidgets(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("PlaceWidget: %w", err)
+	}
+	var area Rectangle
+	if bounds != nil {
+		area = *bounds
+	} else {
+		for _, w := range widgets {
+			if w.WidgetType == "SharedCanvas" {
+				area = WidgetBoundingBox(w)
+				break
+			}
+		}
+		if area.Width <= 0 || area.Height <= 0 {
+			return nil, fmt.Errorf("PlaceWidget: canvas has no SharedCanvas size; pass bounds")
+		}
+	}
+	parentID, _ := req["parent_id"].(string)
+	t := NewCanvasGeometry(widgets).Transform(parentID)
+	spot, ok := FindFreeSpace(widgets, Size{Width: size.Width * t.Scale, Height: size.Height * t.Scale}, near, area)
+	if !ok {
+		return nil, fmt.Errorf("PlaceWidget: no free %gx%g space in bounds", size.Width, size.Height)
+	}
+	loc := t.ToLocal(Point{X: spot.X, Y: spot.Y})
+	create := make(map[string]interface{}, len(req)+1)
+	for k, v := range req {
+		create[k] = v
+	}
+	create["location"] = map[string]interface{}{"x": loc.X, "y": loc.Y}
+	w, err := s.CreateWidget(ctx, canvasID, create)
+	if err != nil {
+		return nil, fmt.Errorf("PlaceWidget: %w", err)
+	}
+	return w, nil
+}
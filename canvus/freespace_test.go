@@ -0,0 +1,56 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestFindFreeSpace(t *testing.T) {
+	bounds := Rectangle{X: 0, Y: 0, Width: 100, Height: 100}
+	widgets := []Widget{
+		{ID: "bg", WidgetType: "SharedCanvas", Location: &Point{}, Size: &Size{Width: 100, Height: 100}},
+		{ID: "a", WidgetType: "Note", Location: &Point{X: 40, Y: 40}, Size: &Size{Width: 20, Height: 20}},
+		{ID: "b", WidgetType: "Note", Location: &Point{X: 60, Y: 40}, Size: &Size{Width: 20, Height: 20}},
+	}
+
+	got, ok := FindFreeSpace(widgets, Size{Width: 10, Height: 10}, Point{X: 50, Y: 50}, bounds)
+	if !ok || got != (Rectangle{X: 45, Y: 30, Width: 10, Height: 10}) {
+		t.Errorf("FindFreeSpace = %+v, %v; want the spot just above the occupied centre", got, ok)
+	}
+	if got, ok := FindFreeSpace(widgets, Size{Width: 10, Height: 10}, Point{X: 10, Y: 10}, bounds); !ok || got.X != 5 || got.Y != 5 {
+		t.Errorf("free point near = %+v, %v; want centred on near", got, ok)
+	}
+	if _, ok := FindFreeSpace(widgets, Size{Width: 100, Height: 50}, Point{X: 50, Y: 50}, Rectangle{X: 0, Y: 30, Width: 100, Height: 50}); ok {
+		t.Error("expected no space when the band is blocked")
+	}
+	if _, ok := FindFreeSpace(nil, Size{Width: 200, Height: 10}, Point{}, bounds); ok {
+		t.Error("expected no space for an oversized widget")
+	}
+}
+
+func TestPlaceWidget(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle("GET", "canvases/c1/widgets", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": "sc", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0, "y": 0}, "size": map[string]interface{}{"width": 1000, "height": 1000}},
+			{"id": "n1", "widget_type": "Note", "location": map[string]interface{}{"x": 400, "y": 400}, "size": map[string]interface{}{"width": 200, "height": 200}},
+		})
+	})
+	s := fake.session()
+
+	w, err := s.PlaceWidget(context.Background(), "c1", map[string]interface{}{
+		"widget_type": "note",
+		"text":        "hello",
+		"size":        map[string]interface{}{"width": 100, "height": 100},
+	}, Point{X: 500, Y: 500}, nil)
+	if err != nil {
+		t.Fatalf("PlaceWidget: %v", err)
+	}
+	if w.Location == nil || WidgetBoundingBox(*w) != (Rectangle{X: 450, Y: 300, Width: 100, Height: 100}) {
+		t.Errorf("placed at %+v", WidgetBoundingBox(*w))
+	}
+	if _, err := s.PlaceWidget(context.Background(), "c1", map[string]interface{}{"widget_type": "note"}, Point{}, nil); err == nil {
+		t.Error("expected error without size")
+	}
+}
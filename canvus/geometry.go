@@ -19,11 +19,13 @@ func Contains(a, b Rectangle) bool {
 //   b := canvus.Rectangle{X: 9, Y: 9, Width: 5, Height: 5}
 //   ok := canvus.Touches(a, b) // true
 func Touches(a, b Rectangle) bool {
-	return a.X < b.X+b.Width && a.X+a.Width > b.X &&
-		a.Y < b.Y+b.Height && a.Y+a.Height > b.Y
+	return a.X <= b.X+b.Width && a.X+a.Width >= b.X &&
+		a.Y <= b.Y+b.Height && a.Y+a.Height >= b.Y
 }
 
-// WidgetBoundingBox returns the bounding box (Rectangle) for a Widget.
+// WidgetBoundingBox returns the bounding box (Rectangle) for a Widget in its parent's coordinate
+// space: Location, and Size multiplied by Scale (an unset Scale counts as 1). For canvas
+// coordinates of nested widgets use CanvasGeometry.CanvasRect.
 //
 // Usage Example:
 //   w := canvus.Widget{Location: &canvus.Point{X: 1, Y: 2}, Size: &canvus.Size{Width: 3, Height: 4}}
@@ -36,13 +38,14 @@ func WidgetBoundingBox(w Widget) Rectangle {
 		y = w.Location.Y
 	}
 	if w.Size != nil {
-		wVal = w.Size.Width
-		hVal = w.Size.Height
+		wVal = w.Size.Width * widgetScale(w)
+		hVal = w.Size.Height * widgetScale(w)
 	}
 	return Rectangle{X: x, Y: y, Width: wVal, Height: hVal}
 }
 
-// WidgetContains returns true if widget a fully contains widget b.
+// WidgetContains returns true if widget a fully contains widget b. Both are compared in their
+// parent's space, so use CanvasGeometry.Contains for widgets with different parents.
 //
 // Usage Example:
 //   ok := canvus.WidgetContains(widgetA, widgetB)
@@ -50,7 +53,8 @@ func WidgetContains(a, b Widget) bool {
 	return Contains(WidgetBoundingBox(a), WidgetBoundingBox(b))
 }
 
-// WidgetsTouch returns true if widgets a and b touch or overlap.
+// WidgetsTouch returns true if widgets a and b touch or overlap. Like WidgetContains it ignores
+// the parent chain; see CanvasGeometry.Touches.
 //
 // Usage Example:
 //   ok := canvus.WidgetsTouch(widgetA, widgetB)
@@ -0,0 +1,216 @@
+package canvus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Connector tip values used for graph edges.
+const (
+	tipNone  = "none"
+	tipArrow = "solid-equilateral-triangle"
+)
+
+// Graph is a node/edge description of a diagram, as parsed from Mermaid or Graphviz DOT.
+type Graph struct {
+	Directed bool
+	// Direction is the layout direction: "TB" (top to bottom, the default), "BT", "LR" or "RL".
+	Direction string
+	Nodes     []GraphNode
+	Edges     []GraphEdge
+}
+
+// GraphNode is a node of a Graph. Color is a Canvus RRGGBBAA colour, or empty for the default.
+type GraphNode struct {
+	ID    string
+	Label string
+	Color string
+}
+
+// GraphEdge is an edge of a Graph. SrcTip and DstTip are connector tips ("none" or
+// "solid-equilateral-triangle"); Color is RRGGBBAA or empty.
+type GraphEdge struct {
+	From   string
+	To     string
+	Label  string
+	Color  string
+	SrcTip string
+	DstTip string
+}
+
+// ParseGraph parses a Mermaid flowchart or a Graphviz DOT graph, detected from its header.
+func ParseGraph(src string) (*Graph, error) {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%%") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ToLower(line))
+		switch fields[0] {
+		case "flowchart":
+			return ParseMermaid(src)
+		case "graph":
+			// Mermaid writes "graph TD"; DOT writes "graph name {" or "graph {".
+			if strings.Contains(line, "{") || len(fields) == 1 {
+				return ParseDOT(src)
+			}
+			switch strings.TrimSuffix(fields[1], ";") {
+			case "td", "tb", "bt", "lr", "rl":
+				return ParseMermaid(src)
+			}
+			return ParseDOT(src)
+		case "digraph", "strict":
+			return ParseDOT(src)
+		}
+		return nil, fmt.Errorf("ParseGraph: unrecognised header %q", line)
+	}
+	return nil, fmt.Errorf("ParseGraph: empty input")
+}
+
+// node returns the node with the given ID, adding it (labelled with its ID) if it is new.
+func (g *Graph) node(id string) *GraphNode {
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == id {
+			return &g.Nodes[i]
+		}
+	}
+	g.Nodes = append(g.Nodes, GraphNode{ID: id, Label: id})
+	return &g.Nodes[len(g.Nodes)-1]
+}
+
+// graphColor converts a hex ("#f9f", "#ff9900", "#ff990080") or common named colour to RRGGBBAA.
+func graphColor(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(strings.Trim(strings.TrimSpace(s), `"`)))
+	if i := strings.IndexAny(s, ":;"); i >= 0 {
+		s = s[:i] // DOT colour lists ("red:blue") use the first colour
+	}
+	if hex, ok := namedGraphColors[s]; ok {
+		s = "#" + hex
+	}
+	if !strings.HasPrefix(s, "#") {
+		return "", false
+	}
+	h := s[1:]
+	if len(h) == 3 || len(h) == 4 {
+		var b strings.Builder
+		for _, c := range h {
+			b.WriteRune(c)
+			b.WriteRune(c)
+		}
+		h = b.String()
+	}
+	c, err := NormalizeColor(h)
+	if err != nil {
+		return "", false
+	}
+	return c, true
+}
+
+var namedGraphColors = map[string]string{
+	"white": "ffffff", "black": "000000", "red": "ff0000", "green": "008000", "blue": "0000ff",
+	"yellow": "ffff00", "orange": "ffa500", "purple": "800080", "pink": "ffc0cb", "gray": "808080",
+	"grey": "808080", "lightgray": "d3d3d3", "lightgrey": "d3d3d3", "darkgray": "a9a9a9", "darkgrey": "a9a9a9",
+	"lightblue": "add8e6", "lightgreen": "90ee90", "lightyellow": "ffffe0", "lightpink": "ffb6c1",
+	"cyan": "00ffff", "magenta": "ff00ff", "brown": "a52a2a", "gold": "ffd700", "navy": "000080",
+	"teal": "008080", "lime": "00ff00", "olive": "808000", "maroon": "800000", "silver": "c0c0c0",
+	"aquamarine": "7fffd4", "coral": "ff7f50", "salmon": "fa8072", "khaki": "f0e68c", "lavender": "e6e6fa",
+	"beige": "f5f5dc", "tomato": "ff6347", "orchid": "da70d6", "violet": "ee82ee", "turquoise": "40e0d0",
+	"skyblue": "87ceeb", "steelblue": "4682b4", "palegreen": "98fb98", "darkgreen": "006400",
+	"darkblue": "00008b", "darkred": "8b0000", "darkorange": "ff8c00", "transparent": "ffffff00",
+}
+
+// graphLayout assigns every node a cell (rank, position within rank) using a layered layout:
+// ranks are longest paths from the sources (back edges of cycles are ignored) and nodes within a
+// rank are ordered by the mean position of their predecessors within their ranks; nodes without
+// predecessors keep their first-appearance position within the rank.
+func graphLayout(g *Graph) map[string][2]int {
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.ID] = i
+	}
+	succ := make([][]int, len(g.Nodes))
+	for _, e := range g.Edges {
+		from, ok1 := index[e.From]
+		to, ok2 := index[e.To]
+		if ok1 && ok2 && from != to {
+			succ[from] = append(succ[from], to)
+		}
+	}
+
+	// Depth-first search in input order, dropping edges back onto the current path.
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make([]int, len(g.Nodes))
+	forward := make([][]int, len(g.Nodes))
+	var order []int // reverse topological order of the acyclic subgraph
+	var visit func(int)
+	visit = func(v int) {
+		state[v] = onPath
+		for _, w := range succ[v] {
+			if state[w] == onPath {
+				continue
+			}
+			forward[v] = append(forward[v], w)
+			if state[w] == unvisited {
+				visit(w)
+			}
+		}
+		state[v] = done
+		order = append(order, v)
+	}
+	for v := range g.Nodes {
+		if state[v] == unvisited {
+			visit(v)
+		}
+	}
+	rank := make([]int, len(g.Nodes))
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+		for _, w := range forward[v] {
+			if rank[v]+1 > rank[w] {
+				rank[w] = rank[v] + 1
+			}
+		}
+	}
+
+	var layers [][]int
+	for v := range g.Nodes {
+		for len(layers) <= rank[v] {
+			layers = append(layers, nil)
+		}
+		layers[rank[v]] = append(layers[rank[v]], v)
+	}
+	pos := make([]float64, len(g.Nodes))
+	preds := make([][]int, len(g.Nodes))
+	for v := range forward {
+		for _, w := range forward[v] {
+			preds[w] = append(preds[w], v)
+		}
+	}
+	cells := make(map[string][2]int, len(g.Nodes))
+	for r, layer := range layers {
+		// Keys are positions within a rank: a node's own first-appearance position in this
+		// rank, or the mean position of its predecessors in theirs.
+		key := make(map[int]float64, len(layer))
+		for i, v := range layer {
+			key[v] = float64(i)
+			if r > 0 && len(preds[v]) > 0 {
+				sum := 0.0
+				for _, p := range preds[v] {
+					sum += pos[p]
+				}
+				key[v] = sum / float64(len(preds[v]))
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return key[layer[i]] < key[layer[j]] })
+		for i, v := range layer {
+			pos[v] = float64(i)
+			cells[g.Nodes[v].ID] = [2]int{r, i}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,167 @@
+package canvus
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseMermaid(t *testing.T) {
+	g, err := ParseGraph(`%% build pipeline
+flowchart LR
+    A[Commit] --> B{Tests pass?}
+    B -->|yes| C(("Deploy<br>prod")) ; B -- no --- D[/Fix/]
+    C & D -.-> E:::done
+    E <--> A
+    classDef done fill:#9f9
+    style A fill:lightblue,stroke:#333
+    linkStyle 1 stroke:red
+`)
+	if err != nil {
+		t.Fatalf("ParseGraph: %v", err)
+	}
+	if g.Direction != "LR" || !g.Directed {
+		t.Errorf("header = %q, directed %v", g.Direction, g.Directed)
+	}
+	wantNodes := []GraphNode{
+		{ID: "A", Label: "Commit", Color: "ADD8E6FF"},
+		{ID: "B", Label: "Tests pass?"},
+		{ID: "C", Label: "Deploy\nprod"},
+		{ID: "D", Label: "Fix"},
+		{ID: "E", Label: "E", Color: "99FF99FF"},
+	}
+	if !reflect.DeepEqual(g.Nodes, wantNodes) {
+		t.Errorf("Nodes = %+v", g.Nodes)
+	}
+	wantEdges := []GraphEdge{
+		{From: "A", To: "B", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "B", To: "C", Label: "yes", Color: "FF0000FF", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "B", To: "D", Label: "no", SrcTip: tipNone, DstTip: tipNone},
+		{From: "C", To: "E", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "D", To: "E", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "E", To: "A", SrcTip: tipArrow, DstTip: tipArrow},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("Edges = %+v", g.Edges)
+	}
+	if _, err := ParseMermaid("sequenceDiagram\nA->>B: hi"); err == nil {
+		t.Error("expected error for a non-flowchart diagram")
+	}
+}
+
+func TestParseDOT(t *testing.T) {
+	g, err := ParseGraph(`// services
+digraph deps {
+  rankdir=LR
+  node [style=filled, color=yellow]
+  api [label="API\ngateway"];
+  db [fillcolor="#336699" shape=cylinder]
+  api -> { auth db } [label="calls"]
+  auth -> db [dir=both color=grey]
+  subgraph cluster_x { node [style=""]; cache:p1 }
+  api -> cache [arrowhead=none]
+  /* undirected-style edge */
+}`)
+	if err != nil {
+		t.Fatalf("ParseGraph: %v", err)
+	}
+	if g.Direction != "LR" || !g.Directed {
+		t.Errorf("header = %q, directed %v", g.Direction, g.Directed)
+	}
+	wantNodes := []GraphNode{
+		{ID: "api", Label: "API\ngateway", Color: "FFFF00FF"},
+		{ID: "db", Label: "db", Color: "336699FF"},
+		{ID: "auth", Label: "auth", Color: "FFFF00FF"},
+		{ID: "cache", Label: "cache"},
+	}
+	if !reflect.DeepEqual(g.Nodes, wantNodes) {
+		t.Errorf("Nodes = %+v", g.Nodes)
+	}
+	wantEdges := []GraphEdge{
+		{From: "api", To: "auth", Label: "calls", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "api", To: "db", Label: "calls", SrcTip: tipNone, DstTip: tipArrow},
+		{From: "auth", To: "db", Color: "808080FF", SrcTip: tipArrow, DstTip: tipArrow},
+		{From: "api", To: "cache", SrcTip: tipNone, DstTip: tipNone},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("Edges = %+v", g.Edges)
+	}
+
+	und, err := ParseDOT(`graph { a -- b }`)
+	if err != nil || und.Directed || und.Edges[0].DstTip != tipNone {
+		t.Errorf("undirected graph = %+v, %v", und, err)
+	}
+	if _, err := ParseDOT(`digraph { a -> }`); err == nil {
+		t.Error("expected error for a dangling edge")
+	}
+}
+
+func TestGraphLayoutRanks(t *testing.T) {
+	g := &Graph{
+		Nodes: []GraphNode{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}},
+		Edges: []GraphEdge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"}, {From: "c", To: "d"}, {From: "d", To: "a"}},
+	}
+	cells := graphLayout(g)
+	want := map[string][2]int{"a": {0, 0}, "b": {1, 0}, "c": {1, 1}, "d": {2, 0}}
+	if !reflect.DeepEqual(cells, want) {
+		t.Errorf("cells = %v", cells)
+	}
+}
+
+func TestGraphLayoutOrdersRanksByPosition(t *testing.T) {
+	// Targets declared before their sources follow their predecessors' positions.
+	g := &Graph{
+		Nodes: []GraphNode{{ID: "y"}, {ID: "x"}, {ID: "a"}, {ID: "b"}},
+		Edges: []GraphEdge{{From: "a", To: "x"}, {From: "b", To: "y"}},
+	}
+	cells := graphLayout(g)
+	want := map[string][2]int{"a": {0, 0}, "b": {0, 1}, "x": {1, 0}, "y": {1, 1}}
+	if !reflect.DeepEqual(cells, want) {
+		t.Errorf("cells = %v", cells)
+	}
+}
+
+func TestImportGraphReimportUpdatesInPlace(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases", map[string]interface{}{"id": "c1", "name": "Diagrams"})
+	s := fake.session()
+	ctx := context.Background()
+
+	g, _ := ParseMermaid("graph TD\n  a[Start] -->|go| b[End]")
+	res, err := s.ImportGraph(ctx, "c1", g, GraphImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportGraph: %v", err)
+	}
+	if len(res.Nodes) != 2 || len(res.Edges) != 1 || res.Edges["a->b"] == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	notes := fake.items("canvases/c1/notes")
+	if len(notes) != 3 { // two nodes and the edge label
+		t.Fatalf("notes = %v", notes)
+	}
+	conn := fake.items("canvases/c1/connectors")[0]
+	if conn["src"].(map[string]interface{})["id"] != res.Nodes["a"] || conn["dst"].(map[string]interface{})["tip"] != tipArrow {
+		t.Errorf("connector = %v", conn)
+	}
+
+	// Re-import with a renamed node and a new edge: nothing is duplicated.
+	g2, _ := ParseMermaid("graph TD\n  a[Begin] -->|go| b[End]\n  b --> c")
+	res2, err := s.ImportGraph(ctx, "c1", g2, GraphImportOptions{State: res.State})
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if res2.Nodes["a"] != res.Nodes["a"] || res2.Nodes["c"] == "" {
+		t.Errorf("re-import nodes = %v, first %v", res2.Nodes, res.Nodes)
+	}
+	if n := len(fake.items("canvases/c1/notes")); n != 4 {
+		t.Errorf("notes after re-import = %d, want 4", n)
+	}
+	for _, n := range fake.items("canvases/c1/notes") {
+		if n["id"] == res.Nodes["a"] && n["text"] != "Begin" {
+			t.Errorf("node a text = %v", n["text"])
+		}
+	}
+	if again, _ := s.ImportGraph(ctx, "c1", g2, GraphImportOptions{State: res2.State, DryRun: true}); !again.Plan.Empty() {
+		t.Errorf("third import not empty:\n%s", again.Plan)
+	}
+}
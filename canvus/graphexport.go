@@ -0,0 +1,313 @@
+package canvus
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// GraphFormat selects the output of ExportGraph and Graph.Encode.
+type GraphFormat string
+
+const (
+	GraphFormatDOT     GraphFormat = "dot"
+	GraphFormatGraphML GraphFormat = "graphml"
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+// CanvasGraph builds a Graph from a canvas's connectors and the widgets they join. Node IDs are
+// widget IDs; nodes are labelled by note text, anchor name, browser title (or URL) or asset
+// filename, and notes keep their background colour. Widgets without connectors are left out.
+func (s *Session) CanvasGraph(ctx context.Context, canvasID string) (*Graph, error) {
+	connectors, err := s.ListConnectors(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("CanvasGraph: %w", err)
+	}
+	widgets, err := s.ListWidgets(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("CanvasGraph: %w", err)
+	}
+	types := make(map[string]string, len(widgets))
+	for _, w := range widgets {
+		types[w.ID] = strings.ToLower(w.WidgetType)
+	}
+
+	g := &Graph{Directed: true, Direction: "TB"}
+	needed := map[string]bool{}
+	for _, c := range connectors {
+		if c.Src == nil || c.Dst == nil || c.Src.ID == "" || c.Dst.ID == "" {
+			continue
+		}
+		for _, id := range []string{c.Src.ID, c.Dst.ID} {
+			g.node(id)
+			needed[types[id]] = true
+		}
+		e := GraphEdge{From: c.Src.ID, To: c.Dst.ID, SrcTip: c.Src.Tip, DstTip: c.Dst.Tip}
+		if c.LineColor != "" {
+			if col, err := NormalizeColor(c.LineColor); err == nil {
+				e.Color = col
+			}
+		}
+		g.Edges = append(g.Edges, e)
+	}
+
+	labels, colors, err := s.graphNodeLabels(ctx, canvasID, needed)
+	if err != nil {
+		return nil, fmt.Errorf("CanvasGraph: %w", err)
+	}
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if l := labels[n.ID]; l != "" {
+			n.Label = l
+		} else if t := types[n.ID]; t != "" {
+			n.Label = t + " " + n.ID
+		}
+		n.Color = colors[n.ID]
+	}
+	return g, nil
+}
+
+// graphNodeLabels lists only the widget kinds that appear as graph nodes.
+func (s *Session) graphNodeLabels(ctx context.Context, canvasID string, kinds map[string]bool) (map[string]string, map[string]string, error) {
+	labels, colors := map[string]string{}, map[string]string{}
+	first := func(vs ...string) string {
+		for _, v := range vs {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	if kinds["note"] {
+		notes, err := s.ListNotes(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, n := range notes {
+			labels[n.ID] = first(n.Text, n.Title)
+			if c, err := NormalizeColor(n.BackgroundColor); err == nil {
+				colors[n.ID] = c
+			}
+		}
+	}
+	if kinds["anchor"] {
+		anchors, err := s.ListAnchors(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range anchors {
+			labels[a.ID] = a.AnchorName
+		}
+	}
+	if kinds["browser"] {
+		browsers, err := s.ListBrowsers(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, b := range browsers {
+			labels[b.ID] = first(b.Title, b.URL)
+		}
+	}
+	if kinds["image"] {
+		images, err := s.ListImages(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, im := range images {
+			labels[im.ID] = first(im.OriginalFilename, im.Title)
+		}
+	}
+	if kinds["pdf"] {
+		pdfs, err := s.ListPDFs(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range pdfs {
+			labels[p.ID] = first(p.OriginalFilename, p.Title)
+		}
+	}
+	if kinds["video"] {
+		videos, err := s.ListVideos(ctx, canvasID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range videos {
+			labels[v.ID] = first(v.OriginalFilename, v.Title)
+		}
+	}
+	return labels, colors, nil
+}
+
+// ExportGraph renders the connector graph of a canvas (see CanvasGraph) as DOT, GraphML or
+// Mermaid.
+//
+// Usage Example:
+//
+//	dot, err := session.ExportGraph(ctx, canvasID, canvus.GraphFormatDOT)
+//	_ = os.WriteFile("board.dot", []byte(dot), 0644)
+func (s *Session) ExportGraph(ctx context.Context, canvasID string, format GraphFormat) (string, error) {
+	g, err := s.CanvasGraph(ctx, canvasID)
+	if err != nil {
+		return "", fmt.Errorf("ExportGraph: %w", err)
+	}
+	out, err := g.Encode(format)
+	if err != nil {
+		return "", fmt.Errorf("ExportGraph: %w", err)
+	}
+	return out, nil
+}
+
+// Encode renders the graph in the given format. Edge direction comes from the tips: an arrow
+// at the destination only is a plain directed edge.
+func (g *Graph) Encode(format GraphFormat) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return g.encodeDOT(), nil
+	case GraphFormatGraphML:
+		return g.encodeGraphML(), nil
+	case GraphFormatMermaid:
+		return g.encodeMermaid(), nil
+	}
+	return "", fmt.Errorf("unsupported graph format %q", format)
+}
+
+// edgeDir describes an edge's arrows as a DOT dir value: "forward", "back", "both" or "none".
+func (e GraphEdge) edgeDir() string {
+	src, dst := e.SrcTip != "" && e.SrcTip != tipNone, e.DstTip != "" && e.DstTip != tipNone
+	switch {
+	case src && dst:
+		return "both"
+	case src:
+		return "back"
+	case dst:
+		return "forward"
+	}
+	return "none"
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func (g *Graph) encodeDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph canvas {\n")
+	if g.Direction != "" && g.Direction != "TB" {
+		fmt.Fprintf(&b, "  rankdir=%s;\n", g.Direction)
+	}
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s", dotQuote(n.ID), dotQuote(n.Label))
+		if n.Color != "" {
+			fmt.Fprintf(&b, " style=filled fillcolor=%s", dotQuote("#"+n.Color))
+		}
+		b.WriteString("];\n")
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		if e.Color != "" {
+			attrs = append(attrs, "color="+dotQuote("#"+e.Color))
+		}
+		if d := e.edgeDir(); d != "forward" {
+			attrs = append(attrs, "dir="+d)
+		}
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, " "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (g *Graph) encodeGraphML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="label" for="all" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="color" for="all" attr.name="color" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="dir" for="edge" attr.name="dir" attr.type="string"/>` + "\n")
+	b.WriteString(`  <graph id="canvas" edgedefault="directed">` + "\n")
+	data := func(key, val string) {
+		if val != "" {
+			fmt.Fprintf(&b, `      <data key="%s">%s</data>`+"\n", key, xmlEscape(val))
+		}
+	}
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, `    <node id="%s">`+"\n", xmlEscape(n.ID))
+		data("label", n.Label)
+		data("color", n.Color)
+		b.WriteString("    </node>\n")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(&b, `    <edge id="e%d" source="%s" target="%s">`+"\n", i, xmlEscape(e.From), xmlEscape(e.To))
+		data("label", e.Label)
+		data("color", e.Color)
+		data("dir", e.edgeDir())
+		b.WriteString("    </edge>\n")
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	return b.String()
+}
+
+// mermaidNodeID turns an arbitrary node ID (e.g. a widget UUID) into a Mermaid identifier.
+func mermaidNodeID(id string) string {
+	return "n_" + strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "\n", "<br>").Replace(s)
+}
+
+func (g *Graph) encodeMermaid() string {
+	var b strings.Builder
+	dir := g.Direction
+	if dir == "" {
+		dir = "TB"
+	}
+	fmt.Fprintf(&b, "flowchart %s\n", dir)
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidNodeID(n.ID), mermaidText(n.Label))
+	}
+	for _, e := range g.Edges {
+		from, to, link := e.From, e.To, "-->"
+		switch e.edgeDir() {
+		case "both":
+			link = "<-->"
+		case "back":
+			from, to = to, from // Mermaid has no tail-only arrow
+		case "none":
+			link = "---"
+		}
+		if e.Label != "" {
+			link += "|\"" + mermaidText(e.Label) + "\"|"
+		}
+		fmt.Fprintf(&b, "    %s %s %s\n", mermaidNodeID(from), link, mermaidNodeID(to))
+	}
+	for _, n := range g.Nodes {
+		if n.Color != "" {
+			fmt.Fprintf(&b, "    style %s fill:#%s\n", mermaidNodeID(n.ID), n.Color)
+		}
+	}
+	for i, e := range g.Edges {
+		if e.Color != "" {
+			fmt.Fprintf(&b, "    linkStyle %d stroke:#%s\n", i, e.Color)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package canvus
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportGraph(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "Plan \"A\"", "background_color": "#ffcc00"})
+	fake.seed("canvases/c1/browsers", map[string]interface{}{"id": "b1", "title": "", "url": "https://example.com"})
+	fake.seed("canvases/c1/images", map[string]interface{}{"id": "i1", "original_filename": "chart.png"})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n2", "text": "Unconnected"})
+	fake.seed("canvases/c1/connectors", map[string]interface{}{"id": "k1",
+		"src": map[string]interface{}{"id": "n1", "tip": tipNone},
+		"dst": map[string]interface{}{"id": "b1", "tip": tipArrow}, "line_color": "FF0000FF"})
+	fake.seed("canvases/c1/connectors", map[string]interface{}{"id": "k2",
+		"src": map[string]interface{}{"id": "b1", "tip": tipNone},
+		"dst": map[string]interface{}{"id": "i1", "tip": tipNone}})
+	s := fake.session()
+	ctx := context.Background()
+
+	g, err := s.CanvasGraph(ctx, "c1")
+	if err != nil {
+		t.Fatalf("CanvasGraph: %v", err)
+	}
+	wantNodes := []GraphNode{
+		{ID: "n1", Label: `Plan "A"`, Color: "FFCC00FF"},
+		{ID: "b1", Label: "https://example.com"},
+		{ID: "i1", Label: "chart.png"},
+	}
+	if !reflect.DeepEqual(g.Nodes, wantNodes) {
+		t.Errorf("Nodes = %+v", g.Nodes)
+	}
+	if fake.callCount("GET canvases/c1/videos") != 0 {
+		t.Error("listed videos although none are connected")
+	}
+
+	for _, format := range []GraphFormat{GraphFormatDOT, GraphFormatMermaid} {
+		out, err := s.ExportGraph(ctx, "c1", format)
+		if err != nil {
+			t.Fatalf("ExportGraph(%s): %v", format, err)
+		}
+		back, err := ParseGraph(out)
+		if err != nil {
+			t.Fatalf("re-parse %s: %v\n%s", format, err, out)
+		}
+		if len(back.Nodes) != 3 || len(back.Edges) != 2 {
+			t.Fatalf("%s round trip = %+v", format, back)
+		}
+		if e := back.Edges[0]; e.Color != "FF0000FF" || e.DstTip != tipArrow || e.SrcTip != tipNone {
+			t.Errorf("%s edge 0 = %+v", format, e)
+		}
+		if e := back.Edges[1]; e.DstTip != tipNone {
+			t.Errorf("%s edge 1 = %+v", format, e)
+		}
+		if back.Nodes[0].Color != "FFCC00FF" {
+			t.Errorf("%s node 0 = %+v", format, back.Nodes[0])
+		}
+	}
+
+	xml, err := s.ExportGraph(ctx, "c1", GraphFormatGraphML)
+	if err != nil || !strings.Contains(xml, `<data key="label">Plan &#34;A&#34;</data>`) || !strings.Contains(xml, `source="n1" target="b1"`) {
+		t.Errorf("GraphML = %s, %v", xml, err)
+	}
+	if _, err := s.ExportGraph(ctx, "c1", "svg"); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
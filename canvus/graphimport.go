@@ -0,0 +1,197 @@
+package canvus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Spec key prefixes for widgets created by ImportGraph.
+const (
+	graphNodeKeyPrefix  = "node:"
+	graphEdgeKeyPrefix  = "edge:"
+	graphLabelKeyPrefix = "label:"
+)
+
+// GraphImportOptions controls ImportGraph. Zero values select the defaults.
+type GraphImportOptions struct {
+	Origin    Point   // top-left corner of the layout
+	NodeSize  Size    // note size; default 300x200
+	Spacing   float64 // gap between neighbouring nodes; default 100, doubled between ranks
+	LabelSize Size    // size of the notes holding edge labels; default 200x80
+	// State is the State of a previous import into the same canvas. Nodes and edges it records
+	// are updated in place; ones no longer in the graph are deleted.
+	State *CanvasSpecState
+	// Relayout moves previously imported nodes to their new layout position. By default they
+	// keep wherever users have moved them and only new nodes are placed.
+	Relayout bool
+	DryRun   bool // plan only; the result's State is the input state
+}
+
+// GraphImportResult reports what ImportGraph did. Save State and pass it back as
+// GraphImportOptions.State to re-import without duplicating widgets.
+type GraphImportResult struct {
+	Plan  *CanvasPlan
+	State *CanvasSpecState
+	Nodes map[string]string // graph node ID -> note widget ID
+	Edges map[string]string // edge key ("from->to", with "#2", "#3"... for repeats) -> connector ID
+}
+
+// ImportGraph lays a Graph (see ParseMermaid, ParseDOT) out on a canvas as notes joined by
+// connectors, creating them with CreateNote and CreateConnector. Node fill colours become note
+// background colours; edge labels, which connectors cannot show, become small transparent notes
+// at the middle of the edge. Imports are planned and applied like a CanvasSpec, so re-importing
+// with the previous State updates the existing widgets.
+//
+// Usage Example:
+//
+//	g, _ := canvus.ParseGraph(src)
+//	state, _ := canvus.LoadCanvasSpecState("diagram.state.json")
+//	res, err := session.ImportGraph(ctx, canvasID, g, canvus.GraphImportOptions{State: state})
+//	_ = res.State.Save("diagram.state.json")
+func (s *Session) ImportGraph(ctx context.Context, canvasID string, g *Graph, opts GraphImportOptions) (*GraphImportResult, error) {
+	if g == nil {
+		return nil, fmt.Errorf("ImportGraph: graph is nil")
+	}
+	if opts.State != nil && opts.State.CanvasID != "" && opts.State.CanvasID != canvasID {
+		return nil, fmt.Errorf("ImportGraph: state belongs to canvas %s", opts.State.CanvasID)
+	}
+	spec := graphSpec(canvasID, g, opts)
+	plan, err := s.PlanCanvasSpec(ctx, spec, opts.State)
+	if err != nil {
+		return nil, fmt.Errorf("ImportGraph: %w", err)
+	}
+	res := &GraphImportResult{Plan: plan, State: plan.State}
+	if !opts.DryRun {
+		state, err := s.ApplyCanvasPlan(ctx, plan)
+		if state != nil {
+			res.State = state
+		}
+		if err != nil {
+			res.collectIDs()
+			return res, fmt.Errorf("ImportGraph: %w", err)
+		}
+	}
+	res.collectIDs()
+	return res, nil
+}
+
+func (r *GraphImportResult) collectIDs() {
+	r.Nodes, r.Edges = map[string]string{}, map[string]string{}
+	if r.State == nil {
+		return
+	}
+	for key, res := range r.State.Resources {
+		switch {
+		case strings.HasPrefix(key, graphNodeKeyPrefix):
+			r.Nodes[strings.TrimPrefix(key, graphNodeKeyPrefix)] = res.ID
+		case strings.HasPrefix(key, graphEdgeKeyPrefix):
+			r.Edges[strings.TrimPrefix(key, graphEdgeKeyPrefix)] = res.ID
+		}
+	}
+}
+
+// graphEdgeKeys returns a stable key per edge: "from->to", numbered from "#2" on for repeats.
+func graphEdgeKeys(edges []GraphEdge) []string {
+	seen := map[string]int{}
+	keys := make([]string, len(edges))
+	for i, e := range edges {
+		k := e.From + "->" + e.To
+		seen[k]++
+		if n := seen[k]; n > 1 {
+			k = fmt.Sprintf("%s#%d", k, n)
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+// graphSpec converts a laid-out graph into a CanvasSpec for the given canvas.
+func graphSpec(canvasID string, g *Graph, opts GraphImportOptions) *CanvasSpec {
+	if opts.NodeSize.Width <= 0 || opts.NodeSize.Height <= 0 {
+		opts.NodeSize = Size{Width: 300, Height: 200}
+	}
+	if opts.LabelSize.Width <= 0 || opts.LabelSize.Height <= 0 {
+		opts.LabelSize = Size{Width: 200, Height: 80}
+	}
+	if opts.Spacing <= 0 {
+		opts.Spacing = 100
+	}
+	tracked := func(key string) bool {
+		if opts.Relayout || opts.State == nil {
+			return false
+		}
+		_, ok := opts.State.Resources[key]
+		return ok
+	}
+
+	cells := graphLayout(g)
+	widest := 0
+	rankSize := map[int]int{}
+	for _, c := range cells {
+		rankSize[c[0]]++
+		if rankSize[c[0]] > widest {
+			widest = rankSize[c[0]]
+		}
+	}
+	ranks := len(rankSize)
+	horizontal := g.Direction == "LR" || g.Direction == "RL"
+	// Cross-axis step (within a rank) and main-axis step (between ranks).
+	crossLen, mainLen := opts.NodeSize.Width, opts.NodeSize.Height
+	if horizontal {
+		crossLen, mainLen = mainLen, crossLen
+	}
+	crossStep, mainStep := crossLen+opts.Spacing, mainLen+2*opts.Spacing
+	centers := make(map[string]Point, len(g.Nodes))
+	for id, c := range cells {
+		rank, i := c[0], c[1]
+		if g.Direction == "BT" || g.Direction == "RL" {
+			rank = ranks - 1 - rank
+		}
+		// Centre each rank on the widest one.
+		cross := (float64(widest-rankSize[c[0]])/2+float64(i))*crossStep + crossLen/2
+		main := float64(rank)*mainStep + mainLen/2
+		p := Point{X: opts.Origin.X + cross, Y: opts.Origin.Y + main}
+		if horizontal {
+			p = Point{X: opts.Origin.X + main, Y: opts.Origin.Y + cross}
+		}
+		centers[id] = p
+	}
+
+	spec := &CanvasSpec{Canvas: CanvasSpecCanvas{ID: canvasID}}
+	for _, n := range g.Nodes {
+		key := graphNodeKeyPrefix + n.ID
+		note := NoteSpec{WidgetSpec: WidgetSpec{Key: key}, Text: n.Label, BackgroundColor: n.Color}
+		if !tracked(key) {
+			c := centers[n.ID]
+			size := opts.NodeSize
+			note.Location = &Point{X: c.X - size.Width/2, Y: c.Y - size.Height/2}
+			note.Size = &size
+		}
+		spec.Notes = append(spec.Notes, note)
+	}
+	for i, key := range graphEdgeKeys(g.Edges) {
+		e := g.Edges[i]
+		spec.Connectors = append(spec.Connectors, ConnectorSpec{
+			Key:       graphEdgeKeyPrefix + key,
+			Src:       graphNodeKeyPrefix + e.From,
+			Dst:       graphNodeKeyPrefix + e.To,
+			SrcTip:    e.SrcTip,
+			DstTip:    e.DstTip,
+			LineColor: e.Color,
+		})
+		if e.Label == "" {
+			continue
+		}
+		lkey := graphLabelKeyPrefix + key
+		label := NoteSpec{WidgetSpec: WidgetSpec{Key: lkey}, Text: e.Label, BackgroundColor: "FFFFFF00"}
+		if !tracked(lkey) {
+			a, b := centers[e.From], centers[e.To]
+			size := opts.LabelSize
+			label.Location = &Point{X: (a.X+b.X)/2 - size.Width/2, Y: (a.Y+b.Y)/2 - size.Height/2}
+			label.Size = &size
+		}
+		spec.Notes = append(spec.Notes, label)
+	}
+	return spec
+}
@@ -36,13 +36,16 @@ type Group struct {
 	Description string `json:"description,omitempty"`
 }
 
-// ListGroups retrieves all groups from the Canvus API.
-func (s *Session) ListGroups(ctx context.Context) ([]Group, error) {
+// ListGroups retrieves all groups from the Canvus API. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListGroups(ctx context.Context, filter *Filter) ([]Group, error) {
 	var groups []Group
 	err := s.doRequest(ctx, "GET", "groups", nil, &groups, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListGroups: %w", err)
 	}
+	if filter != nil {
+		groups = FilterSlice(groups, filter)
+	}
 	return groups, nil
 }
 
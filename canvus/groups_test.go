@@ -27,7 +27,7 @@ func TestGroupLifecycle(t *testing.T) {
 	defer func() { _ = admin.DeleteGroup(ctx, group.ID) }()
 
 	// List groups and check the new group is present
-	groups, err := admin.ListGroups(ctx)
+	groups, err := admin.ListGroups(ctx, nil)
 	if err != nil {
 		t.Errorf("failed to list groups: %v", err)
 	}
@@ -69,7 +69,7 @@ func TestGroupLifecycle(t *testing.T) {
 	defer func() { _ = admin.DeleteUser(ctx, user.ID) }()
 
 	// Add user to group
-	err = admin.AddUserToGroup(ctx, group.ID, int(user.ID))
+	err = admin.AddUserToGroup(ctx, group.ID, user.ID)
 	if err != nil {
 		t.Errorf("failed to add user to group: %v", err)
 	}
@@ -91,7 +91,7 @@ func TestGroupLifecycle(t *testing.T) {
 	}
 
 	// Remove user from group
-	err = admin.RemoveUserFromGroup(ctx, group.ID, int(user.ID))
+	err = admin.RemoveUserFromGroup(ctx, group.ID, user.ID)
 	if err != nil {
 		t.Errorf("failed to remove user from group: %v", err)
 	}
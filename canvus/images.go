@@ -6,14 +6,17 @@ import (
 	"io"
 )
 
-// ListImages retrieves all images for a given canvas.
-func (s *Session) ListImages(ctx context.Context, canvasID string) ([]Image, error) {
+// ListImages retrieves all images for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListImages(ctx context.Context, canvasID string, filter *Filter) ([]Image, error) {
 	var images []Image
 	path := fmt.Sprintf("canvases/%s/images", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &images, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListImages: %w", err)
 	}
+	if filter != nil {
+		images = FilterSlice(images, filter)
+	}
 	return images, nil
 }
 
@@ -0,0 +1,123 @@
+package layout
+
+import (
+	"sort"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// Alignment selects the edge or centre line that Align lines items up on.
+type Alignment string
+
+const (
+	AlignLeft    Alignment = "left"
+	AlignRight   Alignment = "right"
+	AlignTop     Alignment = "top"
+	AlignBottom  Alignment = "bottom"
+	AlignCenterX Alignment = "center_x" // same horizontal centre (a vertical line)
+	AlignCenterY Alignment = "center_y" // same vertical centre (a horizontal line)
+)
+
+// Axis is the direction used by Distribute and Space.
+type Axis string
+
+const (
+	Horizontal Axis = "horizontal"
+	Vertical   Axis = "vertical"
+)
+
+// Align moves placements so they share an edge or centre line of their common bounds.
+// Sizes are unchanged; the input slice is not modified.
+func Align(placements []Placement, a Alignment) []Placement {
+	all := BoundsOf(placements)
+	out := append([]Placement(nil), placements...)
+	for i := range out {
+		b := out[i].Bounds()
+		switch a {
+		case AlignLeft:
+			b.X = all.X
+		case AlignRight:
+			b.X = all.X + all.Width - b.Width
+		case AlignTop:
+			b.Y = all.Y
+		case AlignBottom:
+			b.Y = all.Y + all.Height - b.Height
+		case AlignCenterX:
+			b.X = all.X + (all.Width-b.Width)/2
+		case AlignCenterY:
+			b.Y = all.Y + (all.Height-b.Height)/2
+		}
+		out[i].setBounds(b)
+	}
+	return out
+}
+
+// Distribute keeps the first and last placement along the axis in place and moves the others
+// so the gaps between neighbours are equal.
+func Distribute(placements []Placement, axis Axis) []Placement {
+	out, order := sortedAlong(placements, axis)
+	if len(order) < 3 {
+		return out
+	}
+	first, last := out[order[0]].Bounds(), out[order[len(order)-1]].Bounds()
+	start, end := axisStart(first, axis), axisStart(last, axis)+axisLen(last, axis)
+	sum := 0.0
+	for _, i := range order {
+		sum += axisLen(out[i].Bounds(), axis)
+	}
+	gap := (end - start - sum) / float64(len(order)-1)
+	spaceAlong(out, order, axis, gap)
+	return out
+}
+
+// Space keeps the first placement along the axis in place and moves the others so that
+// consecutive placements are exactly gap apart.
+func Space(placements []Placement, axis Axis, gap float64) []Placement {
+	out, order := sortedAlong(placements, axis)
+	spaceAlong(out, order, axis, gap)
+	return out
+}
+
+func sortedAlong(placements []Placement, axis Axis) ([]Placement, []int) {
+	out := append([]Placement(nil), placements...)
+	order := make([]int, len(out))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return axisStart(out[order[a]].Bounds(), axis) < axisStart(out[order[b]].Bounds(), axis)
+	})
+	return out, order
+}
+
+func spaceAlong(out []Placement, order []int, axis Axis, gap float64) {
+	if len(order) == 0 {
+		return
+	}
+	b := out[order[0]].Bounds()
+	next := axisStart(b, axis) + axisLen(b, axis) + gap
+	for _, i := range order[1:] {
+		b := out[i].Bounds()
+		if axis == Horizontal {
+			b.X = next
+		} else {
+			b.Y = next
+		}
+		out[i].setBounds(b)
+		next += axisLen(b, axis) + gap
+	}
+}
+
+func axisStart(r canvus.Rectangle, axis Axis) float64 {
+	if axis == Horizontal {
+		return r.X
+	}
+	return r.Y
+}
+
+func axisLen(r canvus.Rectangle, axis Axis) float64 {
+	if axis == Horizontal {
+		return r.Width
+	}
+	return r.Height
+}
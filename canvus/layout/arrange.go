@@ -0,0 +1,187 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// Grid arranges items in equal cells, row by row. columns <= 0 picks a near-square grid.
+// Each item is centred in its cell; with Options.Resize it is fitted to the cell.
+func Grid(items []Item, bounds canvus.Rectangle, columns int, opts Options) []Placement {
+	n := len(items)
+	if n == 0 {
+		return nil
+	}
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(n))))
+	}
+	if columns > n {
+		columns = n
+	}
+	rows := (n + columns - 1) / columns
+	in := opts.inner(bounds)
+	cellW := math.Max(0, (in.Width-opts.Spacing*float64(columns-1))/float64(columns))
+	cellH := math.Max(0, (in.Height-opts.Spacing*float64(rows-1))/float64(rows))
+
+	out := make([]Placement, 0, n)
+	for i, it := range items {
+		col, row := i%columns, i/columns
+		cx := in.X + float64(col)*(cellW+opts.Spacing)
+		cy := in.Y + float64(row)*(cellH+opts.Spacing)
+		e := opts.fit(it, cellW, cellH)
+		out = append(out, place(it, cx+(cellW-e.Width)/2, cy+(cellH-e.Height)/2, e))
+	}
+	return out
+}
+
+// Flow places items left to right, wrapping to a new row when the next item would cross the
+// right edge. Rows are as tall as their tallest item. With Options.Resize, items wider than the
+// bounds are shrunk to fit. Items may extend below the bounds if they do not all fit.
+func Flow(items []Item, bounds canvus.Rectangle, opts Options) []Placement {
+	in := opts.inner(bounds)
+	x, y, rowH := in.X, in.Y, 0.0
+	out := make([]Placement, 0, len(items))
+	for _, it := range items {
+		e := it.extent()
+		if e.Width > in.Width {
+			e = opts.fit(it, in.Width, math.Inf(1))
+		}
+		if x > in.X && x+e.Width > in.X+in.Width {
+			x, y, rowH = in.X, y+rowH+opts.Spacing, 0
+		}
+		out = append(out, place(it, x, y, e))
+		x += e.Width + opts.Spacing
+		rowH = math.Max(rowH, e.Height)
+	}
+	return out
+}
+
+// Column stacks items top to bottom along the left edge. With Options.Resize every item takes
+// the full width: notes and other free-form widgets keep their height, images, videos and PDFs
+// scale proportionally.
+func Column(items []Item, bounds canvus.Rectangle, opts Options) []Placement {
+	in := opts.inner(bounds)
+	y := in.Y
+	out := make([]Placement, 0, len(items))
+	for _, it := range items {
+		e := opts.fit(it, in.Width, math.Inf(1))
+		out = append(out, place(it, in.X, y, e))
+		y += e.Height + opts.Spacing
+	}
+	return out
+}
+
+// Radial places items evenly on a circle centred in the bounds, starting at the top and going
+// clockwise. The radius is chosen so the items stay inside the bounds.
+func Radial(items []Item, bounds canvus.Rectangle, opts Options) []Placement {
+	n := len(items)
+	if n == 0 {
+		return nil
+	}
+	in := opts.inner(bounds)
+	cx, cy := in.X+in.Width/2, in.Y+in.Height/2
+	maxHalf := 0.0
+	for _, it := range items {
+		e := it.extent()
+		maxHalf = math.Max(maxHalf, math.Max(e.Width, e.Height)/2)
+	}
+	r := math.Max(0, math.Min(in.Width, in.Height)/2-maxHalf)
+	out := make([]Placement, 0, n)
+	for i, it := range items {
+		a := -math.Pi/2 + 2*math.Pi*float64(i)/float64(n)
+		e := it.extent()
+		out = append(out, place(it, cx+r*math.Cos(a)-e.Width/2, cy+r*math.Sin(a)-e.Height/2, e))
+	}
+	return out
+}
+
+// Tree lays out items as a top-down hierarchy using Item.Parent: each depth is a row, leaves are
+// placed left to right and parents are centred over their children. Items whose parent is not
+// in the list are roots. With Options.Resize the whole tree is scaled down to fit the bounds.
+func Tree(items []Item, bounds canvus.Rectangle, opts Options) []Placement {
+	if len(items) == 0 {
+		return nil
+	}
+	byID := make(map[string]int, len(items))
+	for i, it := range items {
+		byID[it.ID] = i
+	}
+	children := make(map[int][]int)
+	var roots []int
+	for i, it := range items {
+		if p, ok := byID[it.Parent]; ok && p != i {
+			children[p] = append(children[p], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	depth := make([]int, len(items))
+	centerX := make([]float64, len(items))
+	visited := make([]bool, len(items))
+	var rowHeights []float64
+	cursor := 0.0
+	var visit func(i, d int)
+	visit = func(i, d int) {
+		visited[i] = true
+		depth[i] = d
+		if d >= len(rowHeights) {
+			rowHeights = append(rowHeights, 0)
+		}
+		e := items[i].extent()
+		rowHeights[d] = math.Max(rowHeights[d], e.Height)
+		var kids []int
+		for _, c := range children[i] {
+			if !visited[c] {
+				kids = append(kids, c)
+			}
+		}
+		if len(kids) == 0 {
+			centerX[i] = cursor + e.Width/2
+			cursor += e.Width + opts.Spacing
+			return
+		}
+		for _, c := range kids {
+			visit(c, d+1)
+		}
+		centerX[i] = (centerX[kids[0]] + centerX[kids[len(kids)-1]]) / 2
+	}
+	for _, r := range roots {
+		visit(r, 0)
+	}
+	// Items in parent cycles are never reached from a root; lay each cycle out from its first item.
+	for i := range items {
+		if !visited[i] {
+			visit(i, 0)
+		}
+	}
+
+	rowY := make([]float64, len(rowHeights))
+	for d := 1; d < len(rowHeights); d++ {
+		rowY[d] = rowY[d-1] + rowHeights[d-1] + opts.Spacing
+	}
+	out := make([]Placement, len(items))
+	for i, it := range items {
+		e := it.extent()
+		out[i] = place(it, centerX[i]-e.Width/2, rowY[depth[i]], e)
+	}
+
+	// Move the tree into the bounds, shrinking it if allowed and necessary.
+	in := opts.inner(bounds)
+	total := BoundsOf(out)
+	f := 1.0
+	if opts.Resize && total.Width > 0 && total.Height > 0 {
+		f = math.Min(1, math.Min(in.Width/total.Width, in.Height/total.Height))
+	}
+	for i := range out {
+		b := out[i].Bounds()
+		out[i].setBounds(canvus.Rectangle{
+			X:      in.X + (b.X-total.X)*f,
+			Y:      in.Y + (b.Y-total.Y)*f,
+			Width:  b.Width * f,
+			Height: b.Height * f,
+		})
+	}
+	return out
+}
@@ -0,0 +1,217 @@
+// Package layout arranges Canvus widgets inside a target rectangle. Layout functions are pure:
+// they take Items and return Placements, which Apply then writes back with UpdateWidget.
+//
+// Sizes are handled per widget type: images, videos and PDFs keep their aspect ratio when a
+// layout resizes them, while notes, browsers and anchors are stretched to fill their slot.
+// All geometry is in canvas units, i.e. the widget size multiplied by its scale and its
+// ancestors' scales; Apply converts locations back to the parent widget's local space.
+//
+// Usage Example:
+//
+//	widgets, _ := session.ListWidgets(ctx, canvasID, &canvus.Filter{Criteria: map[string]interface{}{"widget_type": "Note"}})
+//	placements := layout.Grid(layout.FromWidgets(widgets), area, 0, layout.Options{Spacing: 20, Resize: true})
+//	err := layout.Apply(ctx, session, canvasID, placements)
+package layout
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// Item is a widget to be arranged.
+type Item struct {
+	ID         string
+	WidgetType string      // e.g. "Note", "Image"; decides how the item may be resized
+	Size       canvus.Size // widget size as stored by the API
+	Scale      float64     // 0 is treated as 1
+	Parent     string      // parent item ID in the hierarchy drawn by Tree; not the widget's ParentID
+	// ParentTransform is the space the widget's location is stored in, for widgets nested in
+	// another widget (see canvus.CanvasGeometry.ParentTransform). The zero value is canvas space.
+	ParentTransform canvus.Transform
+}
+
+// FromWidgets converts widgets to Items, skipping connectors, the SharedCanvas and widgets
+// without a size. Nested widgets are resolved against their parents in the same list.
+func FromWidgets(widgets []canvus.Widget) []Item {
+	g := canvus.NewCanvasGeometry(widgets)
+	var items []Item
+	for _, w := range widgets {
+		if !arrangeable(w) {
+			continue
+		}
+		items = append(items, Item{ID: w.ID, WidgetType: w.WidgetType, Size: *w.Size, Scale: w.Scale, ParentTransform: g.ParentTransform(w)})
+	}
+	return items
+}
+
+// PlacementsFromWidgets returns the current placement of widgets already on a canvas, for Align,
+// Distribute and Space. g resolves parents and is usually built from the canvas's full widget
+// list, so that widgets may be a subset of it; if g is nil it is built from widgets. Connectors,
+// the SharedCanvas and widgets without a size are skipped.
+//
+// Usage Example:
+//
+//	all, _ := session.ListWidgets(ctx, canvasID, nil)
+//	notes, _ := session.ListWidgets(ctx, canvasID, &canvus.Filter{Criteria: map[string]interface{}{"widget_type": "Note"}})
+//	placements := layout.PlacementsFromWidgets(canvus.NewCanvasGeometry(all), notes)
+//	err := layout.Apply(ctx, session, canvasID, layout.Align(placements, layout.AlignTop))
+func PlacementsFromWidgets(g *canvus.CanvasGeometry, widgets []canvus.Widget) []Placement {
+	if g == nil {
+		g = canvus.NewCanvasGeometry(widgets)
+	}
+	var out []Placement
+	for _, w := range widgets {
+		if !arrangeable(w) {
+			continue
+		}
+		p := Placement{ID: w.ID, WidgetType: w.WidgetType, Scale: w.Scale, ParentTransform: g.ParentTransform(w)}
+		p.setBounds(g.CanvasRect(w))
+		out = append(out, p)
+	}
+	return out
+}
+
+func arrangeable(w canvus.Widget) bool {
+	return w.Size != nil && !strings.EqualFold(w.WidgetType, "Connector") && w.WidgetType != "SharedCanvas"
+}
+
+// scale is the item's total scale on the canvas: its own and its ancestors'.
+func (it Item) scale() float64 {
+	return positiveScale(it.Scale) * positiveScale(it.ParentTransform.Scale)
+}
+
+func positiveScale(s float64) float64 {
+	if s <= 0 {
+		return 1
+	}
+	return s
+}
+
+// extent is the item's size on the canvas.
+func (it Item) extent() canvus.Size {
+	s := it.scale()
+	return canvus.Size{Width: it.Size.Width * s, Height: it.Size.Height * s}
+}
+
+// KeepsAspect reports whether widgets of the given type must be resized proportionally.
+func KeepsAspect(widgetType string) bool {
+	switch strings.ToLower(widgetType) {
+	case "image", "video", "pdf":
+		return true
+	}
+	return false
+}
+
+// Placement is the computed position and size for one item.
+type Placement struct {
+	ID         string
+	WidgetType string
+	Location   canvus.Point // in canvas coordinates
+	Size       canvus.Size  // widget size to send to the API (canvas extent divided by the total scale)
+	Scale      float64
+	// ParentTransform is copied from the Item; Apply uses it to convert Location to the
+	// parent's local space.
+	ParentTransform canvus.Transform
+}
+
+func (p Placement) scale() float64 {
+	return positiveScale(p.Scale) * positiveScale(p.ParentTransform.Scale)
+}
+
+// Bounds returns the placement's rectangle in canvas units.
+func (p Placement) Bounds() canvus.Rectangle {
+	s := p.scale()
+	return canvus.Rectangle{X: p.Location.X, Y: p.Location.Y, Width: p.Size.Width * s, Height: p.Size.Height * s}
+}
+
+func (p *Placement) setBounds(r canvus.Rectangle) {
+	s := p.scale()
+	p.Location = canvus.Point{X: r.X, Y: r.Y}
+	p.Size = canvus.Size{Width: r.Width / s, Height: r.Height / s}
+}
+
+// Options are shared by all layouts.
+type Options struct {
+	Spacing float64 // gap between neighbouring items
+	Padding float64 // inset from the target rectangle
+	// Resize lets a layout change item sizes to fit its slots. Without it, sizes are kept.
+	Resize bool
+}
+
+func (o Options) inner(bounds canvus.Rectangle) canvus.Rectangle {
+	return canvus.Rectangle{
+		X:      bounds.X + o.Padding,
+		Y:      bounds.Y + o.Padding,
+		Width:  math.Max(0, bounds.Width-2*o.Padding),
+		Height: math.Max(0, bounds.Height-2*o.Padding),
+	}
+}
+
+// fit returns the canvas extent of it inside a maxW x maxH slot. Either limit may be +Inf.
+func (o Options) fit(it Item, maxW, maxH float64) canvus.Size {
+	e := it.extent()
+	if !o.Resize || e.Width <= 0 || e.Height <= 0 {
+		return e
+	}
+	if KeepsAspect(it.WidgetType) {
+		f := math.Min(maxW/e.Width, maxH/e.Height)
+		return canvus.Size{Width: e.Width * f, Height: e.Height * f}
+	}
+	if !math.IsInf(maxW, 1) {
+		e.Width = maxW
+	}
+	if !math.IsInf(maxH, 1) {
+		e.Height = maxH
+	}
+	return e
+}
+
+func place(it Item, x, y float64, extent canvus.Size) Placement {
+	p := Placement{ID: it.ID, WidgetType: it.WidgetType, Scale: it.Scale, ParentTransform: it.ParentTransform}
+	p.setBounds(canvus.Rectangle{X: x, Y: y, Width: extent.Width, Height: extent.Height})
+	return p
+}
+
+// BoundsOf returns the smallest rectangle enclosing all placements.
+func BoundsOf(placements []Placement) canvus.Rectangle {
+	if len(placements) == 0 {
+		return canvus.Rectangle{}
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range placements {
+		b := p.Bounds()
+		minX, minY = math.Min(minX, b.X), math.Min(minY, b.Y)
+		maxX, maxY = math.Max(maxX, b.X+b.Width), math.Max(maxY, b.Y+b.Height)
+	}
+	return canvus.Rectangle{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// WidgetUpdater is the part of *canvus.Session used by Apply.
+type WidgetUpdater interface {
+	UpdateWidget(ctx context.Context, canvasID, widgetID string, req map[string]interface{}) (*canvus.Widget, error)
+}
+
+// Apply writes placements back with UpdateWidget, stopping at the first error. Locations of
+// nested widgets are converted to their parent's local space, as the API expects.
+func Apply(ctx context.Context, u WidgetUpdater, canvasID string, placements []Placement) error {
+	for _, p := range placements {
+		loc := p.Location
+		if p.ParentTransform.Scale > 0 {
+			loc = p.ParentTransform.ToLocal(loc)
+		}
+		req := map[string]interface{}{
+			"widget_type": strings.ToLower(p.WidgetType),
+			"location":    map[string]interface{}{"x": loc.X, "y": loc.Y},
+			"size":        map[string]interface{}{"width": p.Size.Width, "height": p.Size.Height},
+		}
+		if _, err := u.UpdateWidget(ctx, canvasID, p.ID, req); err != nil {
+			return fmt.Errorf("Apply: widget %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
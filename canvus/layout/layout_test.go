@@ -0,0 +1,157 @@
+package layout
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func rectEq(t *testing.T, name string, got, want canvus.Rectangle) {
+	t.Helper()
+	if !near(got.X, want.X) || !near(got.Y, want.Y) || !near(got.Width, want.Width) || !near(got.Height, want.Height) {
+		t.Errorf("%s = %+v, want %+v", name, got, want)
+	}
+}
+
+func TestGridResizesPerType(t *testing.T) {
+	items := []Item{
+		{ID: "n", WidgetType: "Note", Size: canvus.Size{Width: 50, Height: 50}},
+		{ID: "i", WidgetType: "Image", Size: canvus.Size{Width: 200, Height: 100}},
+		{ID: "v", WidgetType: "Video", Size: canvus.Size{Width: 400, Height: 400}, Scale: 0.5},
+	}
+	bounds := canvus.Rectangle{X: 0, Y: 0, Width: 220, Height: 210}
+	got := Grid(items, bounds, 2, Options{Spacing: 10, Padding: 5, Resize: true})
+	// 2x2 grid of 100x95 cells inside a 5px padding.
+	rectEq(t, "note", got[0].Bounds(), canvus.Rectangle{X: 5, Y: 5, Width: 100, Height: 95})
+	rectEq(t, "image", got[1].Bounds(), canvus.Rectangle{X: 115, Y: 5 + 22.5, Width: 100, Height: 50})
+	rectEq(t, "video", got[2].Bounds(), canvus.Rectangle{X: 5 + 2.5, Y: 110, Width: 95, Height: 95})
+	if !near(got[2].Size.Width, 190) {
+		t.Errorf("scaled video API size = %v, want 190 (95 / 0.5)", got[2].Size.Width)
+	}
+}
+
+func TestFlowColumnRadial(t *testing.T) {
+	items := []Item{
+		{ID: "a", WidgetType: "Note", Size: canvus.Size{Width: 60, Height: 20}},
+		{ID: "b", WidgetType: "Note", Size: canvus.Size{Width: 60, Height: 40}},
+		{ID: "c", WidgetType: "Note", Size: canvus.Size{Width: 60, Height: 20}},
+	}
+	bounds := canvus.Rectangle{Width: 130, Height: 200}
+	flow := Flow(items, bounds, Options{Spacing: 10})
+	rectEq(t, "flow c", flow[2].Bounds(), canvus.Rectangle{X: 0, Y: 50, Width: 60, Height: 20})
+
+	col := Column(items, bounds, Options{Spacing: 5, Resize: true})
+	rectEq(t, "column b", col[1].Bounds(), canvus.Rectangle{X: 0, Y: 25, Width: 130, Height: 40})
+
+	rad := Radial(items[:2], canvus.Rectangle{Width: 200, Height: 200}, Options{})
+	rectEq(t, "radial top", rad[0].Bounds(), canvus.Rectangle{X: 70, Y: 20, Width: 60, Height: 20})
+	rectEq(t, "radial bottom", rad[1].Bounds(), canvus.Rectangle{X: 70, Y: 150, Width: 60, Height: 40})
+}
+
+func TestTree(t *testing.T) {
+	items := []Item{
+		{ID: "root", Size: canvus.Size{Width: 10, Height: 10}},
+		{ID: "a", Parent: "root", Size: canvus.Size{Width: 10, Height: 10}},
+		{ID: "b", Parent: "root", Size: canvus.Size{Width: 10, Height: 10}},
+		{ID: "b1", Parent: "b", Size: canvus.Size{Width: 10, Height: 10}},
+	}
+	got := Tree(items, canvus.Rectangle{X: 100, Y: 100, Width: 1000, Height: 1000}, Options{Spacing: 10})
+	rectEq(t, "root", got[0].Bounds(), canvus.Rectangle{X: 110, Y: 100, Width: 10, Height: 10})
+	rectEq(t, "a", got[1].Bounds(), canvus.Rectangle{X: 100, Y: 120, Width: 10, Height: 10})
+	rectEq(t, "b1", got[3].Bounds(), canvus.Rectangle{X: 120, Y: 140, Width: 10, Height: 10})
+
+	small := Tree(items, canvus.Rectangle{Width: 15, Height: 50}, Options{Spacing: 10, Resize: true})
+	if b := BoundsOf(small); b.Width > 15+1e-9 || b.Height > 50+1e-9 {
+		t.Errorf("resized tree does not fit: %+v", b)
+	}
+}
+
+func TestAlignDistributeSpace(t *testing.T) {
+	ps := []Placement{
+		{ID: "a", Location: canvus.Point{X: 0, Y: 0}, Size: canvus.Size{Width: 10, Height: 10}},
+		{ID: "b", Location: canvus.Point{X: 50, Y: 30}, Size: canvus.Size{Width: 20, Height: 20}},
+		{ID: "c", Location: canvus.Point{X: 90, Y: 5}, Size: canvus.Size{Width: 10, Height: 40}},
+	}
+	al := Align(ps, AlignBottom)
+	for _, p := range al {
+		if b := p.Bounds(); !near(b.Y+b.Height, 50) {
+			t.Errorf("AlignBottom %s = %+v", p.ID, b)
+		}
+	}
+	if ps[0].Location.Y != 0 {
+		t.Error("Align modified its input")
+	}
+	d := Distribute(ps, Horizontal)
+	// span 0..100, widths 40, so gaps of 30.
+	if !near(d[1].Location.X, 40) || !near(d[2].Location.X, 90) {
+		t.Errorf("Distribute = %+v", d)
+	}
+	s := Space(ps, Vertical, 5)
+	if !near(s[2].Location.Y, 15) || !near(s[1].Location.Y, 60) {
+		t.Errorf("Space = %+v", s)
+	}
+}
+
+type recordingUpdater struct {
+	reqs map[string]map[string]interface{}
+}
+
+func (r *recordingUpdater) UpdateWidget(ctx context.Context, canvasID, widgetID string, req map[string]interface{}) (*canvus.Widget, error) {
+	r.reqs[widgetID] = req
+	return &canvus.Widget{ID: widgetID}, nil
+}
+
+func TestApply(t *testing.T) {
+	widgets := []canvus.Widget{
+		{ID: "n1", WidgetType: "Note", Size: &canvus.Size{Width: 10, Height: 10}},
+		{ID: "x", WidgetType: "Connector"},
+		{ID: "p", WidgetType: "PDF", Size: &canvus.Size{Width: 10, Height: 20}},
+	}
+	items := FromWidgets(widgets)
+	if len(items) != 2 {
+		t.Fatalf("FromWidgets = %+v", items)
+	}
+	u := &recordingUpdater{reqs: map[string]map[string]interface{}{}}
+	if err := Apply(context.Background(), u, "c1", Column(items, canvus.Rectangle{X: 5}, Options{})); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if u.reqs["p"]["widget_type"] != "pdf" || u.reqs["p"]["location"].(map[string]interface{})["y"] != 10.0 {
+		t.Errorf("Apply request = %v", u.reqs["p"])
+	}
+}
+
+func TestPlacementsFromWidgetsNested(t *testing.T) {
+	all := []canvus.Widget{
+		{ID: "root", WidgetType: "SharedCanvas", Size: &canvus.Size{Width: 1000, Height: 1000}},
+		{ID: "frame", WidgetType: "Anchor", ParentID: "root", Location: &canvus.Point{X: 100, Y: 100}, Size: &canvus.Size{Width: 200, Height: 200}, Scale: 2},
+		{ID: "a", WidgetType: "Note", ParentID: "frame", Location: &canvus.Point{X: 10, Y: 10}, Size: &canvus.Size{Width: 50, Height: 50}},
+		{ID: "b", WidgetType: "Note", ParentID: "root", Location: &canvus.Point{X: 400, Y: 300}, Size: &canvus.Size{Width: 50, Height: 50}},
+	}
+	ps := PlacementsFromWidgets(canvus.NewCanvasGeometry(all), all[2:])
+	if len(ps) != 2 {
+		t.Fatalf("PlacementsFromWidgets = %+v", ps)
+	}
+	rectEq(t, "nested", ps[0].Bounds(), canvus.Rectangle{X: 120, Y: 120, Width: 100, Height: 100})
+	if ps[0].Size.Width != 50 {
+		t.Errorf("API size = %+v, want the widget's own size", ps[0].Size)
+	}
+	if items := FromWidgets(all); items[1].Parent != "" || items[1].ParentTransform.Scale != 2 {
+		t.Errorf("nested item = %+v", items[1])
+	}
+
+	u := &recordingUpdater{reqs: map[string]map[string]interface{}{}}
+	if err := Apply(context.Background(), u, "c1", Align(ps, AlignTop)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	// Both tops move to y=120 on the canvas; the nested note's location is in the frame's space.
+	if loc := u.reqs["a"]["location"].(map[string]interface{}); loc["x"] != 10.0 || loc["y"] != 10.0 {
+		t.Errorf("nested location = %v", loc)
+	}
+	if loc := u.reqs["b"]["location"].(map[string]interface{}); loc["x"] != 400.0 || loc["y"] != 120.0 {
+		t.Errorf("top-level location = %v", loc)
+	}
+}
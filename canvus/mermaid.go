@@ -0,0 +1,311 @@
+package canvus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mermaidShapes lists node shape delimiters, longest openers first.
+var mermaidShapes = []struct{ open, close string }{
+	{"(((", ")))"}, {"((", "))"}, {"([", "])"}, {"[(", ")]"}, {"[[", "]]"}, {"{{", "}}"},
+	{"[/", "]"}, {`[\`, "]"}, {"(", ")"}, {"[", "]"}, {"{", "}"}, {">", "]"},
+}
+
+var (
+	mermaidID   = regexp.MustCompile(`^[\p{L}\p{N}_]+`)
+	mermaidLink = regexp.MustCompile(`^(<)?([-=.]{2,})([>ox])?`)
+	// mermaidLinkEnd finds the closing half of a "A -- text --> B" link.
+	mermaidLinkEnd = regexp.MustCompile(`(--|==|\.-)[-=.]*[>ox]?`)
+	mermaidBreak   = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// ParseMermaid parses a Mermaid flowchart ("flowchart LR" or "graph TD"). It understands node
+// shapes and labels, chained and "&"-grouped links with arrow heads and labels ("-->|text|",
+// "-- text -->"), style, classDef, class, ":::class" and linkStyle fill/stroke colours.
+// Subgraphs are flattened.
+//
+// Usage Example:
+//
+//	g, err := canvus.ParseMermaid("flowchart LR\n  A[Start] --> B{Ok?}\n  B -->|yes| C[Done]")
+func ParseMermaid(src string) (*Graph, error) {
+	g := &Graph{Directed: true, Direction: "TB"}
+	p := &mermaidParser{g: g, classColors: map[string]string{}, nodeClasses: map[string]string{}, styles: map[string]string{}}
+	header := false
+	for lineNo, line := range strings.Split(src, "\n") {
+		for _, stmt := range splitMermaidStatements(line) {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || strings.HasPrefix(stmt, "%%") {
+				continue
+			}
+			if !header {
+				fields := strings.Fields(stmt)
+				kw := strings.ToLower(fields[0])
+				if kw != "flowchart" && kw != "graph" {
+					return nil, fmt.Errorf("ParseMermaid: line %d: expected flowchart or graph header", lineNo+1)
+				}
+				if len(fields) > 1 {
+					g.Direction = mermaidDirection(fields[1])
+				}
+				header = true
+				continue
+			}
+			if err := p.statement(stmt); err != nil {
+				return nil, fmt.Errorf("ParseMermaid: line %d: %w", lineNo+1, err)
+			}
+		}
+	}
+	if !header {
+		return nil, fmt.Errorf("ParseMermaid: missing flowchart header")
+	}
+	p.applyColors()
+	return g, nil
+}
+
+func mermaidDirection(d string) string {
+	switch strings.ToUpper(d) {
+	case "LR", "RL", "BT":
+		return strings.ToUpper(d)
+	}
+	return "TB" // TD and TB
+}
+
+// splitMermaidStatements splits a line on ';' outside quotes and shape brackets.
+func splitMermaidStatements(line string) []string {
+	var out []string
+	depth, quoted, start := 0, false, 0
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case strings.ContainsRune("([{", c):
+			depth++
+		case strings.ContainsRune(")]}", c):
+			depth--
+		case c == ';' && depth <= 0:
+			out = append(out, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, line[start:])
+}
+
+type mermaidParser struct {
+	g           *Graph
+	classColors map[string]string // classDef name -> fill colour
+	nodeClasses map[string]string // node ID -> class name
+	styles      map[string]string // node ID -> style fill colour
+	linkColors  map[int]string    // edge index -> stroke colour; -1 is linkStyle default
+}
+
+func (p *mermaidParser) statement(stmt string) error {
+	fields := strings.Fields(stmt)
+	switch strings.ToLower(fields[0]) {
+	case "subgraph", "end", "direction", "click":
+		return nil
+	case "style":
+		if len(fields) > 2 {
+			if c, ok := mermaidStyleColor(strings.Join(fields[2:], " "), "fill"); ok {
+				p.styles[fields[1]] = c
+			}
+		}
+		return nil
+	case "classdef":
+		if len(fields) > 2 {
+			if c, ok := mermaidStyleColor(strings.Join(fields[2:], " "), "fill"); ok {
+				for _, name := range strings.Split(fields[1], ",") {
+					p.classColors[name] = c
+				}
+			}
+		}
+		return nil
+	case "class":
+		if len(fields) > 2 {
+			for _, id := range strings.Split(fields[1], ",") {
+				p.nodeClasses[strings.TrimSpace(id)] = fields[2]
+			}
+		}
+		return nil
+	case "linkstyle":
+		if len(fields) > 2 {
+			if c, ok := mermaidStyleColor(strings.Join(fields[2:], " "), "stroke"); ok {
+				if p.linkColors == nil {
+					p.linkColors = map[int]string{}
+				}
+				for _, idx := range strings.Split(fields[1], ",") {
+					if idx == "default" {
+						p.linkColors[-1] = c
+					} else if n, err := strconv.Atoi(idx); err == nil {
+						p.linkColors[n] = c
+					}
+				}
+			}
+		}
+		return nil
+	}
+	return p.chain(stmt)
+}
+
+// chain parses "nodes (link nodes)*" where nodes is "node (& node)*".
+func (p *mermaidParser) chain(s string) error {
+	prev, rest, err := p.nodeGroup(s)
+	if err != nil {
+		return err
+	}
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return nil
+		}
+		edge, after, err := parseMermaidLink(rest)
+		if err != nil {
+			return err
+		}
+		next, after, err := p.nodeGroup(after)
+		if err != nil {
+			return err
+		}
+		for _, from := range prev {
+			for _, to := range next {
+				e := edge
+				e.From, e.To = from, to
+				p.g.Edges = append(p.g.Edges, e)
+			}
+		}
+		prev, rest = next, after
+	}
+}
+
+func (p *mermaidParser) nodeGroup(s string) ([]string, string, error) {
+	var ids []string
+	for {
+		id, rest, err := p.nodeRef(strings.TrimSpace(s))
+		if err != nil {
+			return nil, "", err
+		}
+		ids = append(ids, id)
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "&") {
+			return ids, rest, nil
+		}
+		s = rest[1:]
+	}
+}
+
+// nodeRef parses "id", "id[label]" (any shape) and an optional ":::class" suffix.
+func (p *mermaidParser) nodeRef(s string) (string, string, error) {
+	id := mermaidID.FindString(s)
+	if id == "" {
+		return "", "", fmt.Errorf("expected node id at %q", s)
+	}
+	rest := s[len(id):]
+	n := p.g.node(id)
+	for _, sh := range mermaidShapes {
+		if !strings.HasPrefix(rest, sh.open) {
+			continue
+		}
+		body := rest[len(sh.open):]
+		var label string
+		if strings.HasPrefix(strings.TrimSpace(body), `"`) {
+			body = strings.TrimSpace(body)[1:]
+			end := strings.Index(body, `"`)
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated label for node %s", id)
+			}
+			label, body = body[:end], strings.TrimSpace(body[end+1:])
+			if !strings.HasPrefix(body, sh.close) {
+				return "", "", fmt.Errorf("expected %q after label of node %s", sh.close, id)
+			}
+		} else {
+			end := strings.Index(body, sh.close)
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated shape for node %s", id)
+			}
+			label, body = body[:end], body[end:]
+			label = strings.TrimRight(label, `/\`)
+		}
+		n.Label = mermaidBreak.ReplaceAllString(strings.TrimSpace(label), "\n")
+		rest = body[len(sh.close):]
+		break
+	}
+	if strings.HasPrefix(rest, ":::") {
+		cls := mermaidID.FindString(rest[3:])
+		p.nodeClasses[id] = cls
+		rest = rest[3+len(cls):]
+	}
+	return id, rest, nil
+}
+
+// parseMermaidLink parses a link and its optional label at the start of s.
+func parseMermaidLink(s string) (GraphEdge, string, error) {
+	m := mermaidLink.FindStringSubmatch(s)
+	if m == nil {
+		return GraphEdge{}, "", fmt.Errorf("expected link at %q", s)
+	}
+	e := GraphEdge{SrcTip: tipNone, DstTip: tipNone}
+	rest := s[len(m[0]):]
+	head := m[3]
+	if head == "" && (m[2] == "--" || m[2] == "==" || m[2] == "-.") {
+		// "A -- text --> B": the label runs up to the closing half of the link.
+		loc := mermaidLinkEnd.FindStringIndex(rest)
+		if loc == nil {
+			return GraphEdge{}, "", fmt.Errorf("unterminated link label at %q", s)
+		}
+		e.Label = strings.TrimSpace(rest[:loc[0]])
+		closing := rest[loc[0]:loc[1]]
+		if last := closing[len(closing)-1]; last == '>' || last == 'o' || last == 'x' {
+			head = string(last)
+		}
+		rest = rest[loc[1]:]
+	}
+	if head != "" {
+		e.DstTip = tipArrow
+	}
+	if m[1] == "<" {
+		e.SrcTip = tipArrow
+	}
+	if t := strings.TrimSpace(rest); strings.HasPrefix(t, "|") {
+		end := strings.Index(t[1:], "|")
+		if end < 0 {
+			return GraphEdge{}, "", fmt.Errorf("unterminated link label at %q", t)
+		}
+		e.Label = strings.TrimSpace(t[1 : end+1])
+		rest = t[end+2:]
+	}
+	e.Label = mermaidBreak.ReplaceAllString(strings.Trim(e.Label, `"`), "\n")
+	return e, rest, nil
+}
+
+// mermaidStyleColor returns the colour of a property ("fill" or "stroke") in a style list
+// such as "fill:#f9f,stroke:#333,stroke-width:4px".
+func mermaidStyleColor(style, prop string) (string, bool) {
+	for _, part := range strings.Split(style, ",") {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == prop {
+			return graphColor(kv[1])
+		}
+	}
+	return "", false
+}
+
+func (p *mermaidParser) applyColors() {
+	for i := range p.g.Nodes {
+		n := &p.g.Nodes[i]
+		if c, ok := p.styles[n.ID]; ok {
+			n.Color = c
+		} else if c, ok := p.classColors[p.nodeClasses[n.ID]]; ok {
+			n.Color = c
+		} else if c, ok := p.classColors["default"]; ok {
+			n.Color = c
+		}
+	}
+	for i := range p.g.Edges {
+		if c, ok := p.linkColors[i]; ok {
+			p.g.Edges[i].Color = c
+		} else if c, ok := p.linkColors[-1]; ok {
+			p.g.Edges[i].Color = c
+		}
+	}
+}
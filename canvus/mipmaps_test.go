@@ -0,0 +1,44 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMipmapAndAssetBodies(t *testing.T) {
+	fake := newFakeCanvus(t)
+	s := fake.session()
+	ctx := context.Background()
+
+	fake.handle("GET", "api/v1/mipmaps/abc", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		if r.Header.Get("canvas-id") != "c1" {
+			t.Errorf("canvas-id header = %q", r.Header.Get("canvas-id"))
+		}
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"resolution": map[string]interface{}{"width": 640, "height": 480}, "max_level": 3, "pages": 1})
+	})
+	fake.handle("GET", "api/v1/mipmaps/abc/3", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		w.Header().Set("Content-Type", "image/webp")
+		_, _ = w.Write([]byte("RIFF-level-3"))
+	})
+	fake.handle("GET", "api/v1/assets/abc", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("asset-bytes"))
+	})
+
+	info, err := s.GetMipmapInfo(ctx, "c1", "abc", nil)
+	if err != nil {
+		t.Fatalf("GetMipmapInfo: %v", err)
+	}
+	if info.Resolution.Width != 640 || info.MaxLevel != 3 {
+		t.Errorf("info = %+v", info)
+	}
+	level, err := s.GetMipmapLevel(ctx, "c1", "abc", 3, nil)
+	if err != nil || string(level) != "RIFF-level-3" {
+		t.Errorf("GetMipmapLevel = %q, %v", level, err)
+	}
+	asset, err := s.GetAssetByHash(ctx, "c1", "abc")
+	if err != nil || string(asset) != "asset-bytes" {
+		t.Errorf("GetAssetByHash = %q, %v", asset, err)
+	}
+}
@@ -0,0 +1,337 @@
+package canvus
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NoteItem is one sticky note to import with ImportNotes.
+type NoteItem struct {
+	Text     string
+	Color    string // any form NormalizeColor accepts; empty picks the group's preset colour
+	Group    string // notes sharing a group are clustered together
+	Location *Point // explicit canvas position; nil places the note in its group's cluster
+}
+
+// csvNoteColumns maps accepted CSV header names to NoteItem fields.
+var csvNoteColumns = map[string]string{
+	"text": "text", "note": "text", "idea": "text",
+	"color": "color", "colour": "color",
+	"group": "group", "category": "group", "cluster": "group",
+	"x": "x", "y": "y", "position": "position",
+}
+
+// ParseNotesCSV reads notes from CSV with a header row. The text column (also "note" or "idea")
+// is required; color/colour, group (also "category" or "cluster"), and either x and y or a
+// "x,y" position column are optional. Rows with empty text are skipped.
+//
+// Usage Example:
+//
+//	f, _ := os.Open("ideas.csv")
+//	items, err := canvus.ParseNotesCSV(f)
+func ParseNotesCSV(r io.Reader) ([]NoteItem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ParseNotesCSV: %w", err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		if field, ok := csvNoteColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")))]; ok {
+			if _, dup := cols[field]; !dup {
+				cols[field] = i
+			}
+		}
+	}
+	if _, ok := cols["text"]; !ok {
+		return nil, fmt.Errorf("ParseNotesCSV: header has no text column")
+	}
+	var items []NoteItem
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ParseNotesCSV: %w", err)
+		}
+		get := func(field string) string {
+			if i, ok := cols[field]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		item := NoteItem{Text: get("text"), Color: get("color"), Group: get("group")}
+		if item.Text == "" {
+			continue
+		}
+		x, y := get("x"), get("y")
+		if pos := get("position"); pos != "" && x == "" && y == "" {
+			parts := strings.FieldsFunc(pos, func(r rune) bool { return r == ',' || r == ';' || r == ' ' })
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("ParseNotesCSV: row %d: position %q is not \"x,y\"", row, pos)
+			}
+			x, y = parts[0], parts[1]
+		}
+		if x != "" || y != "" {
+			px, errX := strconv.ParseFloat(x, 64)
+			py, errY := strconv.ParseFloat(y, 64)
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("ParseNotesCSV: row %d: invalid position %q,%q", row, x, y)
+			}
+			item.Location = &Point{X: px, Y: py}
+		}
+		items = append(items, item)
+	}
+}
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdBullet  = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.*)$`)
+)
+
+// ParseNotesMarkdown reads notes from Markdown: every bullet or numbered item becomes a note,
+// grouped under the nearest heading above it. Indented lines that follow an item continue it.
+//
+// Usage Example:
+//
+//	items, err := canvus.ParseNotesMarkdown("# Keep\n- Standups\n# Change\n- Release cadence")
+func ParseNotesMarkdown(src string) ([]NoteItem, error) {
+	var items []NoteItem
+	group, open := "", false
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			open = false
+		case mdHeading.MatchString(line):
+			group, open = mdHeading.FindStringSubmatch(line)[2], false
+		case mdBullet.MatchString(line):
+			text := strings.TrimSpace(mdBullet.FindStringSubmatch(line)[1])
+			if open = text != ""; open {
+				items = append(items, NoteItem{Text: text, Group: group})
+			}
+		case open && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			items[len(items)-1].Text += " " + strings.TrimSpace(line)
+		default:
+			open = false
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("ParseNotesMarkdown: no list items found")
+	}
+	return items, nil
+}
+
+// NoteImportOptions controls ImportNotes. Zero values select the defaults.
+type NoteImportOptions struct {
+	Origin   Point   // top-left corner of the first cluster
+	NoteSize Size    // default 300x300
+	Spacing  float64 // gap between notes in a cluster; default 20, five times that between clusters
+	Columns  int     // notes per row in a cluster; default the square root of the cluster size
+	// GroupsPerRow is the number of clusters side by side; default the square root of the
+	// number of groups.
+	GroupsPerRow int
+	// Anchors creates an anchor named after each non-empty group, framing its cluster.
+	Anchors bool
+	// SnapToPresets replaces explicit colours with the nearest note background colour preset of
+	// the canvas. Notes without a colour always take their group's preset, in preset order.
+	SnapToPresets bool
+}
+
+// NoteImportResult reports what ImportNotes created.
+type NoteImportResult struct {
+	Notes   []*Note            // in item order
+	Anchors map[string]*Anchor // group name -> anchor
+}
+
+// ImportNotes creates sticky notes from items (see ParseNotesCSV and ParseNotesMarkdown),
+// clustering them by group in a grid of clusters. Colours are validated with NormalizeColor
+// before anything is created; groups without explicit colours cycle through the canvas's note
+// background presets from GetColorPresets. On a failed create the partial result is returned.
+//
+// Usage Example:
+//
+//	items, _ := canvus.ParseNotesMarkdown(retro)
+//	res, err := session.ImportNotes(ctx, canvasID, items, canvus.NoteImportOptions{Anchors: true})
+func (s *Session) ImportNotes(ctx context.Context, canvasID string, items []NoteItem, opts NoteImportOptions) (*NoteImportResult, error) {
+	if opts.NoteSize.Width <= 0 || opts.NoteSize.Height <= 0 {
+		opts.NoteSize = Size{Width: 300, Height: 300}
+	}
+	if opts.Spacing <= 0 {
+		opts.Spacing = 20
+	}
+
+	colors := make([]string, len(items))
+	var errs ValidationErrors
+	needPresets := opts.SnapToPresets
+	for i, it := range items {
+		if it.Color == "" {
+			needPresets = true
+			continue
+		}
+		c, err := NormalizeColor(it.Color)
+		if err != nil {
+			errs.Add(fmt.Sprintf("items[%d].color", i), err.Error())
+		}
+		colors[i] = c
+	}
+	if errs.HasErrors() {
+		return nil, fmt.Errorf("ImportNotes: %w", errs)
+	}
+	var presets []string
+	if needPresets {
+		cp, err := s.GetColorPresets(ctx, canvasID)
+		if err != nil {
+			return nil, fmt.Errorf("ImportNotes: %w", err)
+		}
+		for _, p := range cp.NoteBackground {
+			if c, err := NormalizeColor(p); err == nil {
+				presets = append(presets, c)
+			}
+		}
+	}
+
+	var groups []string
+	members := map[string][]int{}
+	for i, it := range items {
+		if _, ok := members[it.Group]; !ok {
+			groups = append(groups, it.Group)
+		}
+		members[it.Group] = append(members[it.Group], i)
+	}
+	for gi, g := range groups {
+		for _, i := range members[g] {
+			switch {
+			case len(presets) == 0:
+			case colors[i] == "":
+				colors[i] = presets[gi%len(presets)]
+			case opts.SnapToPresets:
+				colors[i] = nearestColor(colors[i], presets)
+			}
+		}
+	}
+
+	rects := noteClusterLayout(items, groups, members, opts)
+	res := &NoteImportResult{Anchors: map[string]*Anchor{}}
+	if opts.Anchors {
+		for _, g := range groups {
+			if g == "" {
+				continue
+			}
+			frame := noteGroupFrame(rects, members[g], opts.Spacing)
+			anchor, err := s.CreateAnchor(ctx, canvasID, map[string]interface{}{
+				"anchor_name": g,
+				"location":    map[string]interface{}{"x": frame.X, "y": frame.Y},
+				"size":        map[string]interface{}{"width": frame.Width, "height": frame.Height},
+			})
+			if err != nil {
+				return res, fmt.Errorf("ImportNotes: group %q: %w", g, err)
+			}
+			res.Anchors[g] = anchor
+		}
+	}
+	for i, it := range items {
+		r := rects[i]
+		req := map[string]interface{}{
+			"text":     it.Text,
+			"location": map[string]interface{}{"x": r.X, "y": r.Y},
+			"size":     map[string]interface{}{"width": r.Width, "height": r.Height},
+		}
+		if colors[i] != "" {
+			req["background_color"] = colors[i]
+		}
+		note, err := s.CreateNote(ctx, canvasID, req)
+		if err != nil {
+			return res, fmt.Errorf("ImportNotes: item %d: %w", i+1, err)
+		}
+		res.Notes = append(res.Notes, note)
+	}
+	return res, nil
+}
+
+// noteClusterLayout returns each item's rectangle. Items without a Location fill their group's
+// cluster row by row; clusters are laid out in rows of GroupsPerRow, each row as tall as its
+// tallest cluster.
+func noteClusterLayout(items []NoteItem, groups []string, members map[string][]int, opts NoteImportOptions) []Rectangle {
+	rects := make([]Rectangle, len(items))
+	nw, nh, gap := opts.NoteSize.Width, opts.NoteSize.Height, opts.Spacing
+	perRow := opts.GroupsPerRow
+	if perRow <= 0 {
+		perRow = int(math.Ceil(math.Sqrt(float64(len(groups)))))
+	}
+	x, y, rowHeight := opts.Origin.X, opts.Origin.Y, 0.0
+	col := 0
+	for _, g := range groups {
+		var placed []int
+		for _, i := range members[g] {
+			if p := items[i].Location; p != nil {
+				rects[i] = Rectangle{X: p.X, Y: p.Y, Width: nw, Height: nh}
+			} else {
+				placed = append(placed, i)
+			}
+		}
+		if len(placed) == 0 {
+			continue
+		}
+		cols := opts.Columns
+		if cols <= 0 {
+			cols = int(math.Ceil(math.Sqrt(float64(len(placed)))))
+		}
+		if cols > len(placed) {
+			cols = len(placed)
+		}
+		rows := (len(placed) + cols - 1) / cols
+		if col == perRow {
+			x, y, rowHeight, col = opts.Origin.X, y+rowHeight+5*gap, 0, 0
+		}
+		for k, i := range placed {
+			rects[i] = Rectangle{X: x + float64(k%cols)*(nw+gap), Y: y + float64(k/cols)*(nh+gap), Width: nw, Height: nh}
+		}
+		w := float64(cols)*(nw+gap) - gap
+		h := float64(rows)*(nh+gap) - gap
+		rowHeight = math.Max(rowHeight, h)
+		x += w + 5*gap
+		col++
+	}
+	return rects
+}
+
+// noteGroupFrame is the bounding box of a group's notes grown by pad on every side.
+func noteGroupFrame(rects []Rectangle, idx []int, pad float64) Rectangle {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, i := range idx {
+		r := rects[i]
+		minX, minY = math.Min(minX, r.X), math.Min(minY, r.Y)
+		maxX, maxY = math.Max(maxX, r.X+r.Width), math.Max(maxY, r.Y+r.Height)
+	}
+	return Rectangle{X: minX - pad, Y: minY - pad, Width: maxX - minX + 2*pad, Height: maxY - minY + 2*pad}
+}
+
+// nearestColor returns the palette entry closest to c in RGB space. Alpha is kept from the
+// palette entry.
+func nearestColor(c string, palette []string) string {
+	r, g, b, _, err := ColorToRGBA(c)
+	if err != nil {
+		return c
+	}
+	best, bestDist := c, math.Inf(1)
+	for _, p := range palette {
+		pr, pg, pb, _, err := ColorToRGBA(p)
+		if err != nil {
+			continue
+		}
+		dr, dg, db := float64(r)-float64(pr), float64(g)-float64(pg), float64(b)-float64(pb)
+		if d := dr*dr + dg*dg + db*db; d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
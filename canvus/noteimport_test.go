@@ -0,0 +1,102 @@
+package canvus
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNotesCSV(t *testing.T) {
+	items, err := ParseNotesCSV(strings.NewReader("Idea,Colour,Category,Position\n" +
+		"\"Faster, smaller builds\",#ff0,Tooling,\n" +
+		",,Tooling,\n" +
+		"Pin this,,Misc,\"100, 200\"\n"))
+	if err != nil {
+		t.Fatalf("ParseNotesCSV: %v", err)
+	}
+	want := []NoteItem{
+		{Text: "Faster, smaller builds", Color: "#ff0", Group: "Tooling"},
+		{Text: "Pin this", Group: "Misc", Location: &Point{X: 100, Y: 200}},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v", items)
+	}
+	if _, err := ParseNotesCSV(strings.NewReader("name,color\nx,red\n")); err == nil {
+		t.Error("expected error without a text column")
+	}
+	if _, err := ParseNotesCSV(strings.NewReader("text,x,y\na,1,\n")); err == nil {
+		t.Error("expected error for a half position")
+	}
+}
+
+func TestParseNotesMarkdown(t *testing.T) {
+	items, err := ParseNotesMarkdown(`Intro paragraph.
+
+- Loose idea
+## Keep ##
+- Standups
+  that stay short
+* [x] Pairing
+## Change
+1. Release cadence
+
+Not an item
+`)
+	if err != nil {
+		t.Fatalf("ParseNotesMarkdown: %v", err)
+	}
+	want := []NoteItem{
+		{Text: "Loose idea"},
+		{Text: "Standups that stay short", Group: "Keep"},
+		{Text: "Pairing", Group: "Keep"},
+		{Text: "Release cadence", Group: "Change"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestImportNotes(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases/c1/colorpresets", map[string]interface{}{"note_background": []interface{}{"FFEE58FF", "81D4FAFF"}})
+	s := fake.session()
+	ctx := context.Background()
+
+	items := []NoteItem{
+		{Text: "a", Group: "Keep"}, {Text: "b", Group: "Keep"}, {Text: "c", Group: "Keep"},
+		{Text: "d", Group: "Change", Color: "#0000ff"},
+		{Text: "e", Group: "Change", Location: &Point{X: 5000, Y: 5000}},
+	}
+	if _, err := s.ImportNotes(ctx, "c1", []NoteItem{{Text: "x", Color: "nope"}}, NoteImportOptions{}); err == nil {
+		t.Fatal("expected a colour validation error")
+	}
+	if n := len(fake.items("canvases/c1/notes")); n != 0 {
+		t.Fatalf("created %d notes despite invalid colour", n)
+	}
+
+	res, err := s.ImportNotes(ctx, "c1", items, NoteImportOptions{NoteSize: Size{Width: 100, Height: 100}, Spacing: 10, Anchors: true, SnapToPresets: true})
+	if err != nil {
+		t.Fatalf("ImportNotes: %v", err)
+	}
+	if len(res.Notes) != 5 || len(res.Anchors) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	notes := fake.items("canvases/c1/notes")
+	loc := func(i int) Point {
+		l := notes[i]["location"].(map[string]interface{})
+		return Point{X: l["x"].(float64), Y: l["y"].(float64)}
+	}
+	// "Keep" is a 2x2 cluster at the origin, "Change" starts after it and a 50-unit gap.
+	wantLoc := []Point{{0, 0}, {110, 0}, {0, 110}, {260, 0}, {5000, 5000}}
+	wantColor := []string{"FFEE58FF", "FFEE58FF", "FFEE58FF", "81D4FAFF", "81D4FAFF"}
+	for i := range notes {
+		if loc(i) != wantLoc[i] || notes[i]["background_color"] != wantColor[i] {
+			t.Errorf("note %d at %v colour %v", i, loc(i), notes[i]["background_color"])
+		}
+	}
+	keep := fake.items("canvases/c1/anchors")[0]
+	if keep["anchor_name"] != "Keep" || keep["size"].(map[string]interface{})["width"] != 230.0 {
+		t.Errorf("anchor = %v", keep)
+	}
+}
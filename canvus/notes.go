@@ -5,11 +5,11 @@ import (
 	"fmt"
 )
 
-// ListNotes retrieves all notes for a given canvas.
+// ListNotes retrieves all notes for a given canvas. If filter is non-nil, results are filtered client-side.
 //
 // API Limitation: The 'title' field is not exposed by the Canvus API.
 // Responses will not include title values. See WarningNoteTitleNotExposed.
-func (s *Session) ListNotes(ctx context.Context, canvasID string) ([]Note, error) {
+func (s *Session) ListNotes(ctx context.Context, canvasID string, filter *Filter) ([]Note, error) {
 	warnOnce(WarningNoteTitleNotExposed)
 	var notes []Note
 	path := fmt.Sprintf("canvases/%s/notes", canvasID)
@@ -17,6 +17,9 @@ func (s *Session) ListNotes(ctx context.Context, canvasID string) ([]Note, error
 	if err != nil {
 		return nil, fmt.Errorf("ListNotes: %w", err)
 	}
+	if filter != nil {
+		notes = FilterSlice(notes, filter)
+	}
 	return notes, nil
 }
 
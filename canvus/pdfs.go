@@ -5,14 +5,17 @@ import (
 	"fmt"
 )
 
-// ListPDFs retrieves all PDFs for a given canvas.
-func (s *Session) ListPDFs(ctx context.Context, canvasID string) ([]PDF, error) {
+// ListPDFs retrieves all PDFs for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListPDFs(ctx context.Context, canvasID string, filter *Filter) ([]PDF, error) {
 	var pdfs []PDF
 	path := fmt.Sprintf("canvases/%s/pdfs", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &pdfs, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListPDFs: %w", err)
 	}
+	if filter != nil {
+		pdfs = FilterSlice(pdfs, filter)
+	}
 	return pdfs, nil
 }
 
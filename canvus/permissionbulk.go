@@ -0,0 +1,445 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PermissionPrincipal is the user or group a bulk permission change applies to.
+type PermissionPrincipal struct {
+	Kind string `json:"kind"` // "user" or "group"
+	ID   int64  `json:"id"`
+}
+
+// UserPrincipal returns a PermissionPrincipal for a user.
+func UserPrincipal(id int64) PermissionPrincipal { return PermissionPrincipal{Kind: "user", ID: id} }
+
+// GroupPrincipal returns a PermissionPrincipal for a group.
+func GroupPrincipal(id int) PermissionPrincipal {
+	return PermissionPrincipal{Kind: "group", ID: int64(id)}
+}
+
+func (p PermissionPrincipal) String() string { return fmt.Sprintf("%s %d", p.Kind, p.ID) }
+
+// PermissionTarget is a canvas or folder selected for a bulk permission change.
+type PermissionTarget struct {
+	Kind string `json:"kind"` // "canvas" or "folder"
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"` // tree path, if selected from a FolderTree
+}
+
+func (t PermissionTarget) String() string {
+	if t.Path != "" {
+		return t.Kind + " " + t.Path
+	}
+	return t.Kind + " " + t.ID
+}
+
+// SelectTargetsByPath selects the canvas or folder at path. For a folder, its canvases are selected,
+// including those in subfolders if recursive is true; includeFolders also selects the folders themselves.
+func SelectTargetsByPath(tree *FolderTree, path string, recursive, includeFolders bool) ([]PermissionTarget, error) {
+	entry, err := tree.Resolve(path)
+	if err != nil {
+		return nil, fmt.Errorf("SelectTargetsByPath: %w", err)
+	}
+	if entry.Canvas != nil {
+		return []PermissionTarget{{Kind: "canvas", ID: entry.Canvas.ID, Name: entry.Canvas.Name, Path: entry.Path}}, nil
+	}
+	targets, err := folderTargets(entry.Folder, recursive, includeFolders)
+	if err != nil {
+		return nil, fmt.Errorf("SelectTargetsByPath: %w", err)
+	}
+	return targets, nil
+}
+
+// folderTargets selects the canvases in folder, and optionally the folders themselves.
+func folderTargets(folder *FolderNode, recursive, includeFolders bool) ([]PermissionTarget, error) {
+	var targets []PermissionTarget
+	visit := func(n *FolderNode) error {
+		if includeFolders && n.ID != "" {
+			targets = append(targets, PermissionTarget{Kind: "folder", ID: n.ID, Name: n.Name, Path: n.Path})
+		}
+		for _, c := range n.Canvases {
+			targets = append(targets, PermissionTarget{Kind: "canvas", ID: c.ID, Name: c.Name, Path: JoinFolderPath(n.Path, c.Name)})
+		}
+		return nil
+	}
+	if !recursive {
+		return targets, visit(folder)
+	}
+	if err := walkFolder(folder, visit); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// SelectTargetsByFilter selects all canvases matching filter.
+func (s *Session) SelectTargetsByFilter(ctx context.Context, filter *Filter) ([]PermissionTarget, error) {
+	canvases, err := s.ListCanvases(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("SelectTargetsByFilter: %w", err)
+	}
+	targets := make([]PermissionTarget, 0, len(canvases))
+	for _, c := range canvases {
+		if c.InTrash {
+			continue
+		}
+		targets = append(targets, PermissionTarget{Kind: "canvas", ID: c.ID, Name: c.Name})
+	}
+	return targets, nil
+}
+
+// SelectTargetsByOwner selects all canvases in a user's home folder (the folder whose ID is the user ID)
+// and its subfolders.
+func SelectTargetsByOwner(tree *FolderTree, userID int64) ([]PermissionTarget, error) {
+	home := tree.Folder(strconv.FormatInt(userID, 10))
+	if home == nil {
+		return nil, fmt.Errorf("SelectTargetsByOwner: no home folder for user %d", userID)
+	}
+	// Walk the node itself: home folders are named after their owners, so paths can be ambiguous.
+	targets, err := folderTargets(home, true, false)
+	if err != nil {
+		return nil, fmt.Errorf("SelectTargetsByOwner: %w", err)
+	}
+	return targets, nil
+}
+
+// BulkPermissionOp is the kind of bulk permission change.
+type BulkPermissionOp string
+
+const (
+	BulkPermissionGrant  BulkPermissionOp = "grant"  // add or change the principal's override
+	BulkPermissionRevoke BulkPermissionOp = "revoke" // remove the principal's direct override
+)
+
+// BulkPermissionChange describes a grant or revoke applied to many targets.
+type BulkPermissionChange struct {
+	Op              BulkPermissionOp
+	Principal       PermissionPrincipal
+	Level           PermissionLevel // required for grants
+	DryRun          bool            // compute the diff and rollback data without writing
+	ContinueOnError bool            // keep going when a single target fails
+}
+
+// PermissionTargetChange is the outcome for one target of a bulk change.
+type PermissionTargetChange struct {
+	Target  PermissionTarget
+	Before  PermissionLevel // the principal's direct override before the change ("" if none)
+	After   PermissionLevel // the principal's direct override after the change ("" if none)
+	Changed bool
+	Note    string // e.g. why a revoke left an inherited override in place
+	Err     error
+}
+
+// BulkPermissionResult is the result of BulkUpdatePermissions.
+type BulkPermissionResult struct {
+	Changes  []PermissionTargetChange
+	Rollback *PermissionRollback
+	DryRun   bool
+}
+
+// Diff renders the changes one target per line.
+func (r *BulkPermissionResult) Diff() string {
+	var b strings.Builder
+	changed := 0
+	for _, c := range r.Changes {
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(&b, "! %s: %v\n", c.Target, c.Err)
+		case c.Changed:
+			changed++
+			fmt.Fprintf(&b, "~ %s: %s -> %s", c.Target, levelOrDash(c.Before), levelOrDash(c.After))
+			if c.Note != "" {
+				fmt.Fprintf(&b, " (%s)", c.Note)
+			}
+			b.WriteString("\n")
+		default:
+			fmt.Fprintf(&b, "= %s: unchanged", c.Target)
+			if c.Note != "" {
+				fmt.Fprintf(&b, " (%s)", c.Note)
+			}
+			b.WriteString("\n")
+		}
+	}
+	verb := "changed"
+	if r.DryRun {
+		verb = "would change"
+	}
+	fmt.Fprintf(&b, "%d of %d targets %s\n", changed, len(r.Changes), verb)
+	return b.String()
+}
+
+func levelOrDash(l PermissionLevel) string {
+	if l == "" {
+		return "-"
+	}
+	return string(l)
+}
+
+// PermissionRollback records the direct overrides of every modified target before a bulk change.
+type PermissionRollback struct {
+	CreatedAt time.Time                 `json:"created_at"`
+	Principal PermissionPrincipal       `json:"principal"`
+	Op        BulkPermissionOp          `json:"op"`
+	Entries   []PermissionRollbackEntry `json:"entries"`
+}
+
+// PermissionRollbackEntry is the saved override set for one target.
+type PermissionRollbackEntry struct {
+	Target PermissionTarget    `json:"target"`
+	Before PermissionOverrides `json:"before"`
+}
+
+// Save writes the rollback data as indented JSON.
+func (r *PermissionRollback) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("PermissionRollback.Save: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("PermissionRollback.Save: %w", err)
+	}
+	return nil
+}
+
+// LoadPermissionRollback reads rollback data written by PermissionRollback.Save.
+func LoadPermissionRollback(path string) (*PermissionRollback, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPermissionRollback: %w", err)
+	}
+	var r PermissionRollback
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("LoadPermissionRollback: %w", err)
+	}
+	return &r, nil
+}
+
+// PermissionOverrides holds the direct (non-inherited) overrides of a canvas or folder. Inherited entries
+// are never written back, since sending them would turn them into direct overrides.
+// LinkPermission only applies to canvases.
+type PermissionOverrides struct {
+	EditorsCanShare bool                 `json:"editors_can_share"`
+	LinkPermission  string               `json:"link_permission,omitempty"`
+	Users           []PermissionOverride `json:"users"`
+	Groups          []PermissionOverride `json:"groups"`
+	inheritedUsers  map[int64]PermissionLevel
+	inheritedGroups map[int64]PermissionLevel
+}
+
+func (s *Session) readPermissionSet(ctx context.Context, t PermissionTarget) (*PermissionOverrides, error) {
+	set := &PermissionOverrides{inheritedUsers: map[int64]PermissionLevel{}, inheritedGroups: map[int64]PermissionLevel{}}
+	add := func(id int64, perm string, inherited, group bool) {
+		switch {
+		case inherited && group:
+			set.inheritedGroups[id] = PermissionLevel(perm)
+		case inherited:
+			set.inheritedUsers[id] = PermissionLevel(perm)
+		case group:
+			set.Groups = append(set.Groups, PermissionOverride{ID: id, Permission: perm})
+		default:
+			set.Users = append(set.Users, PermissionOverride{ID: id, Permission: perm})
+		}
+	}
+	switch t.Kind {
+	case "canvas":
+		p, err := s.GetCanvasPermissions(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		set.EditorsCanShare, set.LinkPermission = p.EditorsCanShare, p.LinkPermission
+		for _, u := range p.Users {
+			add(u.ID, u.Permission, u.Inherited, false)
+		}
+		for _, g := range p.Groups {
+			add(g.ID, g.Permission, g.Inherited, true)
+		}
+	case "folder":
+		p, err := s.GetFolderPermissions(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		set.EditorsCanShare = p.EditorsCanShare
+		for _, u := range p.Users {
+			add(u.ID, u.Permission, u.Inherited, false)
+		}
+		for _, g := range p.Groups {
+			add(g.ID, g.Permission, g.Inherited, true)
+		}
+	default:
+		return nil, fmt.Errorf("unknown target kind %q", t.Kind)
+	}
+	return set, nil
+}
+
+func (s *Session) writePermissionSet(ctx context.Context, t PermissionTarget, set *PermissionOverrides) error {
+	switch t.Kind {
+	case "canvas":
+		p := CanvasPermissions{EditorsCanShare: set.EditorsCanShare, LinkPermission: set.LinkPermission,
+			Users: []CanvasUserPermission{}, Groups: []CanvasGroupPermission{}}
+		for _, u := range set.Users {
+			p.Users = append(p.Users, CanvasUserPermission{ID: u.ID, Permission: u.Permission})
+		}
+		for _, g := range set.Groups {
+			p.Groups = append(p.Groups, CanvasGroupPermission{ID: g.ID, Permission: g.Permission})
+		}
+		_, err := s.SetCanvasPermissions(ctx, t.ID, p)
+		return err
+	case "folder":
+		p := FolderPermissions{EditorsCanShare: set.EditorsCanShare,
+			Users: []FolderUserPermission{}, Groups: []FolderGroupPermission{}}
+		for _, u := range set.Users {
+			p.Users = append(p.Users, FolderUserPermission{ID: u.ID, Permission: u.Permission})
+		}
+		for _, g := range set.Groups {
+			p.Groups = append(p.Groups, FolderGroupPermission{ID: g.ID, Permission: g.Permission})
+		}
+		_, err := s.SetFolderPermissions(ctx, t.ID, p)
+		return err
+	}
+	return fmt.Errorf("unknown target kind %q", t.Kind)
+}
+
+// restorePrincipalOverride replaces the principal's direct override on the target with the one
+// recorded in e, or removes it if e had none.
+func (s *Session) restorePrincipalOverride(ctx context.Context, e PermissionRollbackEntry, principal PermissionPrincipal) error {
+	current, err := s.readPermissionSet(ctx, e.Target)
+	if err != nil {
+		return err
+	}
+	next := current.clone()
+	entries, recorded := &next.Users, e.Before.Users
+	if principal.Kind == "group" {
+		entries, recorded = &next.Groups, e.Before.Groups
+	}
+	kept := (*entries)[:0]
+	for _, o := range *entries {
+		if o.ID != principal.ID {
+			kept = append(kept, o)
+		}
+	}
+	for _, o := range recorded {
+		if o.ID == principal.ID {
+			kept = append(kept, o)
+		}
+	}
+	*entries = kept
+	return s.writePermissionSet(ctx, e.Target, next)
+}
+
+// clone copies the direct entries of a permission set.
+func (set *PermissionOverrides) clone() *PermissionOverrides {
+	out := *set
+	out.Users = append([]PermissionOverride(nil), set.Users...)
+	out.Groups = append([]PermissionOverride(nil), set.Groups...)
+	return &out
+}
+
+// apply performs op on a copy of set and returns it with the principal's level before and after.
+func (set *PermissionOverrides) apply(change BulkPermissionChange) (next *PermissionOverrides, before, after PermissionLevel, note string) {
+	next = set.clone()
+	entries, inherited := &next.Users, set.inheritedUsers
+	if change.Principal.Kind == "group" {
+		entries, inherited = &next.Groups, set.inheritedGroups
+	}
+	idx := -1
+	for i, e := range *entries {
+		if e.ID == change.Principal.ID {
+			idx = i
+			before = PermissionLevel(e.Permission)
+		}
+	}
+	switch change.Op {
+	case BulkPermissionGrant:
+		if idx >= 0 {
+			(*entries)[idx].Permission = string(change.Level)
+		} else {
+			*entries = append(*entries, PermissionOverride{ID: change.Principal.ID, Permission: string(change.Level)})
+		}
+		after = change.Level
+	case BulkPermissionRevoke:
+		if idx >= 0 {
+			*entries = append((*entries)[:idx], (*entries)[idx+1:]...)
+		}
+		if lvl, ok := inherited[change.Principal.ID]; ok {
+			note = fmt.Sprintf("still inherits %s from a parent folder", lvl)
+		}
+	}
+	return next, before, after, note
+}
+
+// BulkUpdatePermissions grants or revokes a principal's override on many canvases and folders.
+// Each target is updated with a read-modify-write of its direct overrides, so entries for other users
+// and groups, editors_can_share and link_permission are preserved. The result includes a diff and a
+// rollback record that restores the previous overrides of every target that changed.
+//
+// Usage Example:
+//
+//	tree, _ := session.LoadFolderTree(ctx)
+//	targets, _ := canvus.SelectTargetsByPath(tree, "/Team/Project", true, true)
+//	res, err := session.BulkUpdatePermissions(ctx, targets, canvus.BulkPermissionChange{
+//		Op: canvus.BulkPermissionGrant, Principal: canvus.GroupPrincipal(7), Level: canvus.PermissionEdit, DryRun: true,
+//	})
+//	fmt.Print(res.Diff())
+//	_ = res.Rollback.Save("rollback.json")
+func (s *Session) BulkUpdatePermissions(ctx context.Context, targets []PermissionTarget, change BulkPermissionChange) (*BulkPermissionResult, error) {
+	switch {
+	case change.Op != BulkPermissionGrant && change.Op != BulkPermissionRevoke:
+		return nil, fmt.Errorf("BulkUpdatePermissions: unknown op %q", change.Op)
+	case change.Op == BulkPermissionGrant && change.Level.Rank() == 0 && change.Level != PermissionNone:
+		return nil, fmt.Errorf("BulkUpdatePermissions: invalid level %q", change.Level)
+	case change.Principal.Kind != "user" && change.Principal.Kind != "group":
+		return nil, fmt.Errorf("BulkUpdatePermissions: invalid principal kind %q", change.Principal.Kind)
+	}
+	result := &BulkPermissionResult{
+		DryRun:   change.DryRun,
+		Rollback: &PermissionRollback{CreatedAt: time.Now().UTC(), Principal: change.Principal, Op: change.Op},
+	}
+	for _, t := range targets {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("BulkUpdatePermissions: %w", err)
+		}
+		tc := PermissionTargetChange{Target: t}
+		set, err := s.readPermissionSet(ctx, t)
+		if err == nil {
+			var next *PermissionOverrides
+			next, tc.Before, tc.After, tc.Note = set.apply(change)
+			tc.Changed = tc.Before != tc.After
+			if tc.Changed && !change.DryRun {
+				err = s.writePermissionSet(ctx, t, next)
+			}
+			if tc.Changed && err == nil {
+				result.Rollback.Entries = append(result.Rollback.Entries, PermissionRollbackEntry{Target: t, Before: *set})
+			}
+		}
+		tc.Err = err
+		result.Changes = append(result.Changes, tc)
+		if err != nil && !change.ContinueOnError {
+			return result, fmt.Errorf("BulkUpdatePermissions: %s: %w", t, err)
+		}
+	}
+	return result, nil
+}
+
+// ApplyPermissionRollback restores the rollback principal's overrides recorded in a rollback. Each
+// target is re-read and only the principal's entry is put back, so changes made to other users,
+// groups and settings since the bulk update are kept. All entries are attempted; the returned error
+// joins the targets that failed.
+func (s *Session) ApplyPermissionRollback(ctx context.Context, rollback *PermissionRollback) error {
+	var failed []string
+	for _, e := range rollback.Entries {
+		if err := s.restorePrincipalOverride(ctx, e, rollback.Principal); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", e.Target, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("ApplyPermissionRollback: %d targets failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func seedBulkPermissions(f *fakeCanvus) {
+	f.seed("canvas-folders", map[string]interface{}{"id": "root", "name": "", "folder_id": ""})
+	f.seed("canvas-folders", map[string]interface{}{"id": "1000", "name": "alice", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "proj", "name": "Project", "folder_id": "1000"})
+	f.seed("canvases", map[string]interface{}{"id": "c1", "name": "One", "folder_id": "1000"})
+	f.seed("canvases", map[string]interface{}{"id": "c2", "name": "Two", "folder_id": "proj"})
+	f.seed("canvases/c1/permissions", map[string]interface{}{
+		"editors_can_share": true,
+		"link_permission":   "view",
+		"users": []interface{}{
+			map[string]interface{}{"id": 1000, "permission": "owner", "inherited": false},
+			map[string]interface{}{"id": 7, "permission": "view", "inherited": true},
+		},
+		"groups": []interface{}{map[string]interface{}{"id": 3, "permission": "edit", "inherited": false}},
+	})
+	f.seed("canvases/c2/permissions", map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"id": 1000, "permission": "owner", "inherited": true},
+			map[string]interface{}{"id": 7, "permission": "edit", "inherited": false},
+		},
+	})
+	f.seed("canvas-folders/proj/permissions", map[string]interface{}{"users": []interface{}{}})
+}
+
+func TestBulkUpdatePermissionsGrantDryRunAndRollback(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedBulkPermissions(fake)
+	s := fake.session()
+	ctx := context.Background()
+
+	tree, err := s.LoadFolderTree(ctx)
+	if err != nil {
+		t.Fatalf("LoadFolderTree: %v", err)
+	}
+	targets, err := SelectTargetsByOwner(tree, 1000)
+	if err != nil || len(targets) != 2 {
+		t.Fatalf("SelectTargetsByOwner = %+v, %v", targets, err)
+	}
+
+	change := BulkPermissionChange{Op: BulkPermissionGrant, Principal: UserPrincipal(7), Level: PermissionEdit, DryRun: true}
+	res, err := s.BulkUpdatePermissions(ctx, targets, change)
+	if err != nil {
+		t.Fatalf("BulkUpdatePermissions: %v", err)
+	}
+	want := "~ canvas /alice/One: - -> edit\n= canvas /alice/Project/Two: unchanged\n1 of 2 targets would change\n"
+	if res.Diff() != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", res.Diff(), want)
+	}
+	if fake.callCount("POST canvases") != 0 {
+		t.Fatal("dry run must not write permissions")
+	}
+
+	change.DryRun = false
+	res, err = s.BulkUpdatePermissions(ctx, targets, change)
+	if err != nil {
+		t.Fatalf("BulkUpdatePermissions: %v", err)
+	}
+	perms, _ := s.GetCanvasPermissions(ctx, "c1")
+	// Unrelated direct entries and settings are kept; inherited entries are not turned into overrides.
+	if !perms.EditorsCanShare || perms.LinkPermission != "view" || len(perms.Groups) != 1 || len(perms.Users) != 2 {
+		t.Fatalf("overrides clobbered: %+v", perms)
+	}
+	if perms.Users[1].ID != 7 || perms.Users[1].Permission != "edit" || perms.Users[1].Inherited {
+		t.Errorf("grant not applied: %+v", perms.Users)
+	}
+
+	path := filepath.Join(t.TempDir(), "rollback.json")
+	if err := res.Rollback.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	rb, err := LoadPermissionRollback(path)
+	if err != nil || len(rb.Entries) != 1 {
+		t.Fatalf("LoadPermissionRollback = %+v, %v", rb, err)
+	}
+	// A change to another user after the bulk update survives the rollback.
+	perms.Users = append(perms.Users[:2:2], CanvasUserPermission{ID: 9, Permission: "view"})
+	if _, err := s.SetCanvasPermissions(ctx, "c1", *perms); err != nil {
+		t.Fatalf("SetCanvasPermissions: %v", err)
+	}
+	if err := s.ApplyPermissionRollback(ctx, rb); err != nil {
+		t.Fatalf("ApplyPermissionRollback: %v", err)
+	}
+	perms, _ = s.GetCanvasPermissions(ctx, "c1")
+	if len(perms.Users) != 2 || perms.Users[0].ID != 1000 || perms.Users[1].ID != 9 || perms.LinkPermission != "view" {
+		t.Errorf("rollback did not restore only the principal's override: %+v", perms)
+	}
+}
+
+func TestBulkUpdatePermissionsRevoke(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedBulkPermissions(fake)
+	s := fake.session()
+	ctx := context.Background()
+
+	targets := []PermissionTarget{{Kind: "canvas", ID: "c1"}, {Kind: "canvas", ID: "c2"}, {Kind: "folder", ID: "proj"}}
+	res, err := s.BulkUpdatePermissions(ctx, targets, BulkPermissionChange{Op: BulkPermissionRevoke, Principal: UserPrincipal(7)})
+	if err != nil {
+		t.Fatalf("BulkUpdatePermissions: %v", err)
+	}
+	if !strings.Contains(res.Diff(), "= canvas c1: unchanged (still inherits view from a parent folder)") ||
+		!strings.Contains(res.Diff(), "~ canvas c2: edit -> -") {
+		t.Errorf("unexpected diff:\n%s", res.Diff())
+	}
+	perms, _ := s.GetCanvasPermissions(ctx, "c2")
+	if len(perms.Users) != 0 {
+		t.Errorf("revoke left entries (inherited entries must not be written back): %+v", perms.Users)
+	}
+
+	// A target whose write fails is left out of the rollback.
+	fake.handle(http.MethodPost, "canvases/c1/permissions", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	res, err = s.BulkUpdatePermissions(ctx, targets[:2], BulkPermissionChange{Op: BulkPermissionGrant, Principal: UserPrincipal(7), Level: PermissionEdit, ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("BulkUpdatePermissions: %v", err)
+	}
+	if res.Changes[0].Err == nil || len(res.Rollback.Entries) != 1 || res.Rollback.Entries[0].Target.ID != "c2" {
+		t.Errorf("rollback entries = %+v, changes = %+v", res.Rollback.Entries, res.Changes)
+	}
+
+	if _, err := s.BulkUpdatePermissions(ctx, targets, BulkPermissionChange{Op: "toggle", Principal: UserPrincipal(7)}); err == nil {
+		t.Error("expected error for unknown op")
+	}
+}
+
+func TestSelectTargetsByOwnerSharedName(t *testing.T) {
+	tree := BuildFolderTree(
+		[]Folder{{ID: "root"}, {ID: "1000", Name: "alex", ParentID: "root"}, {ID: "1001", Name: "alex", ParentID: "root"}},
+		[]Canvas{{ID: "c1", Name: "First", FolderID: "1000"}, {ID: "c2", Name: "Second", FolderID: "1001"}},
+	)
+	targets, err := SelectTargetsByOwner(tree, 1001)
+	if err != nil || len(targets) != 1 || targets[0].ID != "c2" {
+		t.Fatalf("SelectTargetsByOwner = %+v, %v", targets, err)
+	}
+}
@@ -0,0 +1,516 @@
+package canvus
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PermissionLevel is a Canvus access level: none < view < edit < owner.
+type PermissionLevel string
+
+const (
+	PermissionNone  PermissionLevel = "none"
+	PermissionView  PermissionLevel = "view"
+	PermissionEdit  PermissionLevel = "edit"
+	PermissionOwner PermissionLevel = "owner"
+)
+
+// Rank orders permission levels; unknown values rank as none.
+func (p PermissionLevel) Rank() int {
+	switch p {
+	case PermissionView:
+		return 1
+	case PermissionEdit:
+		return 2
+	case PermissionOwner:
+		return 3
+	}
+	return 0
+}
+
+// AtLeast reports whether p grants at least the access of other.
+func (p PermissionLevel) AtLeast(other PermissionLevel) bool {
+	return p.Rank() >= other.Rank()
+}
+
+// PermissionSource identifies the rule that contributed a step of an explanation chain.
+type PermissionSource string
+
+const (
+	PermissionSourceAdmin   PermissionSource = "admin"   // server administrators have full access
+	PermissionSourceBlocked PermissionSource = "blocked" // blocked users have no access
+	PermissionSourceUser    PermissionSource = "user"    // a user override on the resource or an ancestor folder
+	PermissionSourceGroup   PermissionSource = "group"   // a group override for a group the user belongs to
+	PermissionSourceLink    PermissionSource = "link"    // the canvas link_permission
+	PermissionSourceDefault PermissionSource = "default" // nothing applies
+)
+
+// PermissionStep is one rule considered while computing an effective permission.
+type PermissionStep struct {
+	Source        PermissionSource
+	Level         PermissionLevel
+	GroupID       int    // for group steps
+	GroupName     string // for group steps
+	InheritedFrom string // folder ID that defines an inherited override; empty if set on the resource itself
+}
+
+// String describes the step in one line, e.g. "edit via group Designers (inherited from folder f1)".
+func (s PermissionStep) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s via ", s.Level)
+	switch s.Source {
+	case PermissionSourceGroup:
+		fmt.Fprintf(&b, "group %s", s.GroupName)
+	case PermissionSourceUser:
+		b.WriteString("user override")
+	case PermissionSourceLink:
+		b.WriteString("shared link")
+	case PermissionSourceAdmin:
+		b.WriteString("server admin")
+	case PermissionSourceBlocked:
+		b.WriteString("blocked user")
+	default:
+		b.WriteString("no matching rule")
+	}
+	if s.InheritedFrom != "" {
+		fmt.Fprintf(&b, " (inherited from folder %s)", s.InheritedFrom)
+	}
+	return b.String()
+}
+
+// EffectivePermission is the access a user has on a canvas or folder, with the rules that led to it.
+// The most permissive applicable rule wins; admin and blocked status short-circuit all overrides.
+type EffectivePermission struct {
+	UserID       int64
+	ResourceKind string // "canvas" or "folder"
+	ResourceID   string
+	Level        PermissionLevel
+	Chain        []PermissionStep // every rule that applied, in evaluation order
+	Decisive     int              // index into Chain of the rule that determined Level
+}
+
+// Explain renders the explanation chain, marking the decisive step.
+func (e *EffectivePermission) Explain() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "user %d has %s on %s %s\n", e.UserID, e.Level, e.ResourceKind, e.ResourceID)
+	for i, step := range e.Chain {
+		marker := " "
+		if i == e.Decisive {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "  %s %s\n", marker, step)
+	}
+	return b.String()
+}
+
+// PermissionEngine computes effective permissions. It caches users, group memberships,
+// folders and permission overrides, so create one per review run and discard it afterwards.
+type PermissionEngine struct {
+	session *Session
+
+	mu          sync.Mutex
+	users       map[int64]*User
+	userGroups  map[int64][]Group
+	folders     map[string]Folder
+	canvasPerms map[string]*CanvasPermissions
+	folderPerms map[string]*FolderPermissions
+}
+
+// NewPermissionEngine creates a PermissionEngine for the given session.
+func NewPermissionEngine(session *Session) *PermissionEngine {
+	return &PermissionEngine{
+		session:     session,
+		users:       make(map[int64]*User),
+		canvasPerms: make(map[string]*CanvasPermissions),
+		folderPerms: make(map[string]*FolderPermissions),
+	}
+}
+
+// PermissionOverride is the common shape of canvas and folder user/group override entries.
+type PermissionOverride struct {
+	ID         int64  `json:"id"`
+	Permission string `json:"permission"`
+	Inherited  bool   `json:"inherited,omitempty"`
+}
+
+func (pe *PermissionEngine) user(ctx context.Context, id int64) (*User, error) {
+	pe.mu.Lock()
+	u, ok := pe.users[id]
+	pe.mu.Unlock()
+	if ok {
+		return u, nil
+	}
+	u, err := pe.session.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pe.mu.Lock()
+	pe.users[id] = u
+	pe.mu.Unlock()
+	return u, nil
+}
+
+// groupsOf returns the groups a user belongs to, loading all memberships on first use.
+func (pe *PermissionEngine) groupsOf(ctx context.Context, userID int64) ([]Group, error) {
+	pe.mu.Lock()
+	loaded := pe.userGroups != nil
+	pe.mu.Unlock()
+	if !loaded {
+		groups, err := pe.session.ListGroups(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		memberships := make(map[int64][]Group)
+		for _, g := range groups {
+			members, err := pe.session.ListGroupMembers(ctx, g.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				memberships[int64(m.ID)] = append(memberships[int64(m.ID)], g)
+			}
+		}
+		pe.mu.Lock()
+		pe.userGroups = memberships
+		pe.mu.Unlock()
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.userGroups[userID], nil
+}
+
+func (pe *PermissionEngine) folder(ctx context.Context, id string) (Folder, bool, error) {
+	pe.mu.Lock()
+	loaded := pe.folders != nil
+	pe.mu.Unlock()
+	if !loaded {
+		list, err := pe.session.ListFolders(ctx, nil)
+		if err != nil {
+			return Folder{}, false, err
+		}
+		idx := make(map[string]Folder, len(list))
+		for _, f := range list {
+			idx[f.ID] = f
+		}
+		pe.mu.Lock()
+		pe.folders = idx
+		pe.mu.Unlock()
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	f, ok := pe.folders[id]
+	return f, ok, nil
+}
+
+func (pe *PermissionEngine) canvasPermissions(ctx context.Context, id string) (*CanvasPermissions, error) {
+	pe.mu.Lock()
+	p, ok := pe.canvasPerms[id]
+	pe.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	p, err := pe.session.GetCanvasPermissions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pe.mu.Lock()
+	pe.canvasPerms[id] = p
+	pe.mu.Unlock()
+	return p, nil
+}
+
+func (pe *PermissionEngine) folderPermissions(ctx context.Context, id string) (*FolderPermissions, error) {
+	pe.mu.Lock()
+	p, ok := pe.folderPerms[id]
+	pe.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	p, err := pe.session.GetFolderPermissions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pe.mu.Lock()
+	pe.folderPerms[id] = p
+	pe.mu.Unlock()
+	return p, nil
+}
+
+// EffectiveCanvasPermission computes what a user can do on a canvas and why.
+//
+// Usage Example:
+//
+//	engine := canvus.NewPermissionEngine(session)
+//	eff, err := engine.EffectiveCanvasPermission(ctx, 1034, canvasID)
+//	fmt.Print(eff.Explain())
+func (pe *PermissionEngine) EffectiveCanvasPermission(ctx context.Context, userID int64, canvasID string) (*EffectivePermission, error) {
+	canvas, err := pe.session.GetCanvas(ctx, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("EffectiveCanvasPermission: %w", err)
+	}
+	eff, err := pe.effectiveCanvas(ctx, userID, canvas)
+	if err != nil {
+		return nil, fmt.Errorf("EffectiveCanvasPermission: %w", err)
+	}
+	return eff, nil
+}
+
+func (pe *PermissionEngine) effectiveCanvas(ctx context.Context, userID int64, canvas *Canvas) (*EffectivePermission, error) {
+	perms, err := pe.canvasPermissions(ctx, canvas.ID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]PermissionOverride, len(perms.Users))
+	for i, u := range perms.Users {
+		users[i] = PermissionOverride{ID: u.ID, Permission: u.Permission, Inherited: u.Inherited}
+	}
+	groups := make([]PermissionOverride, len(perms.Groups))
+	for i, g := range perms.Groups {
+		groups[i] = PermissionOverride{ID: g.ID, Permission: g.Permission, Inherited: g.Inherited}
+	}
+	eff := &EffectivePermission{UserID: userID, ResourceKind: "canvas", ResourceID: canvas.ID}
+	if err := pe.evaluate(ctx, eff, canvas.FolderID, users, groups, PermissionLevel(perms.LinkPermission)); err != nil {
+		return nil, err
+	}
+	return eff, nil
+}
+
+// EffectiveFolderPermission computes what a user can do on a folder and why.
+func (pe *PermissionEngine) EffectiveFolderPermission(ctx context.Context, userID int64, folderID string) (*EffectivePermission, error) {
+	perms, err := pe.folderPermissions(ctx, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("EffectiveFolderPermission: %w", err)
+	}
+	f, _, err := pe.folder(ctx, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("EffectiveFolderPermission: %w", err)
+	}
+	users := make([]PermissionOverride, len(perms.Users))
+	for i, u := range perms.Users {
+		users[i] = PermissionOverride{ID: u.ID, Permission: u.Permission, Inherited: u.Inherited}
+	}
+	groups := make([]PermissionOverride, len(perms.Groups))
+	for i, g := range perms.Groups {
+		groups[i] = PermissionOverride{ID: g.ID, Permission: g.Permission, Inherited: g.Inherited}
+	}
+	eff := &EffectivePermission{UserID: userID, ResourceKind: "folder", ResourceID: folderID}
+	if err := pe.evaluate(ctx, eff, f.ParentID, users, groups, ""); err != nil {
+		return nil, fmt.Errorf("EffectiveFolderPermission: %w", err)
+	}
+	return eff, nil
+}
+
+// evaluate fills eff from the override entries of a resource whose parent folder is parentID.
+func (pe *PermissionEngine) evaluate(ctx context.Context, eff *EffectivePermission, parentID string, users, groups []PermissionOverride, link PermissionLevel) error {
+	u, err := pe.user(ctx, eff.UserID)
+	if err != nil {
+		return err
+	}
+	if u.Blocked {
+		eff.Chain = []PermissionStep{{Source: PermissionSourceBlocked, Level: PermissionNone}}
+		eff.Level = PermissionNone
+		return nil
+	}
+	if u.Admin {
+		eff.Chain = []PermissionStep{{Source: PermissionSourceAdmin, Level: PermissionOwner}}
+		eff.Level = PermissionOwner
+		return nil
+	}
+
+	for _, e := range users {
+		if e.ID != eff.UserID {
+			continue
+		}
+		step := PermissionStep{Source: PermissionSourceUser, Level: PermissionLevel(e.Permission)}
+		if e.Inherited {
+			if step.InheritedFrom, err = pe.inheritedFrom(ctx, parentID, false, e.ID); err != nil {
+				return err
+			}
+		}
+		eff.Chain = append(eff.Chain, step)
+	}
+	memberOf, err := pe.groupsOf(ctx, eff.UserID)
+	if err != nil {
+		return err
+	}
+	for _, g := range memberOf {
+		for _, e := range groups {
+			if e.ID != int64(g.ID) {
+				continue
+			}
+			step := PermissionStep{Source: PermissionSourceGroup, Level: PermissionLevel(e.Permission), GroupID: g.ID, GroupName: g.Name}
+			if e.Inherited {
+				if step.InheritedFrom, err = pe.inheritedFrom(ctx, parentID, true, e.ID); err != nil {
+					return err
+				}
+			}
+			eff.Chain = append(eff.Chain, step)
+		}
+	}
+	if link.Rank() > 0 {
+		eff.Chain = append(eff.Chain, PermissionStep{Source: PermissionSourceLink, Level: link})
+	}
+	if len(eff.Chain) == 0 {
+		eff.Chain = []PermissionStep{{Source: PermissionSourceDefault, Level: PermissionNone}}
+	}
+	eff.Level = PermissionNone
+	for i, step := range eff.Chain {
+		if step.Level.Rank() > eff.Level.Rank() || i == 0 {
+			eff.Level = step.Level
+			eff.Decisive = i
+		}
+	}
+	return nil
+}
+
+// inheritedFrom walks up from folderID to the nearest folder that sets the override directly.
+// It returns "" if no such folder is visible to the session.
+func (pe *PermissionEngine) inheritedFrom(ctx context.Context, folderID string, group bool, principal int64) (string, error) {
+	for id := folderID; id != ""; {
+		perms, err := pe.folderPermissions(ctx, id)
+		if err != nil {
+			if isNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		entries := perms.Users
+		if group {
+			entries = nil
+			for _, g := range perms.Groups {
+				entries = append(entries, FolderUserPermission(g))
+			}
+		}
+		for _, e := range entries {
+			if e.ID == principal && !e.Inherited {
+				return id, nil
+			}
+		}
+		f, ok, err := pe.folder(ctx, id)
+		if err != nil || !ok {
+			return "", err
+		}
+		id = f.ParentID
+	}
+	return "", nil
+}
+
+// AccessReportRow is one user/resource pair in an AccessReport.
+type AccessReportRow struct {
+	UserID       int64
+	UserName     string
+	UserEmail    string
+	ResourceKind string
+	ResourceID   string
+	ResourceName string
+	Level        PermissionLevel
+	Reason       string // the decisive step of the explanation chain
+}
+
+// AccessReport lists who can access what, for periodic access reviews.
+type AccessReport struct {
+	GeneratedAt time.Time
+	Subject     string // e.g. "user 1034" or "canvas 9c21..."
+	Rows        []AccessReportRow
+}
+
+// UserAccessReport lists every canvas a user can at least view.
+func (pe *PermissionEngine) UserAccessReport(ctx context.Context, userID int64) (*AccessReport, error) {
+	u, err := pe.user(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UserAccessReport: %w", err)
+	}
+	canvases, err := pe.session.ListCanvases(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("UserAccessReport: %w", err)
+	}
+	report := &AccessReport{GeneratedAt: time.Now(), Subject: fmt.Sprintf("user %d", userID)}
+	for i := range canvases {
+		c := &canvases[i]
+		if c.InTrash {
+			continue
+		}
+		eff, err := pe.effectiveCanvas(ctx, userID, c)
+		if err != nil {
+			return nil, fmt.Errorf("UserAccessReport: canvas %s: %w", c.ID, err)
+		}
+		if eff.Level.Rank() == 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, accessRow(u, "canvas", c.ID, c.Name, eff))
+	}
+	report.sort()
+	return report, nil
+}
+
+// CanvasAccessReport lists every user who can at least view a canvas.
+func (pe *PermissionEngine) CanvasAccessReport(ctx context.Context, canvasID string) (*AccessReport, error) {
+	canvas, err := pe.session.GetCanvas(ctx, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("CanvasAccessReport: %w", err)
+	}
+	users, err := pe.session.ListUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("CanvasAccessReport: %w", err)
+	}
+	pe.mu.Lock()
+	for i := range users {
+		pe.users[users[i].ID] = &users[i]
+	}
+	pe.mu.Unlock()
+	report := &AccessReport{GeneratedAt: time.Now(), Subject: "canvas " + canvasID}
+	for i := range users {
+		eff, err := pe.effectiveCanvas(ctx, users[i].ID, canvas)
+		if err != nil {
+			return nil, fmt.Errorf("CanvasAccessReport: user %d: %w", users[i].ID, err)
+		}
+		if eff.Level.Rank() == 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, accessRow(&users[i], "canvas", canvas.ID, canvas.Name, eff))
+	}
+	report.sort()
+	return report, nil
+}
+
+func accessRow(u *User, kind, id, name string, eff *EffectivePermission) AccessReportRow {
+	return AccessReportRow{
+		UserID: u.ID, UserName: u.Name, UserEmail: u.Email,
+		ResourceKind: kind, ResourceID: id, ResourceName: name,
+		Level: eff.Level, Reason: eff.Chain[eff.Decisive].String(),
+	}
+}
+
+func (r *AccessReport) sort() {
+	sort.SliceStable(r.Rows, func(i, j int) bool {
+		a, b := r.Rows[i], r.Rows[j]
+		if a.Level.Rank() != b.Level.Rank() {
+			return a.Level.Rank() > b.Level.Rank()
+		}
+		if a.ResourceName != b.ResourceName {
+			return a.ResourceName < b.ResourceName
+		}
+		return a.UserID < b.UserID
+	})
+}
+
+// WriteCSV writes the report as CSV with a header row.
+func (r *AccessReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"user_id", "user_name", "user_email", "resource_kind", "resource_id", "resource_name", "permission", "reason"})
+	for _, row := range r.Rows {
+		_ = cw.Write([]string{
+			strconv.FormatInt(row.UserID, 10), row.UserName, row.UserEmail,
+			row.ResourceKind, row.ResourceID, row.ResourceName, string(row.Level), row.Reason,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
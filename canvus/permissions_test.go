@@ -0,0 +1,111 @@
+package canvus
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func seedPermissions(f *fakeCanvus) {
+	f.seed("users", map[string]interface{}{"id": float64(1), "name": "Ann", "email": "ann@example.com"})
+	f.seed("users", map[string]interface{}{"id": float64(2), "name": "Ben", "email": "ben@example.com"})
+	f.seed("users", map[string]interface{}{"id": float64(3), "name": "Root", "email": "root@example.com", "admin": true})
+	f.seed("users", map[string]interface{}{"id": float64(4), "name": "Eve", "email": "eve@example.com", "blocked": true})
+	f.seed("users", map[string]interface{}{"id": float64(5), "name": "Dan", "email": "dan@example.com"})
+	f.seed("groups", map[string]interface{}{"id": float64(10), "name": "Designers"})
+	f.seed("groups/10/members", map[string]interface{}{"id": float64(2), "name": "Ben"})
+	f.seed("groups/10/members", map[string]interface{}{"id": float64(4), "name": "Eve"})
+
+	f.seed("canvas-folders", map[string]interface{}{"id": "team", "name": "Team"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "proj", "name": "Project", "folder_id": "team"})
+	f.seed("canvas-folders/team/permissions", map[string]interface{}{
+		"groups": []interface{}{map[string]interface{}{"id": 10, "permission": "edit", "inherited": false}},
+	})
+	f.seed("canvas-folders/proj/permissions", map[string]interface{}{
+		"groups": []interface{}{map[string]interface{}{"id": 10, "permission": "edit", "inherited": true}},
+	})
+	f.seed("canvases", map[string]interface{}{"id": "c1", "name": "Board", "folder_id": "proj"})
+	f.seed("canvases/c1/permissions", map[string]interface{}{
+		"link_permission": "view",
+		"users": []interface{}{
+			map[string]interface{}{"id": 1, "permission": "owner", "inherited": false},
+			map[string]interface{}{"id": 2, "permission": "view", "inherited": false},
+		},
+		"groups": []interface{}{map[string]interface{}{"id": 10, "permission": "edit", "inherited": true}},
+	})
+}
+
+func TestEffectiveCanvasPermission(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedPermissions(fake)
+	engine := NewPermissionEngine(fake.session())
+	ctx := context.Background()
+
+	tests := []struct {
+		userID   int64
+		want     PermissionLevel
+		decisive string
+	}{
+		{1, PermissionOwner, "owner via user override"},
+		{2, PermissionEdit, "edit via group Designers (inherited from folder team)"},
+		{3, PermissionOwner, "owner via server admin"},
+		{4, PermissionNone, "none via blocked user"},
+		{5, PermissionView, "view via shared link"},
+	}
+	for _, tt := range tests {
+		eff, err := engine.EffectiveCanvasPermission(ctx, tt.userID, "c1")
+		if err != nil {
+			t.Fatalf("user %d: %v", tt.userID, err)
+		}
+		if eff.Level != tt.want {
+			t.Errorf("user %d: level = %s, want %s\n%s", tt.userID, eff.Level, tt.want, eff.Explain())
+		}
+		if got := eff.Chain[eff.Decisive].String(); got != tt.decisive {
+			t.Errorf("user %d: decisive = %q, want %q", tt.userID, got, tt.decisive)
+		}
+	}
+
+	eff, _ := engine.EffectiveCanvasPermission(ctx, 2, "c1")
+	if len(eff.Chain) != 3 || !strings.Contains(eff.Explain(), "* edit via group Designers") {
+		t.Errorf("unexpected chain:\n%s", eff.Explain())
+	}
+	// Group memberships are loaded once per engine.
+	if n := fake.callCount("GET groups/10/members"); n != 1 {
+		t.Errorf("members fetched %d times", n)
+	}
+}
+
+func TestAccessReports(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedPermissions(fake)
+	engine := NewPermissionEngine(fake.session())
+	ctx := context.Background()
+
+	report, err := engine.CanvasAccessReport(ctx, "c1")
+	if err != nil {
+		t.Fatalf("CanvasAccessReport: %v", err)
+	}
+	var names []string
+	for _, r := range report.Rows {
+		names = append(names, r.UserName+":"+string(r.Level))
+	}
+	if got := strings.Join(names, ","); got != "Ann:owner,Root:owner,Ben:edit,Dan:view" {
+		t.Errorf("rows = %s", got)
+	}
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "user_id,user_name,user_email,resource_kind") || strings.Count(buf.String(), "\n") != 5 {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+
+	userReport, err := engine.UserAccessReport(ctx, 2)
+	if err != nil {
+		t.Fatalf("UserAccessReport: %v", err)
+	}
+	if len(userReport.Rows) != 1 || userReport.Rows[0].ResourceID != "c1" || userReport.Rows[0].Level != PermissionEdit {
+		t.Errorf("unexpected user report: %+v", userReport.Rows)
+	}
+}
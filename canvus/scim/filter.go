@@ -0,0 +1,360 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2).
+type Filter interface {
+	// Match reports whether a resource, in its JSON object form, satisfies the filter.
+	Match(resource map[string]interface{}) bool
+}
+
+// ParseFilter parses a SCIM filter such as `userName eq "bjensen@example.com"` or
+// `emails[type eq "work" and value co "@example.com"] or not (active eq false)`.
+// Supported operators are eq, ne, co, sw, ew, gt, ge, lt, le and pr, combined with and, or, not,
+// parentheses and value-path brackets. Attribute names and string comparisons are case-insensitive.
+func ParseFilter(s string) (Filter, error) {
+	toks, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{toks: toks}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q at end of filter", p.toks[p.pos].text)
+	}
+	return f, nil
+}
+
+type filterToken struct {
+	text   string
+	quoted bool // a JSON string literal; text is the decoded value
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			toks = append(toks, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			var v string
+			if err := json.Unmarshal([]byte(s[i:j+1]), &v); err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter", s[i:j+1])
+			}
+			toks = append(toks, filterToken{text: v, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t()[]\"", s[j]) < 0 {
+				j++
+			}
+			toks = append(toks, filterToken{text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+}
+
+func (p *filterParser) peekWord(w string) bool {
+	return p.pos < len(p.toks) && !p.toks[p.pos].quoted && strings.EqualFold(p.toks[p.pos].text, w)
+}
+
+func (p *filterParser) expect(w string) error {
+	if !p.peekWord(w) {
+		if p.pos >= len(p.toks) {
+			return fmt.Errorf("expected %q at end of filter", w)
+		}
+		return fmt.Errorf("expected %q, got %q", w, p.toks[p.pos].text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.peekWord("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{f}, p.expect(")")
+	}
+	if p.peekWord("(") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	}
+	return p.parseAttr()
+}
+
+func (p *filterParser) parseAttr() (Filter, error) {
+	if p.pos >= len(p.toks) || p.toks[p.pos].quoted {
+		return nil, fmt.Errorf("expected attribute name")
+	}
+	attr := normalizeAttrPath(p.toks[p.pos].text)
+	p.pos++
+	if p.peekWord("[") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{attr: attr, inner: inner}, nil
+	}
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("expected operator after %q", attr)
+	}
+	op := strings.ToLower(p.toks[p.pos].text)
+	p.pos++
+	if op == "pr" {
+		return compareFilter{attr: attr, op: op}, nil
+	}
+	switch op {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("expected value after %q", op)
+	}
+	tok := p.toks[p.pos]
+	p.pos++
+	var value interface{} = tok.text
+	if !tok.quoted {
+		switch strings.ToLower(tok.text) {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			n, err := strconv.ParseFloat(tok.text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", tok.text)
+			}
+			value = n
+		}
+	}
+	return compareFilter{attr: attr, op: op, value: value}, nil
+}
+
+type andFilter struct{ a, b Filter }
+type orFilter struct{ a, b Filter }
+type notFilter struct{ f Filter }
+
+func (f andFilter) Match(r map[string]interface{}) bool { return f.a.Match(r) && f.b.Match(r) }
+func (f orFilter) Match(r map[string]interface{}) bool  { return f.a.Match(r) || f.b.Match(r) }
+func (f notFilter) Match(r map[string]interface{}) bool { return !f.f.Match(r) }
+
+// valuePathFilter matches when any element of a multi-valued attribute matches inner.
+type valuePathFilter struct {
+	attr  string
+	inner Filter
+}
+
+func (f valuePathFilter) Match(r map[string]interface{}) bool {
+	for _, v := range lookupAttr(r, f.attr) {
+		if m, ok := v.(map[string]interface{}); ok && f.inner.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	attr  string
+	op    string
+	value interface{}
+}
+
+func (f compareFilter) Match(r map[string]interface{}) bool {
+	values := lookupAttr(r, f.attr)
+	if f.op == "pr" {
+		for _, v := range values {
+			if v != nil && v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if f.op == "ne" {
+		return !(compareFilter{attr: f.attr, op: "eq", value: f.value}).Match(r)
+	}
+	if f.value == nil && f.op == "eq" {
+		return len(values) == 0
+	}
+	for _, v := range values {
+		if compareValue(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareValue(have interface{}, op string, want interface{}) bool {
+	switch w := want.(type) {
+	case string:
+		h, ok := have.(string)
+		if !ok {
+			return false
+		}
+		h, w = strings.ToLower(h), strings.ToLower(w)
+		switch op {
+		case "eq":
+			return h == w
+		case "co":
+			return strings.Contains(h, w)
+		case "sw":
+			return strings.HasPrefix(h, w)
+		case "ew":
+			return strings.HasSuffix(h, w)
+		case "gt":
+			return h > w
+		case "ge":
+			return h >= w
+		case "lt":
+			return h < w
+		case "le":
+			return h <= w
+		}
+	case float64:
+		h, ok := have.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return h == w
+		case "gt":
+			return h > w
+		case "ge":
+			return h >= w
+		case "lt":
+			return h < w
+		case "le":
+			return h <= w
+		}
+	case bool:
+		h, ok := have.(bool)
+		return ok && op == "eq" && h == w
+	}
+	return false
+}
+
+// normalizeAttrPath strips a core schema URN prefix, e.g.
+// "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName" becomes "name.givenName".
+func normalizeAttrPath(path string) string {
+	for _, urn := range []string{SchemaUser, SchemaGroup} {
+		if len(path) > len(urn) && strings.EqualFold(path[:len(urn)+1], urn+":") {
+			return path[len(urn)+1:]
+		}
+	}
+	return path
+}
+
+// lookupAttr resolves a dotted attribute path against a resource, flattening multi-valued
+// attributes so that "emails.value" yields every email address.
+func lookupAttr(r map[string]interface{}, path string) []interface{} {
+	current := []interface{}{r}
+	for _, part := range strings.Split(path, ".") {
+		var next []interface{}
+		for _, c := range current {
+			m, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			v, ok := getFold(m, part)
+			if !ok {
+				continue
+			}
+			if arr, ok := v.([]interface{}); ok {
+				next = append(next, arr...)
+			} else {
+				next = append(next, v)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// getFold returns m[key], matching the key case-insensitively.
+func getFold(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
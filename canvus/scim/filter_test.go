@@ -0,0 +1,48 @@
+package scim
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	user := map[string]interface{}{
+		"userName": "bjensen@example.com",
+		"name":     map[string]interface{}{"familyName": "Jensen"},
+		"active":   true,
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+			map[string]interface{}{"value": "babs@home.example", "type": "home"},
+		},
+		"meta": map[string]interface{}{"lastModified": "2024-05-01T00:00:00Z"},
+	}
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "BJENSEN@example.com"`, true},
+		{`UserName ne "bjensen@example.com"`, false},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:name.familyName co "ens"`, true},
+		{`userName sw "bj" and userName ew ".com"`, true},
+		{`emails.value eq "babs@home.example"`, true},
+		{`emails[type eq "work" and value co "home"]`, false},
+		{`emails[type eq "home" and value co "home"]`, true},
+		{`active eq false or (title pr)`, false},
+		{`not (active eq false)`, true},
+		{`meta.lastModified gt "2024-01-01T00:00:00Z"`, true},
+		{`title eq null`, true},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.filter, err)
+			continue
+		}
+		if got := f.Match(user); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{`userName`, `userName xx "a"`, `userName eq "a`, `(userName pr`, `userName eq a`} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,631 @@
+package scim
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// Options configures a Handler. A nil *Options uses the zero value.
+type Options struct {
+	// BaseURL is the absolute URL the handler is mounted at, used for meta.location and member $ref.
+	BaseURL string
+	// BearerToken, if set, is required as "Authorization: Bearer <token>" on every request.
+	BearerToken string
+	// ApproveNewUsers marks users created through SCIM as approved.
+	ApproveNewUsers bool
+	// MaxResults caps the page size of list responses (default 200).
+	MaxResults int
+}
+
+// Handler is an http.Handler serving /Users, /Groups and /ServiceProviderConfig.
+// Mount it with http.StripPrefix so that request paths start at the SCIM root.
+type Handler struct {
+	backend Backend
+	opts    Options
+}
+
+// NewHandler creates a SCIM handler backed by a Canvus session (or any Backend).
+func NewHandler(backend Backend, opts *Options) *Handler {
+	h := &Handler{backend: backend}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.MaxResults <= 0 {
+		h.opts.MaxResults = 200
+	}
+	h.opts.BaseURL = strings.TrimSuffix(h.opts.BaseURL, "/")
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.opts.BearerToken != "" {
+		scheme, got, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(got), []byte(h.opts.BearerToken)) != 1 {
+			writeError(w, scimError(http.StatusUnauthorized, "", "invalid or missing bearer token"))
+			return
+		}
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var err error
+	switch {
+	case len(parts) == 1 && parts[0] == "ServiceProviderConfig" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, "", serviceProviderConfig(h.opts.MaxResults))
+	case len(parts) == 1 && parts[0] == "Users":
+		err = h.serveCollection(w, r, h.listUsers, h.createUser)
+	case len(parts) == 2 && parts[0] == "Users":
+		err = h.serveUser(w, r, parts[1])
+	case len(parts) == 1 && parts[0] == "Groups":
+		err = h.serveCollection(w, r, h.listGroups, h.createGroup)
+	case len(parts) == 2 && parts[0] == "Groups":
+		err = h.serveGroup(w, r, parts[1])
+	default:
+		err = scimError(http.StatusNotFound, "", "unknown endpoint "+r.URL.Path)
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (h *Handler) serveCollection(w http.ResponseWriter, r *http.Request,
+	list func(ctx context.Context, q listQuery) ([]map[string]interface{}, error),
+	create func(w http.ResponseWriter, r *http.Request) error) error {
+	switch r.Method {
+	case http.MethodGet:
+		q, err := parseListQuery(r, h.opts.MaxResults)
+		if err != nil {
+			return err
+		}
+		all, err := list(r.Context(), q)
+		if err != nil {
+			return err
+		}
+		var matched []interface{}
+		for _, res := range all {
+			if q.filter == nil || q.filter.Match(res) {
+				matched = append(matched, res)
+			}
+		}
+		resp := ListResponse{Schemas: []string{SchemaListResponse}, TotalResults: len(matched), StartIndex: q.startIndex, Resources: []interface{}{}}
+		if start := q.startIndex - 1; start < len(matched) {
+			end := start + q.count
+			if end > len(matched) {
+				end = len(matched)
+			}
+			resp.Resources = matched[start:end]
+		}
+		resp.ItemsPerPage = len(resp.Resources)
+		writeJSON(w, http.StatusOK, "", resp)
+		return nil
+	case http.MethodPost:
+		return create(w, r)
+	}
+	return scimError(http.StatusMethodNotAllowed, "", r.Method+" not supported")
+}
+
+type listQuery struct {
+	filter          Filter
+	startIndex      int
+	count           int
+	excludedMembers bool
+}
+
+func parseListQuery(r *http.Request, maxResults int) (listQuery, error) {
+	v := r.URL.Query()
+	q := listQuery{startIndex: 1, count: maxResults}
+	if f := v.Get("filter"); f != "" {
+		filter, err := ParseFilter(f)
+		if err != nil {
+			return q, scimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		q.filter = filter
+	}
+	if s := v.Get("startIndex"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, scimError(http.StatusBadRequest, "invalidValue", "invalid startIndex")
+		}
+		if n > 1 {
+			q.startIndex = n
+		}
+	}
+	if s := v.Get("count"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, scimError(http.StatusBadRequest, "invalidValue", "invalid count")
+		}
+		if n < 0 {
+			n = 0
+		}
+		if n < q.count {
+			q.count = n
+		}
+	}
+	for _, a := range strings.Split(v.Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(a), "members") {
+			q.excludedMembers = true
+		}
+	}
+	return q, nil
+}
+
+// Users
+
+func (h *Handler) userResource(u *canvus.User) *User {
+	id := strconv.FormatInt(u.ID, 10)
+	active := !u.Blocked
+	res := &User{
+		Schemas:     []string{SchemaUser},
+		ID:          id,
+		UserName:    u.Email,
+		DisplayName: u.Name,
+		Emails:      []MultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Active:      &active,
+	}
+	if u.Name != "" {
+		res.Name = &Name{Formatted: u.Name}
+	}
+	meta := &Meta{ResourceType: "User", Created: u.CreatedAt, Location: h.location("Users", id)}
+	meta.Version = version(res)
+	res.Meta = meta
+	return res
+}
+
+func (h *Handler) listUsers(ctx context.Context, q listQuery) ([]map[string]interface{}, error) {
+	users, err := h.backend.ListUsers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]interface{}, 0, len(users))
+	for i := range users {
+		out = append(out, toMap(h.userResource(&users[i])))
+	}
+	return out, nil
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) error {
+	var in User
+	if err := decodeBody(r, &in); err != nil {
+		return err
+	}
+	email := in.email()
+	if email == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	ctx := r.Context()
+	users, err := h.backend.ListUsers(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Email, email) {
+			return scimError(http.StatusConflict, "uniqueness", "userName "+email+" already exists")
+		}
+	}
+	req := canvus.CreateUserRequest{Email: email, Name: in.displayName()}
+	if req.Name == "" {
+		req.Name = email
+	}
+	if h.opts.ApproveNewUsers {
+		approved := true
+		req.Approved = &approved
+	}
+	created, err := h.backend.CreateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	if in.Active != nil && !*in.Active {
+		if err := h.backend.BlockUser(ctx, created.ID); err != nil {
+			return err
+		}
+	}
+	u, err := h.backend.GetUser(ctx, created.ID)
+	if err != nil {
+		return err
+	}
+	res := h.userResource(u)
+	w.Header().Set("Location", res.Meta.Location)
+	writeJSON(w, http.StatusCreated, res.Meta.Version, res)
+	return nil
+}
+
+func (h *Handler) serveUser(w http.ResponseWriter, r *http.Request, rawID string) error {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return scimError(http.StatusNotFound, "", "user "+rawID+" not found")
+	}
+	ctx := r.Context()
+	cur, err := h.backend.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	res := h.userResource(cur)
+	if done, err := checkPreconditions(w, r, res.Meta.Version); done || err != nil {
+		return err
+	}
+	var want User
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, res.Meta.Version, res)
+		return nil
+	case http.MethodDelete:
+		if err := h.backend.BlockUser(ctx, id); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case http.MethodPut:
+		if err := decodeBody(r, &want); err != nil {
+			return err
+		}
+	case http.MethodPatch:
+		if err := h.patchResource(r, res, &want); err != nil {
+			return err
+		}
+	default:
+		return scimError(http.StatusMethodNotAllowed, "", r.Method+" not supported")
+	}
+
+	update := canvus.UpdateUserRequest{}
+	changed := false
+	if email := want.email(); email != "" && !strings.EqualFold(email, cur.Email) {
+		update.Email = &email
+		changed = true
+	}
+	name := want.displayName()
+	if r.Method == http.MethodPatch {
+		name = want.renamedFrom(res)
+	}
+	if name != "" && name != cur.Name {
+		update.Name = &name
+		changed = true
+	}
+	if changed {
+		if _, err := h.backend.UpdateUser(ctx, id, update); err != nil {
+			return err
+		}
+	}
+	if want.Active != nil && *want.Active == cur.Blocked {
+		if *want.Active {
+			err = h.backend.UnblockUser(ctx, id)
+		} else {
+			err = h.backend.BlockUser(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	u, err := h.backend.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	res = h.userResource(u)
+	writeJSON(w, http.StatusOK, res.Meta.Version, res)
+	return nil
+}
+
+// Groups
+
+func (h *Handler) groupResource(g *canvus.Group, members []canvus.GroupMember) *Group {
+	id := strconv.Itoa(g.ID)
+	res := &Group{Schemas: []string{SchemaGroup}, ID: id, DisplayName: g.Name}
+	for _, m := range members {
+		mid := strconv.Itoa(m.ID)
+		display := m.Name
+		if display == "" {
+			display = m.Email
+		}
+		res.Members = append(res.Members, Member{Value: mid, Display: display, Ref: h.location("Users", mid)})
+	}
+	meta := &Meta{ResourceType: "Group", Location: h.location("Groups", id)}
+	meta.Version = version(res)
+	res.Meta = meta
+	return res
+}
+
+func (h *Handler) loadGroup(ctx context.Context, id int) (*canvus.Group, []canvus.GroupMember, error) {
+	g, err := h.backend.GetGroup(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := h.backend.ListGroupMembers(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, members, nil
+}
+
+func (h *Handler) listGroups(ctx context.Context, q listQuery) ([]map[string]interface{}, error) {
+	groups, err := h.backend.ListGroups(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]interface{}, 0, len(groups))
+	for i := range groups {
+		var members []canvus.GroupMember
+		// Members are needed for the response unless excluded, and for filters that may test them.
+		if !q.excludedMembers || q.filter != nil {
+			if members, err = h.backend.ListGroupMembers(ctx, groups[i].ID); err != nil {
+				return nil, err
+			}
+		}
+		m := toMap(h.groupResource(&groups[i], members))
+		if q.excludedMembers {
+			delete(m, "members")
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request) error {
+	var in Group
+	if err := decodeBody(r, &in); err != nil {
+		return err
+	}
+	if in.DisplayName == "" {
+		return scimError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	ctx := r.Context()
+	groups, err := h.backend.ListGroups(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if strings.EqualFold(g.Name, in.DisplayName) {
+			return scimError(http.StatusConflict, "uniqueness", "group "+in.DisplayName+" already exists")
+		}
+	}
+	want, err := memberIDs(in.Members)
+	if err != nil {
+		return err
+	}
+	g, err := h.backend.CreateGroup(ctx, canvus.CreateGroupRequest{Name: in.DisplayName})
+	if err != nil {
+		return err
+	}
+	if err := h.syncMembers(ctx, g.ID, nil, want); err != nil {
+		return err
+	}
+	g, members, err := h.loadGroup(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	res := h.groupResource(g, members)
+	w.Header().Set("Location", res.Meta.Location)
+	writeJSON(w, http.StatusCreated, res.Meta.Version, res)
+	return nil
+}
+
+func (h *Handler) serveGroup(w http.ResponseWriter, r *http.Request, rawID string) error {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		return scimError(http.StatusNotFound, "", "group "+rawID+" not found")
+	}
+	ctx := r.Context()
+	g, members, err := h.loadGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	res := h.groupResource(g, members)
+	if done, err := checkPreconditions(w, r, res.Meta.Version); done || err != nil {
+		return err
+	}
+	var want Group
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, res.Meta.Version, res)
+		return nil
+	case http.MethodDelete:
+		if err := h.backend.DeleteGroup(ctx, id); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case http.MethodPut:
+		if err := decodeBody(r, &want); err != nil {
+			return err
+		}
+	case http.MethodPatch:
+		if err := h.patchResource(r, res, &want); err != nil {
+			return err
+		}
+	default:
+		return scimError(http.StatusMethodNotAllowed, "", r.Method+" not supported")
+	}
+
+	wantIDs, err := memberIDs(want.Members)
+	if err != nil {
+		return err
+	}
+	if want.DisplayName != "" && want.DisplayName != g.Name {
+		if _, err := h.backend.UpdateGroup(ctx, id, map[string]interface{}{"name": want.DisplayName}); err != nil {
+			return err
+		}
+	}
+	current := make([]int64, 0, len(members))
+	for _, m := range members {
+		current = append(current, int64(m.ID))
+	}
+	if err := h.syncMembers(ctx, id, current, wantIDs); err != nil {
+		return err
+	}
+	g, members, err = h.loadGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	res = h.groupResource(g, members)
+	writeJSON(w, http.StatusOK, res.Meta.Version, res)
+	return nil
+}
+
+// syncMembers adds and removes users so the group has exactly the wanted members.
+func (h *Handler) syncMembers(ctx context.Context, groupID int, current, want []int64) error {
+	have := map[int64]bool{}
+	for _, id := range current {
+		have[id] = true
+	}
+	keep := map[int64]bool{}
+	for _, id := range want {
+		keep[id] = true
+		if !have[id] {
+			if err := h.backend.AddUserToGroup(ctx, groupID, id); err != nil {
+				return err
+			}
+			have[id] = true
+		}
+	}
+	for _, id := range current {
+		if !keep[id] {
+			if err := h.backend.RemoveUserFromGroup(ctx, groupID, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func memberIDs(members []Member) ([]int64, error) {
+	ids := make([]int64, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil {
+			return nil, scimError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid member %q", m.Value))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Shared helpers
+
+// patchResource applies a PatchOp body to the current resource and decodes the result into want.
+func (h *Handler) patchResource(r *http.Request, current interface{}, want interface{}) error {
+	var req PatchRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	m := toMap(current)
+	if err := applyPatch(m, req.Operations); err != nil {
+		return err
+	}
+	// Some identity providers send booleans as strings ("False").
+	if v, ok := getFold(m, "active"); ok {
+		if s, ok := v.(string); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return scimError(http.StatusBadRequest, "invalidValue", "invalid active value "+s)
+			}
+			setFold(m, "active", b)
+		}
+	}
+	raw, _ := json.Marshal(m)
+	if err := json.Unmarshal(raw, want); err != nil {
+		return scimError(http.StatusBadRequest, "invalidValue", err.Error())
+	}
+	return nil
+}
+
+// checkPreconditions handles If-Match (412 on mismatch) and, for GET, If-None-Match.
+// It reports done after writing a 304 Not Modified response.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, etag string) (done bool, err error) {
+	if r.Method == http.MethodGet {
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			return true, nil
+		}
+		return false, nil
+	}
+	if im := r.Header.Get("If-Match"); im != "" && !etagMatches(im, etag) {
+		return false, scimError(http.StatusPreconditionFailed, "", "resource was modified (ETag mismatch)")
+	}
+	return false, nil
+}
+
+func etagMatches(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// version returns a weak ETag derived from the resource content.
+func version(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	sum := sha256.Sum256(raw)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+func (h *Handler) location(kind, id string) string {
+	return h.opts.BaseURL + "/" + kind + "/" + id
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	raw, _ := json.Marshal(v)
+	var m map[string]interface{}
+	_ = json.Unmarshal(raw, &m)
+	return m
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return scimError(http.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	return nil
+}
+
+func scimError(status int, scimType, detail string) *Error {
+	return &Error{Schemas: []string{SchemaError}, Status: strconv.Itoa(status), ScimType: scimType, Detail: detail, code: status}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var se *Error
+	if !errors.As(err, &se) {
+		status := http.StatusInternalServerError
+		var apiErr *canvus.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 {
+			status = apiErr.StatusCode
+		}
+		se = scimError(status, "", err.Error())
+	}
+	writeJSON(w, se.code, "", se)
+}
+
+func writeJSON(w http.ResponseWriter, status int, etag string, v interface{}) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func serviceProviderConfig(maxResults int) map[string]interface{} {
+	supported := func(ok bool) map[string]interface{} { return map[string]interface{}{"supported": ok} }
+	return map[string]interface{}{
+		"schemas":        []string{SchemaSPConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": maxResults},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(true),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type": "oauthbearertoken", "name": "OAuth Bearer Token", "description": "Authentication with a bearer token",
+		}},
+	}
+}
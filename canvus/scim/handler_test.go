@@ -0,0 +1,331 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// fakeBackend is an in-memory Canvus user/group store.
+type fakeBackend struct {
+	mu      sync.Mutex
+	nextID  int
+	users   map[int64]*canvus.User
+	groups  map[int]*canvus.Group
+	members map[int][]int64
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{users: map[int64]*canvus.User{}, groups: map[int]*canvus.Group{}, members: map[int][]int64{}}
+}
+
+func notFound() error {
+	return &canvus.APIError{StatusCode: http.StatusNotFound, Code: canvus.ErrNotFound}
+}
+
+func (b *fakeBackend) ListUsers(ctx context.Context, filter *canvus.Filter) ([]canvus.User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []canvus.User
+	for _, u := range b.users {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return canvus.FilterSlice(out, filter), nil
+}
+
+func (b *fakeBackend) GetUser(ctx context.Context, id int64) (*canvus.User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.users[id]
+	if !ok {
+		return nil, notFound()
+	}
+	c := *u
+	return &c, nil
+}
+
+func (b *fakeBackend) CreateUser(ctx context.Context, req interface{}) (*canvus.User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := req.(canvus.CreateUserRequest)
+	b.nextID++
+	u := &canvus.User{ID: int64(b.nextID), Email: r.Email, Name: r.Name, Approved: r.Approved != nil && *r.Approved}
+	b.users[u.ID] = u
+	c := *u
+	return &c, nil
+}
+
+func (b *fakeBackend) UpdateUser(ctx context.Context, id int64, req interface{}) (*canvus.User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.users[id]
+	if !ok {
+		return nil, notFound()
+	}
+	r := req.(canvus.UpdateUserRequest)
+	if r.Email != nil {
+		u.Email = *r.Email
+	}
+	if r.Name != nil {
+		u.Name = *r.Name
+	}
+	c := *u
+	return &c, nil
+}
+
+func (b *fakeBackend) setBlocked(id int64, blocked bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.users[id]
+	if !ok {
+		return notFound()
+	}
+	u.Blocked = blocked
+	return nil
+}
+
+func (b *fakeBackend) BlockUser(ctx context.Context, id int64) error {
+	return b.setBlocked(id, true)
+}
+
+func (b *fakeBackend) UnblockUser(ctx context.Context, id int64) error {
+	return b.setBlocked(id, false)
+}
+
+func (b *fakeBackend) ListGroups(ctx context.Context, filter *canvus.Filter) ([]canvus.Group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []canvus.Group
+	for _, g := range b.groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return canvus.FilterSlice(out, filter), nil
+}
+
+func (b *fakeBackend) GetGroup(ctx context.Context, id int) (*canvus.Group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.groups[id]
+	if !ok {
+		return nil, notFound()
+	}
+	c := *g
+	return &c, nil
+}
+
+func (b *fakeBackend) CreateGroup(ctx context.Context, req interface{}) (*canvus.Group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	g := &canvus.Group{ID: b.nextID, Name: req.(canvus.CreateGroupRequest).Name}
+	b.groups[g.ID] = g
+	c := *g
+	return &c, nil
+}
+
+func (b *fakeBackend) UpdateGroup(ctx context.Context, id int, req map[string]interface{}) (*canvus.Group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.groups[id]
+	if !ok {
+		return nil, notFound()
+	}
+	g.Name = req["name"].(string)
+	c := *g
+	return &c, nil
+}
+
+func (b *fakeBackend) DeleteGroup(ctx context.Context, id int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.groups, id)
+	delete(b.members, id)
+	return nil
+}
+
+func (b *fakeBackend) ListGroupMembers(ctx context.Context, id int) ([]canvus.GroupMember, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []canvus.GroupMember
+	for _, uid := range b.members[id] {
+		u := b.users[uid]
+		out = append(out, canvus.GroupMember{ID: int(uid), Name: u.Name, Email: u.Email})
+	}
+	return out, nil
+}
+
+func (b *fakeBackend) AddUserToGroup(ctx context.Context, groupID int, userID int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.users[userID]; !ok {
+		return notFound()
+	}
+	b.members[groupID] = append(b.members[groupID], userID)
+	return nil
+}
+
+func (b *fakeBackend) RemoveUserFromGroup(ctx context.Context, groupID int, userID int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids := b.members[groupID]
+	for i, id := range ids {
+		if id == userID {
+			b.members[groupID] = append(ids[:i], ids[i+1:]...)
+			return nil
+		}
+	}
+	return notFound()
+}
+
+type scimClient struct {
+	t   *testing.T
+	srv *httptest.Server
+}
+
+func (c scimClient) do(method, path, body string, header map[string]string) (*http.Response, map[string]interface{}) {
+	c.t.Helper()
+	req, _ := http.NewRequest(method, c.srv.URL+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/scim+json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var out map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
+
+func newTestServer(t *testing.T, b Backend, opts *Options) scimClient {
+	srv := httptest.NewServer(http.StripPrefix("/scim/v2", NewHandler(b, opts)))
+	t.Cleanup(srv.Close)
+	return scimClient{t: t, srv: srv}
+}
+
+func TestUsersLifecycle(t *testing.T) {
+	b := newFakeBackend()
+	c := newTestServer(t, b, &Options{BaseURL: "https://bridge.example.com/scim/v2", ApproveNewUsers: true})
+
+	resp, u := c.do("POST", "/scim/v2/Users", `{"schemas":["`+SchemaUser+`"],"userName":"bjensen@example.com","name":{"givenName":"Barbara","familyName":"Jensen"},"active":true}`, nil)
+	if resp.StatusCode != http.StatusCreated || u["id"] != "1" || u["displayName"] != "Barbara Jensen" {
+		t.Fatalf("create = %d %v", resp.StatusCode, u)
+	}
+	if resp.Header.Get("Location") != "https://bridge.example.com/scim/v2/Users/1" || resp.Header.Get("ETag") == "" {
+		t.Errorf("missing Location/ETag headers: %v", resp.Header)
+	}
+	if !b.users[1].Approved {
+		t.Error("ApproveNewUsers not honored")
+	}
+	if resp, _ := c.do("POST", "/scim/v2/Users", `{"userName":"BJENSEN@example.com"}`, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate create = %d, want 409", resp.StatusCode)
+	}
+
+	_, list := c.do("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "BJensen@example.com" and active eq true`), "", nil)
+	if list["totalResults"] != float64(1) {
+		t.Errorf("filter list = %v", list)
+	}
+	if resp, _ := c.do("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq`), "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad filter = %d, want 400", resp.StatusCode)
+	}
+
+	resp, _ = c.do("GET", "/scim/v2/Users/1", "", nil)
+	etag := resp.Header.Get("ETag")
+	if resp, _ := c.do("GET", "/scim/v2/Users/1", "", map[string]string{"If-None-Match": etag}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d, want 304", resp.StatusCode)
+	}
+
+	// Azure-style PATCH: capitalised op, string boolean.
+	patch := `{"schemas":["` + SchemaPatchOp + `"],"Operations":[{"op":"Replace","path":"active","value":"False"},{"op":"replace","path":"name.formatted","value":"Babs Jensen"}]}`
+	resp, u = c.do("PATCH", "/scim/v2/Users/1", patch, map[string]string{"If-Match": etag})
+	if resp.StatusCode != http.StatusOK || u["active"] != false || !b.users[1].Blocked || b.users[1].Name != "Babs Jensen" {
+		t.Fatalf("patch = %d %v (user %+v)", resp.StatusCode, u, b.users[1])
+	}
+	if resp, _ := c.do("PATCH", "/scim/v2/Users/1", patch, map[string]string{"If-Match": etag}); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match = %d, want 412", resp.StatusCode)
+	}
+
+	resp, u = c.do("PUT", "/scim/v2/Users/1", `{"userName":"barbara@example.com","displayName":"Barbara","active":true}`, nil)
+	if resp.StatusCode != http.StatusOK || u["userName"] != "barbara@example.com" || b.users[1].Blocked {
+		t.Errorf("put = %d %v", resp.StatusCode, u)
+	}
+
+	if resp, _ := c.do("DELETE", "/scim/v2/Users/1", "", nil); resp.StatusCode != http.StatusNoContent || !b.users[1].Blocked {
+		t.Errorf("delete = %d, blocked=%v", resp.StatusCode, b.users[1].Blocked)
+	}
+	if resp, body := c.do("GET", "/scim/v2/Users/99", "", nil); resp.StatusCode != http.StatusNotFound || body["status"] != "404" {
+		t.Errorf("missing user = %d %v", resp.StatusCode, body)
+	}
+}
+
+func TestGroupsMembership(t *testing.T) {
+	b := newFakeBackend()
+	ctx := context.Background()
+	alice, _ := b.CreateUser(ctx, canvus.CreateUserRequest{Email: "alice@example.com", Name: "Alice"})
+	bob, _ := b.CreateUser(ctx, canvus.CreateUserRequest{Email: "bob@example.com", Name: "Bob"})
+	c := newTestServer(t, b, nil)
+
+	resp, g := c.do("POST", "/scim/v2/Groups", `{"displayName":"Design","members":[{"value":"1"}]}`, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create group = %d %v", resp.StatusCode, g)
+	}
+	gid := g["id"].(string)
+
+	patch := `{"Operations":[
+		{"op":"add","path":"members","value":[{"value":"2"}]},
+		{"op":"remove","path":"members[value eq \"1\"]"},
+		{"op":"replace","value":{"displayName":"Design Team"}}]}`
+	resp, g = c.do("PATCH", "/scim/v2/Groups/"+gid, patch, nil)
+	if resp.StatusCode != http.StatusOK || g["displayName"] != "Design Team" {
+		t.Fatalf("patch group = %d %v", resp.StatusCode, g)
+	}
+	if m := b.members[3]; len(m) != 1 || m[0] != bob.ID {
+		t.Errorf("members = %v, want only bob (alice is %d)", m, alice.ID)
+	}
+
+	_, list := c.do("GET", "/scim/v2/Groups?excludedAttributes=members&filter="+url.QueryEscape(`displayName sw "design"`), "", nil)
+	res := list["Resources"].([]interface{})
+	if len(res) != 1 || res[0].(map[string]interface{})["members"] != nil {
+		t.Errorf("list groups = %v", list)
+	}
+	_, list = c.do("GET", "/scim/v2/Groups?filter="+url.QueryEscape(`members[value eq "2"]`), "", nil)
+	if list["totalResults"] != float64(1) {
+		t.Errorf("member filter = %v", list)
+	}
+
+	if resp, _ := c.do("PATCH", "/scim/v2/Groups/"+gid, `{"Operations":[{"op":"add","path":"members","value":[{"value":"x"}]}]}`, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid member = %d, want 400", resp.StatusCode)
+	}
+	if resp, _ := c.do("DELETE", "/scim/v2/Groups/"+gid, "", nil); resp.StatusCode != http.StatusNoContent || len(b.groups) != 0 {
+		t.Errorf("delete group = %d", resp.StatusCode)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	c := newTestServer(t, newFakeBackend(), &Options{BearerToken: "s3cret"})
+	if resp, _ := c.do("GET", "/scim/v2/Users", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("no token = %d, want 401", resp.StatusCode)
+	}
+	for _, header := range []string{"s3cret", "Basic s3cret", "Bearers3cret"} {
+		if resp, _ := c.do("GET", "/scim/v2/Users", "", map[string]string{"Authorization": header}); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q = %d, want 401", header, resp.StatusCode)
+		}
+	}
+	for _, header := range []string{"Bearer s3cret", "bearer s3cret"} {
+		if resp, _ := c.do("GET", "/scim/v2/ServiceProviderConfig", "", map[string]string{"Authorization": header}); resp.StatusCode != http.StatusOK {
+			t.Errorf("Authorization %q = %d, want 200", header, resp.StatusCode)
+		}
+	}
+}
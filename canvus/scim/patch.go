@@ -0,0 +1,207 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// applyPatch applies PatchOp operations (RFC 7644 section 3.5.2) to a resource in its JSON
+// object form. The handler then reconciles the patched resource like a PUT.
+func applyPatch(resource map[string]interface{}, ops []PatchOperation) error {
+	for i, op := range ops {
+		if err := applyPatchOp(resource, op); err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func applyPatchOp(r map[string]interface{}, op PatchOperation) error {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "replace", "remove":
+	default:
+		return scimError(http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("unknown op %q", op.Op))
+	}
+	if op.Path == "" {
+		if kind == "remove" {
+			return scimError(http.StatusBadRequest, "noTarget", "remove requires a path")
+		}
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return scimError(http.StatusBadRequest, "invalidValue", "operation without path needs an object value")
+		}
+		for k, v := range values {
+			if err := applyPatchOp(r, PatchOperation{Op: kind, Path: k, Value: v}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	attr, filter, sub, err := parsePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+	existing, _ := getFold(r, attr)
+
+	if filter != nil {
+		elems, _ := existing.([]interface{})
+		kept := elems[:0:0]
+		matched := false
+		for _, e := range elems {
+			m, ok := e.(map[string]interface{})
+			if !ok || !filter.Match(m) {
+				kept = append(kept, e)
+				continue
+			}
+			matched = true
+			switch {
+			case kind == "remove" && sub == "":
+				continue // drop the element
+			case kind == "remove":
+				deleteFold(m, sub)
+			case sub != "":
+				setFold(m, sub, op.Value)
+			default:
+				if v, ok := op.Value.(map[string]interface{}); ok {
+					for k, x := range v {
+						setFold(m, k, x)
+					}
+				}
+			}
+			kept = append(kept, m)
+		}
+		if !matched && kind != "remove" {
+			return scimError(http.StatusBadRequest, "noTarget", fmt.Sprintf("no values match %q", op.Path))
+		}
+		setFold(r, attr, kept)
+		return nil
+	}
+
+	if sub != "" {
+		m, ok := existing.(map[string]interface{})
+		if !ok {
+			if _, isList := existing.([]interface{}); isList {
+				return scimError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("%q is multi-valued; use a filter", attr))
+			}
+			if kind == "remove" {
+				return nil
+			}
+			m = map[string]interface{}{}
+			setFold(r, attr, m)
+		}
+		if kind == "remove" {
+			deleteFold(m, sub)
+		} else {
+			setFold(m, sub, op.Value)
+		}
+		return nil
+	}
+
+	list, isList := existing.([]interface{})
+	values, valueIsList := op.Value.([]interface{})
+	switch kind {
+	case "remove":
+		if isList && op.Value != nil {
+			// Remove the listed values only, e.g. {"path":"members","value":[{"value":"42"}]}.
+			if !valueIsList {
+				values = []interface{}{op.Value}
+			}
+			var kept []interface{}
+			for _, e := range list {
+				if !containsValue(values, e) {
+					kept = append(kept, e)
+				}
+			}
+			setFold(r, attr, kept)
+			return nil
+		}
+		deleteFold(r, attr)
+	case "add":
+		if isList || valueIsList {
+			if !valueIsList {
+				values = []interface{}{op.Value}
+			}
+			for _, v := range values {
+				if !containsValue(list, v) {
+					list = append(list, v)
+				}
+			}
+			setFold(r, attr, list)
+			return nil
+		}
+		fallthrough
+	case "replace":
+		if m, ok := existing.(map[string]interface{}); ok {
+			if v, ok := op.Value.(map[string]interface{}); ok {
+				for k, x := range v {
+					setFold(m, k, x)
+				}
+				return nil
+			}
+		}
+		setFold(r, attr, op.Value)
+	}
+	return nil
+}
+
+// parsePatchPath splits `attr[filter].sub`, `attr.sub` or `attr` into its parts.
+func parsePatchPath(path string) (attr string, filter Filter, sub string, err error) {
+	path = normalizeAttrPath(path)
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		j := strings.LastIndexByte(path, ']')
+		if j < i {
+			return "", nil, "", scimError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("invalid path %q", path))
+		}
+		filter, err = ParseFilter(path[i+1 : j])
+		if err != nil {
+			return "", nil, "", scimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		return path[:i], filter, strings.TrimPrefix(path[j+1:], "."), nil
+	}
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		return path[:i], nil, path[i+1:], nil
+	}
+	return path, nil, "", nil
+}
+
+// containsValue reports whether list holds v, comparing multi-valued entries by their "value".
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, e := range list {
+		em, ok1 := e.(map[string]interface{})
+		vm, ok2 := v.(map[string]interface{})
+		if ok1 && ok2 {
+			ev, _ := getFold(em, "value")
+			vv, _ := getFold(vm, "value")
+			if ev != nil && fmt.Sprint(ev) == fmt.Sprint(vv) {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setFold(m map[string]interface{}, key string, v interface{}) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			m[k] = v
+			return
+		}
+	}
+	m[key] = v
+}
+
+func deleteFold(m map[string]interface{}, key string) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			delete(m, k)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package scim implements a SCIM 2.0 (RFC 7643/7644) provisioning endpoint on top of the
+// Canvus SDK, so identity providers can manage Canvus users and groups.
+//
+// Users map onto Canvus users: userName is the email address, displayName (or name.formatted)
+// is the name and active is the inverse of blocked. Deleting a user blocks it. Groups map onto
+// Canvus groups and their members. Canvus has no storage for externalId, so it is not retained.
+//
+// Usage Example:
+//
+//	h := scim.NewHandler(session, &scim.Options{BaseURL: "https://bridge.example.com/scim/v2"})
+//	http.Handle("/scim/v2/", http.StripPrefix("/scim/v2", h))
+package scim
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jaypaulb/Canvus-Go-API/canvus"
+)
+
+// Schema URNs used in SCIM messages.
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaSPConfig     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// Backend is the subset of *canvus.Session used by the handler.
+type Backend interface {
+	ListUsers(ctx context.Context, filter *canvus.Filter) ([]canvus.User, error)
+	GetUser(ctx context.Context, id int64) (*canvus.User, error)
+	CreateUser(ctx context.Context, req interface{}) (*canvus.User, error)
+	UpdateUser(ctx context.Context, id int64, req interface{}) (*canvus.User, error)
+	BlockUser(ctx context.Context, userID int64) error
+	UnblockUser(ctx context.Context, userID int64) error
+	ListGroups(ctx context.Context, filter *canvus.Filter) ([]canvus.Group, error)
+	GetGroup(ctx context.Context, id int) (*canvus.Group, error)
+	CreateGroup(ctx context.Context, req interface{}) (*canvus.Group, error)
+	UpdateGroup(ctx context.Context, groupID int, req map[string]interface{}) (*canvus.Group, error)
+	DeleteGroup(ctx context.Context, id int) error
+	ListGroupMembers(ctx context.Context, groupID int) ([]canvus.GroupMember, error)
+	AddUserToGroup(ctx context.Context, groupID int, userID int64) error
+	RemoveUserFromGroup(ctx context.Context, groupID int, userID int64) error
+}
+
+var _ Backend = (*canvus.Session)(nil)
+
+// Meta is the SCIM resource metadata.
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// Name is the SCIM complex name attribute.
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is an entry of a multi-valued attribute such as emails.
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// User is the SCIM User resource.
+type User struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *Name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []MultiValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+// displayName returns the best available full name.
+func (u *User) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		return u.Name.composed()
+	}
+	return ""
+}
+
+// renamedFrom returns the name a PATCH changed relative to before, or "". A PATCH may update only
+// one of displayName, name.formatted or name.givenName/familyName, leaving the others stale.
+func (u *User) renamedFrom(before *User) string {
+	var b Name
+	if before.Name != nil {
+		b = *before.Name
+	}
+	var n Name
+	if u.Name != nil {
+		n = *u.Name
+	}
+	switch {
+	case u.DisplayName != "" && u.DisplayName != before.DisplayName:
+		return u.DisplayName
+	case n.Formatted != "" && n.Formatted != b.Formatted:
+		return n.Formatted
+	case n.composed() != "" && n.composed() != b.composed():
+		return n.composed()
+	}
+	return ""
+}
+
+func (n *Name) composed() string {
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
+
+// email returns userName, falling back to the primary (or first) email.
+func (u *User) email() string {
+	if u.UserName != "" {
+		return u.UserName
+	}
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// Member is an entry of a Group's members attribute.
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// Group is the SCIM Group resource.
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// ListResponse is the envelope for query results.
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// PatchOperation is one operation of a PatchOp request.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// PatchRequest is the body of a PATCH request.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// Error is the SCIM error response body. It also implements error.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+	code     int
+}
+
+func (e *Error) Error() string {
+	return e.Status + " " + e.ScimType + ": " + e.Detail
+}
@@ -0,0 +1,617 @@
+package canvus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// searchFieldWeights weights matches by field; names and titles count double.
+var searchFieldWeights = map[string]float64{
+	"text":     1,
+	"title":    2,
+	"name":     2,
+	"filename": 2,
+	"url":      1,
+}
+
+// BM25 parameters.
+const (
+	searchK1 = 1.2
+	searchB  = 0.75
+)
+
+// SearchDoc is one widget in a SearchIndex. Fields holds its searchable text by field name:
+// "text" (notes), "title" (browsers, assets), "url" (browsers), "filename" (images, PDFs and
+// videos) and "name" (anchors).
+type SearchDoc struct {
+	CanvasID   string
+	CanvasName string
+	WidgetID   string
+	WidgetType string
+	Fields     map[string]string
+}
+
+func (d *SearchDoc) key() string {
+	return d.CanvasID + "/" + d.WidgetID
+}
+
+// SearchHit is a ranked search result. Snippet is an excerpt of Field with the matched words
+// wrapped in the highlight markers.
+type SearchHit struct {
+	Doc     SearchDoc
+	Score   float64
+	Field   string
+	Snippet string
+}
+
+// SearchOptions controls SearchIndex.Search. Zero values select the defaults.
+type SearchOptions struct {
+	Limit         int      // maximum number of hits; 0 returns all
+	CanvasIDs     []string // only search these canvases
+	WidgetTypes   []string // only return these widget types (case-insensitive)
+	SnippetWords  int      // words of context in a snippet; default 12
+	HighlightPre  string   // default "**"
+	HighlightPost string   // default "**"
+}
+
+type searchPosting struct {
+	field string
+	pos   int
+}
+
+// SearchIndex is an in-memory full-text index of note text, browser titles and URLs, asset
+// filenames and anchor names across canvases. Build it with Refresh, which re-indexes only
+// canvases whose modified_at changed, or keep it current from your own change feed with Put
+// and Remove. A SearchIndex is safe for concurrent use.
+//
+// Queries are words (all must match), "quoted phrases" and prefix* terms, ranked with BM25.
+//
+// Usage Example:
+//
+//	ix := canvus.NewSearchIndex()
+//	_, _ = ix.Refresh(ctx, session)
+//	hits, err := ix.Search(`"release plan" budg*`, canvus.SearchOptions{Limit: 10})
+//	for _, h := range hits {
+//		fmt.Println(h.Doc.CanvasName, h.Snippet)
+//	}
+type SearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*SearchDoc
+	postings map[string]map[string][]searchPosting // term -> doc key -> occurrences
+	docLen   map[string]int
+	totalLen int
+	canvases map[string]map[string]bool // canvas ID -> doc keys
+	modified map[string]string          // canvas ID -> modified_at when last indexed
+}
+
+// NewSearchIndex returns an empty SearchIndex.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     map[string]*SearchDoc{},
+		postings: map[string]map[string][]searchPosting{},
+		docLen:   map[string]int{},
+		canvases: map[string]map[string]bool{},
+		modified: map[string]string{},
+	}
+}
+
+// Len returns the number of indexed widgets.
+func (ix *SearchIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Put adds or replaces a document.
+func (ix *SearchIndex) Put(doc SearchDoc) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.put(doc)
+}
+
+// Remove drops a widget from the index. It reports whether the widget was indexed.
+func (ix *SearchIndex) Remove(canvasID, widgetID string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.remove(canvasID + "/" + widgetID)
+}
+
+// RemoveCanvas drops every widget of a canvas.
+func (ix *SearchIndex) RemoveCanvas(canvasID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeCanvas(canvasID)
+}
+
+func (ix *SearchIndex) put(doc SearchDoc) {
+	k := doc.key()
+	ix.remove(k)
+	d := doc
+	d.Fields = make(map[string]string, len(doc.Fields))
+	n := 0
+	for field, text := range doc.Fields {
+		d.Fields[field] = text
+		for i, t := range searchTokens(text) {
+			if ix.postings[t.term] == nil {
+				ix.postings[t.term] = map[string][]searchPosting{}
+			}
+			ix.postings[t.term][k] = append(ix.postings[t.term][k], searchPosting{field: field, pos: i})
+			n++
+		}
+	}
+	ix.docs[k] = &d
+	ix.docLen[k] = n
+	ix.totalLen += n
+	if ix.canvases[d.CanvasID] == nil {
+		ix.canvases[d.CanvasID] = map[string]bool{}
+	}
+	ix.canvases[d.CanvasID][k] = true
+}
+
+func (ix *SearchIndex) remove(k string) bool {
+	d, ok := ix.docs[k]
+	if !ok {
+		return false
+	}
+	for _, text := range d.Fields {
+		for _, t := range searchTokens(text) {
+			if p := ix.postings[t.term]; p != nil {
+				delete(p, k)
+				if len(p) == 0 {
+					delete(ix.postings, t.term)
+				}
+			}
+		}
+	}
+	ix.totalLen -= ix.docLen[k]
+	delete(ix.docLen, k)
+	delete(ix.docs, k)
+	delete(ix.canvases[d.CanvasID], k)
+	return true
+}
+
+func (ix *SearchIndex) removeCanvas(canvasID string) {
+	for k := range ix.canvases[canvasID] {
+		ix.remove(k)
+	}
+	delete(ix.canvases, canvasID)
+	delete(ix.modified, canvasID)
+}
+
+// Refresh lists the canvases and re-indexes those that are new or whose modified_at changed
+// since they were last indexed; canvases that are gone or in the trash are dropped. It returns
+// the number of canvases re-indexed. A canvas that cannot be read keeps its previous documents
+// and is retried on the next Refresh; the others are still indexed and the failures are
+// returned together.
+func (ix *SearchIndex) Refresh(ctx context.Context, s *Session) (int, error) {
+	canvases, err := s.ListCanvases(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("Refresh: %w", err)
+	}
+	live := map[string]bool{}
+	n := 0
+	var errs []error
+	for _, c := range canvases {
+		if c.InTrash {
+			continue
+		}
+		live[c.ID] = true
+		ix.mu.RLock()
+		prev, seen := ix.modified[c.ID]
+		ix.mu.RUnlock()
+		if seen && c.ModifiedAt != "" && prev == c.ModifiedAt {
+			continue
+		}
+		if err := ix.IndexCanvas(ctx, s, c); err != nil {
+			errs = append(errs, fmt.Errorf("canvas %s: %w", c.ID, err))
+			continue
+		}
+		n++
+	}
+	ix.mu.Lock()
+	for id := range ix.canvases {
+		if !live[id] {
+			ix.removeCanvas(id)
+		}
+	}
+	for id := range ix.modified {
+		if !live[id] {
+			delete(ix.modified, id)
+		}
+	}
+	ix.mu.Unlock()
+	if len(errs) > 0 {
+		return n, fmt.Errorf("Refresh: %d canvases could not be indexed: %w", len(errs), errors.Join(errs...))
+	}
+	return n, nil
+}
+
+// IndexCanvas (re-)indexes one canvas from ListNotes, ListBrowsers, ListImages, ListPDFs,
+// ListVideos and ListAnchors, replacing its previous documents.
+func (ix *SearchIndex) IndexCanvas(ctx context.Context, s *Session, canvas Canvas) error {
+	docs, err := canvasSearchDocs(ctx, s, canvas)
+	if err != nil {
+		return fmt.Errorf("IndexCanvas: %w", err)
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeCanvas(canvas.ID)
+	for _, d := range docs {
+		ix.put(d)
+	}
+	ix.modified[canvas.ID] = canvas.ModifiedAt
+	return nil
+}
+
+func canvasSearchDocs(ctx context.Context, s *Session, c Canvas) ([]SearchDoc, error) {
+	var docs []SearchDoc
+	add := func(id, widgetType string, fields ...string) {
+		d := SearchDoc{CanvasID: c.ID, CanvasName: c.Name, WidgetID: id, WidgetType: widgetType, Fields: map[string]string{}}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if fields[i+1] != "" {
+				d.Fields[fields[i]] = fields[i+1]
+			}
+		}
+		if len(d.Fields) > 0 {
+			docs = append(docs, d)
+		}
+	}
+	notes, err := s.ListNotes(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		add(n.ID, "Note", "text", n.Text)
+	}
+	browsers, err := s.ListBrowsers(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range browsers {
+		add(b.ID, "Browser", "title", b.Title, "url", b.URL)
+	}
+	images, err := s.ListImages(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, im := range images {
+		add(im.ID, "Image", "filename", im.OriginalFilename, "title", im.Title)
+	}
+	pdfs, err := s.ListPDFs(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pdfs {
+		add(p.ID, "PDF", "filename", p.OriginalFilename, "title", p.Title)
+	}
+	videos, err := s.ListVideos(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range videos {
+		add(v.ID, "Video", "filename", v.OriginalFilename, "title", v.Title)
+	}
+	anchors, err := s.ListAnchors(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range anchors {
+		add(a.ID, "Anchor", "name", a.AnchorName)
+	}
+	return docs, nil
+}
+
+// searchClause is one query element: a word, a prefix* or a "phrase" (several terms).
+type searchClause struct {
+	terms  []string
+	prefix bool
+}
+
+// clauseMatch is how one clause matched one document.
+type clauseMatch struct {
+	tf   float64         // field-weighted occurrence count
+	hits []searchPosting // matched word positions, for highlighting
+}
+
+// Search runs a query and returns hits ranked by BM25 score, best first. Every word, phrase
+// and prefix in the query must match.
+func (ix *SearchIndex) Search(query string, opts SearchOptions) ([]SearchHit, error) {
+	clauses, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Search: %w", err)
+	}
+	if opts.SnippetWords <= 0 {
+		opts.SnippetWords = 12
+	}
+	if opts.HighlightPre == "" && opts.HighlightPost == "" {
+		opts.HighlightPre, opts.HighlightPost = "**", "**"
+	}
+	canvasOK := func(string) bool { return true }
+	if len(opts.CanvasIDs) > 0 {
+		set := map[string]bool{}
+		for _, id := range opts.CanvasIDs {
+			set[id] = true
+		}
+		canvasOK = func(id string) bool { return set[id] }
+	}
+	typeOK := func(string) bool { return true }
+	if len(opts.WidgetTypes) > 0 {
+		set := map[string]bool{}
+		for _, t := range opts.WidgetTypes {
+			set[strings.ToLower(t)] = true
+		}
+		typeOK = func(t string) bool { return set[strings.ToLower(t)] }
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	n := float64(len(ix.docs))
+	avg := 1.0
+	if len(ix.docs) > 0 && ix.totalLen > 0 {
+		avg = float64(ix.totalLen) / n
+	}
+	scores := map[string]float64{}
+	hits := map[string][]searchPosting{}
+	for ci, c := range clauses {
+		matches := ix.matchClause(c)
+		idf := math.Log(1 + (n-float64(len(matches))+0.5)/(float64(len(matches))+0.5))
+		next := map[string]float64{}
+		for k, m := range matches {
+			if ci > 0 {
+				if _, ok := scores[k]; !ok {
+					continue
+				}
+			}
+			d := ix.docs[k]
+			if !canvasOK(d.CanvasID) || !typeOK(d.WidgetType) {
+				continue
+			}
+			norm := 1 - searchB + searchB*float64(ix.docLen[k])/avg
+			next[k] = scores[k] + idf*m.tf*(searchK1+1)/(m.tf+searchK1*norm)
+			hits[k] = append(hits[k], m.hits...)
+		}
+		scores = next
+	}
+
+	out := make([]SearchHit, 0, len(scores))
+	for k, score := range scores {
+		d := ix.docs[k]
+		field := bestSearchField(hits[k])
+		h := SearchHit{Doc: *d, Score: score, Field: field}
+		h.Doc.Fields = make(map[string]string, len(d.Fields))
+		for f, t := range d.Fields {
+			h.Doc.Fields[f] = t
+		}
+		h.Snippet = searchSnippet(d.Fields[field], field, hits[k], opts)
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if out[i].Doc.CanvasID != out[j].Doc.CanvasID {
+			return out[i].Doc.CanvasID < out[j].Doc.CanvasID
+		}
+		return out[i].Doc.WidgetID < out[j].Doc.WidgetID
+	})
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out, nil
+}
+
+func (ix *SearchIndex) matchClause(c searchClause) map[string]*clauseMatch {
+	out := map[string]*clauseMatch{}
+	addTerm := func(term string) {
+		for k, occ := range ix.postings[term] {
+			m := out[k]
+			if m == nil {
+				m = &clauseMatch{}
+				out[k] = m
+			}
+			for _, p := range occ {
+				m.tf += searchFieldWeights[p.field]
+				m.hits = append(m.hits, p)
+			}
+		}
+	}
+	switch {
+	case c.prefix:
+		for term := range ix.postings {
+			if strings.HasPrefix(term, c.terms[0]) {
+				addTerm(term)
+			}
+		}
+	case len(c.terms) == 1:
+		addTerm(c.terms[0])
+	default:
+		rest := make([]map[string][]searchPosting, len(c.terms)-1)
+		for i, t := range c.terms[1:] {
+			if rest[i] = ix.postings[t]; rest[i] == nil {
+				return out
+			}
+		}
+		for k, occ := range ix.postings[c.terms[0]] {
+			for _, p := range occ {
+				ok := true
+				for i := range rest {
+					if !containsPosting(rest[i][k], searchPosting{field: p.field, pos: p.pos + i + 1}) {
+						ok = false
+						break
+					}
+				}
+				if !ok {
+					continue
+				}
+				m := out[k]
+				if m == nil {
+					m = &clauseMatch{}
+					out[k] = m
+				}
+				m.tf += searchFieldWeights[p.field]
+				for i := range c.terms {
+					m.hits = append(m.hits, searchPosting{field: p.field, pos: p.pos + i})
+				}
+			}
+		}
+	}
+	return out
+}
+
+func containsPosting(occ []searchPosting, p searchPosting) bool {
+	for _, o := range occ {
+		if o == p {
+			return true
+		}
+	}
+	return false
+}
+
+// bestSearchField picks the field with the most weighted hits, preferring names on ties.
+func bestSearchField(hits []searchPosting) string {
+	weight := map[string]float64{}
+	for _, h := range hits {
+		weight[h.field] += searchFieldWeights[h.field]
+	}
+	best := ""
+	for f, w := range weight {
+		if best == "" || w > weight[best] || (w == weight[best] && f < best) {
+			best = f
+		}
+	}
+	return best
+}
+
+// searchSnippet cuts a window of opts.SnippetWords words around the first hit in field and
+// wraps every hit word in the highlight markers.
+func searchSnippet(text, field string, hits []searchPosting, opts SearchOptions) string {
+	toks := searchTokens(text)
+	if len(toks) == 0 {
+		return ""
+	}
+	marked := map[int]bool{}
+	first := len(toks)
+	for _, h := range hits {
+		if h.field == field && h.pos < len(toks) {
+			marked[h.pos] = true
+			if h.pos < first {
+				first = h.pos
+			}
+		}
+	}
+	if first == len(toks) {
+		first = 0
+	}
+	start := first - opts.SnippetWords/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + opts.SnippetWords
+	if end > len(toks) {
+		end = len(toks)
+	}
+	lo, hi := toks[start].start, toks[end-1].end
+	if start == 0 {
+		lo = 0
+	}
+	if end == len(toks) {
+		hi = len(text)
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := lo
+	for i := start; i < end; i++ {
+		if t := toks[i]; marked[i] {
+			b.WriteString(text[pos:t.start] + opts.HighlightPre + text[t.start:t.end] + opts.HighlightPost)
+			pos = t.end
+		}
+	}
+	b.WriteString(text[pos:hi])
+	if end < len(toks) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// searchToken is a lower-cased word and its byte range in the source text.
+type searchToken struct {
+	term       string
+	start, end int
+}
+
+// searchTokens splits text into words of letters and digits.
+func searchTokens(text string) []searchToken {
+	var out []searchToken
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			out = append(out, searchToken{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, searchToken{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return out
+}
+
+// parseSearchQuery splits a query into words, prefix* terms and "quoted phrases".
+func parseSearchQuery(q string) ([]searchClause, error) {
+	var out []searchClause
+	for i := 0; i < len(q); {
+		switch {
+		case q[i] == ' ' || q[i] == '\t' || q[i] == '\n':
+			i++
+		case q[i] == '"':
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated phrase in %q", q)
+			}
+			if c := phraseClause(q[i+1 : i+1+end]); c != nil {
+				out = append(out, *c)
+			}
+			i += end + 2
+		default:
+			end := strings.IndexAny(q[i:], " \t\n\"")
+			if end < 0 {
+				end = len(q) - i
+			}
+			word := q[i : i+end]
+			i += end
+			prefix := strings.HasSuffix(word, "*")
+			c := phraseClause(strings.TrimRight(word, "*"))
+			if c == nil {
+				continue
+			}
+			c.prefix = prefix && len(c.terms) == 1
+			out = append(out, *c)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	return out, nil
+}
+
+func phraseClause(s string) *searchClause {
+	toks := searchTokens(s)
+	if len(toks) == 0 {
+		return nil
+	}
+	c := &searchClause{}
+	for _, t := range toks {
+		c.terms = append(c.terms, t.term)
+	}
+	return c
+}
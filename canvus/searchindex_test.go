@@ -0,0 +1,108 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSearchIndex(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases", map[string]interface{}{"id": "c1", "name": "Planning", "modified_at": "t1"})
+	fake.seed("canvases", map[string]interface{}{"id": "c2", "name": "Retro", "modified_at": "t1"})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "Draft the release plan for Q3.\nBudget review follows the release plan sign-off."})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n2", "text": "Plan the release party"})
+	fake.seed("canvases/c1/browsers", map[string]interface{}{"id": "b1", "title": "Release Plan", "url": "https://wiki.example.com/release"})
+	fake.seed("canvases/c2/images", map[string]interface{}{"id": "i1", "original_filename": "budget-2025.png"})
+	fake.seed("canvases/c2/anchors", map[string]interface{}{"id": "a1", "anchor_name": "Budgeting"})
+	s := fake.session()
+	ctx := context.Background()
+
+	ix := NewSearchIndex()
+	if n, err := ix.Refresh(ctx, s); err != nil || n != 2 || ix.Len() != 5 {
+		t.Fatalf("Refresh = %d, %v; Len %d", n, err, ix.Len())
+	}
+
+	hits, err := ix.Search(`"release plan"`, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Doc.WidgetID != "b1" || hits[1].Doc.WidgetID != "n1" {
+		t.Fatalf("phrase hits = %+v", hits)
+	}
+	if hits[0].Field != "title" || hits[0].Snippet != "**Release** **Plan**" {
+		t.Errorf("title hit = %q %q", hits[0].Field, hits[0].Snippet)
+	}
+	if want := "Draft the **release** **plan** for Q3. Budget review follows the **release** **plan**…"; hits[1].Snippet != want {
+		t.Errorf("snippet = %q", hits[1].Snippet)
+	}
+
+	hits, _ = ix.Search("budg*", SearchOptions{HighlightPre: "<", HighlightPost: ">", SnippetWords: 3})
+	got := map[string]string{}
+	for _, h := range hits {
+		got[h.Doc.WidgetID] = h.Snippet
+	}
+	if len(got) != 3 || got["i1"] != "<budget>-2025.png" || got["n1"] != "…Q3. <Budget> review…" {
+		t.Errorf("prefix hits = %v", got)
+	}
+	if hits, _ := ix.Search("budg* plan", SearchOptions{}); len(hits) != 1 || hits[0].Doc.WidgetID != "n1" {
+		t.Errorf("AND hits = %+v", hits)
+	}
+	if hits, _ := ix.Search("release", SearchOptions{WidgetTypes: []string{"note"}, Limit: 1}); len(hits) != 1 || hits[0].Doc.WidgetType != "Note" {
+		t.Errorf("filtered hits = %+v", hits)
+	}
+	if _, err := ix.Search(`"open`, SearchOptions{}); err == nil {
+		t.Error("expected error for an unterminated phrase")
+	}
+
+	// Only changed canvases are re-indexed; deleted widgets and canvases drop out.
+	fake.mu.Lock()
+	fake.store["canvases/c1/notes"] = fake.store["canvases/c1/notes"][1:]
+	fake.store["canvases"][0]["modified_at"] = "t2"
+	fake.store["canvases"] = fake.store["canvases"][:1]
+	fake.mu.Unlock()
+	before := fake.callCount("GET canvases/c1/notes")
+	if n, err := ix.Refresh(ctx, s); err != nil || n != 1 || ix.Len() != 2 {
+		t.Fatalf("second Refresh = %d, %v; Len %d", n, err, ix.Len())
+	}
+	if fake.callCount("GET canvases/c1/notes") != before+1 {
+		t.Error("changed canvas not re-listed")
+	}
+	if n, _ := ix.Refresh(ctx, s); n != 0 {
+		t.Errorf("unchanged Refresh re-indexed %d canvases", n)
+	}
+
+	ix.Put(SearchDoc{CanvasID: "c1", WidgetID: "n9", WidgetType: "Note", Fields: map[string]string{"text": "Zebra crossing"}})
+	if hits, _ := ix.Search("zebra", SearchOptions{}); len(hits) != 1 {
+		t.Errorf("Put doc not found: %+v", hits)
+	}
+	if !ix.Remove("c1", "n9") || ix.Remove("c1", "n9") {
+		t.Error("Remove reported wrong result")
+	}
+}
+
+func TestSearchIndexRefreshSkipsUnreadableCanvas(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("canvases", map[string]interface{}{"id": "bad", "name": "Broken", "modified_at": "t1"})
+	fake.seed("canvases", map[string]interface{}{"id": "c1", "name": "Planning", "modified_at": "t1"})
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "Release plan"})
+	fake.handle("GET", "canvases/bad/notes", func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		writeFakeJSON(w, http.StatusForbidden, map[string]interface{}{"code": "forbidden", "message": "no access"})
+	})
+	s := fake.session()
+	ctx := context.Background()
+
+	ix := NewSearchIndex()
+	ix.Put(SearchDoc{CanvasID: "gone", WidgetID: "g1", WidgetType: "Note", Fields: map[string]string{"text": "stale"}})
+	n, err := ix.Refresh(ctx, s)
+	if err == nil || !strings.Contains(err.Error(), "canvas bad") {
+		t.Errorf("Refresh error = %v", err)
+	}
+	if n != 1 || ix.Len() != 1 {
+		t.Fatalf("Refresh = %d; Len %d", n, ix.Len())
+	}
+	if hits, _ := ix.Search("stale", SearchOptions{}); len(hits) != 0 {
+		t.Errorf("stale canvas kept: %+v", hits)
+	}
+}
@@ -44,13 +44,16 @@ func (a *APIKeyAuthenticator) Authenticate(req *http.Request) {
 type transportWithAPIKey struct {
 	transport http.RoundTripper
 	header   string
+	mu       sync.RWMutex
 	apiKey   string
 }
 
 // RoundTrip adds the API key to the request headers
 func (t *transportWithAPIKey) RoundTrip(req *http.Request) (*http.Response, error) {
 	req = req.Clone(req.Context())
+	t.mu.RLock()
 	req.Header.Add(t.header, t.apiKey)
+	t.mu.RUnlock()
 	req.Header.Add("Content-Type", "application/json")
 	return t.transport.RoundTrip(req)
 }
@@ -99,7 +102,7 @@ func WithAPIKey(apiKey string) SessionConfigOption {
 // WithToken configures the session to use a bearer token.
 func WithToken(token string) SessionOption {
 	return func(s *Session) {
-		s.authenticator = &TokenAuthenticator{Token: token}
+		s.setAuthenticator(&TokenAuthenticator{Token: token})
 	}
 }
 
@@ -258,6 +261,7 @@ type Session struct {
 	HTTPClient    *http.Client
 	config        *SessionConfig
 	authenticator Authenticator
+	authMutex     sync.RWMutex // guards authenticator against SetToken during requests
 	tokenManager  *tokenManager
 	circuitBreaker *circuitBreaker
 	userID        int64 // ID of the authenticated user, if available
@@ -292,7 +296,7 @@ func NewSession(cfg *SessionConfig, opts ...SessionConfigOption) *Session {
 	// If we have a token from the store, use it
 	if s.tokenManager.tokenStore != nil {
 		if token, err := s.tokenManager.tokenStore.GetToken(); err == nil && token != "" {
-			s.authenticator = &TokenAuthenticator{Token: token}
+			s.setAuthenticator(&TokenAuthenticator{Token: token})
 		}
 	}
 
@@ -361,9 +365,7 @@ func (s *Session) doRequest(ctx context.Context, method, endpoint string, body i
 		req.Header.Set("User-Agent", s.config.UserAgent)
 
 		// Apply authentication
-		if s.authenticator != nil {
-			s.authenticator.Authenticate(req)
-		}
+		s.authenticate(req)
 
 		// Execute request
 		resp, err = s.HTTPClient.Do(req)
@@ -486,17 +488,29 @@ func (s *Session) handleErrorResponse(resp *http.Response, body []byte, attempt
 // refreshAuthToken attempts to refresh the authentication token
 func (s *Session) refreshAuthToken(ctx context.Context) error {
 	// If we're using token-based auth, try to refresh the token
-	if tokenAuth, ok := s.authenticator.(*TokenAuthenticator); ok {
-		// Use the token manager to handle refresh
-		newToken := s.tokenManager.getToken()
-		if newToken != "" && newToken != tokenAuth.Token {
-			tokenAuth.Token = newToken
-			return nil
-		}
+	s.authMutex.RLock()
+	tokenAuth, ok := s.authenticator.(*TokenAuthenticator)
+	s.authMutex.RUnlock()
+	if !ok {
+		return errors.New("unable to refresh authentication token")
+	}
+
+	// Use the token manager to handle refresh, outside the lock so requests are not held up
+	newToken := s.tokenManager.getToken()
 
-		// If we couldn't get a new token, clear the current one
-		s.authenticator = nil
+	s.authMutex.Lock()
+	defer s.authMutex.Unlock()
+	if s.authenticator != tokenAuth {
+		// Replaced meanwhile (SetToken, Login or another refresh); retry with that one
+		return nil
+	}
+	if newToken != "" && newToken != tokenAuth.Token {
+		s.authenticator = &TokenAuthenticator{Token: newToken}
+		return nil
 	}
+
+	// If we couldn't get a new token, clear the current one
+	s.authenticator = nil
 	return errors.New("unable to refresh authentication token")
 }
 
@@ -723,6 +737,15 @@ func validateResponse(obj interface{}, reqBody interface{}, method string) error
 			}
 			continue
 		}
+		// Nested objects (e.g. connector ends) are echoed with extra defaults; only compare requested keys
+		if reqSub, ok := reqVal.(map[string]interface{}); ok {
+			if respSub, ok := respVal.(map[string]interface{}); ok {
+				if err := validateResponse(respSub, reqSub, method); err != nil {
+					return fmt.Errorf("response field %q: %w", k, err)
+				}
+				continue
+			}
+		}
 		if !reflect.DeepEqual(respVal, reqVal) {
 			return fmt.Errorf("response field %q mismatch: got %v, want %v", k, respVal, reqVal)
 		}
@@ -780,9 +803,7 @@ func (s *Session) doRequestWithHeaders(ctx context.Context, method, endpoint str
 	if err != nil {
 		return err
 	}
-	if s.authenticator != nil {
-		s.authenticator.Authenticate(req)
-	}
+	s.authenticate(req)
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -806,17 +827,13 @@ func (s *Session) doRequestWithHeaders(ctx context.Context, method, endpoint str
 	if out != nil {
 		if rawResponse {
 			// out must be *[]byte
-			b, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return err
-			}
 			if ptr, ok := out.(*[]byte); ok {
-				*ptr = b
+				*ptr = respBody
 			} else {
 				return errors.New("out must be *[]byte when rawResponse is true")
 			}
 		} else {
-			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			if err := json.Unmarshal(respBody, out); err != nil {
 				return err
 			}
 		}
@@ -863,7 +880,7 @@ func (s *Session) Login(ctx context.Context, email, password string) error {
 	if loginResp.Token == "" {
 		return errors.New("login: no token returned")
 	}
-	s.authenticator = &TokenAuthenticator{Token: loginResp.Token}
+	s.setAuthenticator(&TokenAuthenticator{Token: loginResp.Token})
 	s.userID = loginResp.User.ID
 	return nil
 }
@@ -877,10 +894,69 @@ func (s *Session) Logout(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	s.authenticator = nil
+	s.setAuthenticator(nil)
 	return nil
 }
 
+// setAuthenticator replaces the session's authenticator. Authenticators are never modified
+// in place, so a request that has already read one is unaffected.
+func (s *Session) setAuthenticator(a Authenticator) {
+	s.authMutex.Lock()
+	defer s.authMutex.Unlock()
+	s.authenticator = a
+}
+
+// authenticate applies the current authenticator, if any, to req.
+func (s *Session) authenticate(req *http.Request) {
+	s.authMutex.RLock()
+	defer s.authMutex.RUnlock()
+	if s.authenticator != nil {
+		s.authenticator.Authenticate(req)
+	}
+}
+
+// SetToken switches a running session to a new access token. Sessions created with WithAPIKey
+// have their API key transport updated; otherwise the authenticator is replaced. The token
+// is also saved to the configured TokenStore. It is safe to call while requests are in flight.
+func (s *Session) SetToken(token string, expiresAt time.Time) {
+	if t, ok := s.HTTPClient.Transport.(*transportWithAPIKey); ok {
+		t.mu.Lock()
+		t.apiKey = token
+		t.mu.Unlock()
+	} else {
+		s.authMutex.Lock()
+		if a, ok := s.authenticator.(*APIKeyAuthenticator); ok {
+			s.authenticator = &APIKeyAuthenticator{Header: a.Header, APIKey: token}
+		} else {
+			s.authenticator = &TokenAuthenticator{Token: token}
+		}
+		s.authMutex.Unlock()
+	}
+	var expiresIn time.Duration
+	if !expiresAt.IsZero() {
+		expiresIn = time.Until(expiresAt)
+	}
+	s.tokenManager.setToken(token, expiresIn)
+}
+
+// currentToken returns the token the session authenticates with, or "" if unknown.
+func (s *Session) currentToken() string {
+	if t, ok := s.HTTPClient.Transport.(*transportWithAPIKey); ok {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.apiKey
+	}
+	s.authMutex.RLock()
+	defer s.authMutex.RUnlock()
+	switch a := s.authenticator.(type) {
+	case *TokenAuthenticator:
+		return a.Token
+	case *APIKeyAuthenticator:
+		return a.APIKey
+	}
+	return ""
+}
+
 // Users provides access to user management methods.
 func (s *Session) Users() *Session {
 	return s
@@ -0,0 +1,437 @@
+package canvus
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	// quadNodeCapacity is the number of entries a leaf holds before it splits.
+	quadNodeCapacity = 8
+	// quadMinSize stops splitting once a quadrant would be narrower than one canvas unit.
+	quadMinSize = 1.0
+	// quadInitialSize is the side of the first root square; the root doubles as widgets fall outside it.
+	quadInitialSize = 1024.0
+)
+
+// SpatialIndex is a quadtree over widget bounding boxes in canvas coordinates (resolved through
+// the parent chain with CanvasGeometry) for region, point-hit and nearest-neighbour queries.
+// Insert, Update and Remove keep it current as widgets change, moving descendants along with
+// their parents, so long-running tools can answer many queries from one ListWidgets call.
+// Widgets without a size are tracked but never returned by queries. A SpatialIndex is safe for
+// concurrent use.
+//
+// Usage Example:
+//
+//	idx, _ := session.LoadSpatialIndex(ctx, canvasID)
+//	hits := idx.At(canvus.Point{X: 1200, Y: 800})
+//	zone, ok := idx.Zone(frameID, 5)
+type SpatialIndex struct {
+	// CanvasID is reported in the WidgetZones returned by Zone.
+	CanvasID string
+
+	mu             sync.RWMutex
+	root           *quadNode
+	entries        map[string]*spatialEntry
+	geom           *CanvasGeometry
+	sharedCanvasID string
+}
+
+type spatialEntry struct {
+	widget Widget
+	rect   Rectangle
+	node   *quadNode
+}
+
+type quadNode struct {
+	bounds   Rectangle
+	entries  []*spatialEntry
+	children *[4]*quadNode
+}
+
+// NewSpatialIndex builds an index over the given widgets.
+func NewSpatialIndex(widgets []Widget) *SpatialIndex {
+	ix := &SpatialIndex{entries: make(map[string]*spatialEntry, len(widgets)), geom: NewCanvasGeometry(widgets)}
+	for _, w := range widgets {
+		if w.WidgetType == "SharedCanvas" {
+			ix.sharedCanvasID = w.ID
+		}
+		ix.index(w.ID)
+	}
+	return ix
+}
+
+// LoadSpatialIndex lists the widgets of a canvas and indexes them.
+func (s *Session) LoadSpatialIndex(ctx context.Context, canvasID string) (*SpatialIndex, error) {
+	widgets, err := s.ListWidgets(ctx, canvasID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("LoadSpatialIndex: %w", err)
+	}
+	ix := NewSpatialIndex(widgets)
+	ix.CanvasID = canvasID
+	return ix, nil
+}
+
+// Len returns the number of indexed (sized) widgets.
+func (ix *SpatialIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.entries)
+}
+
+// Get returns the index's copy of a widget.
+func (ix *SpatialIndex) Get(id string) (Widget, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.geom == nil {
+		return Widget{}, false
+	}
+	return ix.geom.Widget(id)
+}
+
+// CanvasRect returns the canvas-space bounding box the index holds for a widget.
+func (ix *SpatialIndex) CanvasRect(id string) (Rectangle, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	e, ok := ix.entries[id]
+	if !ok {
+		return Rectangle{}, false
+	}
+	return e.rect, true
+}
+
+// Insert adds a widget, replacing any indexed widget with the same ID.
+func (ix *SpatialIndex) Insert(w Widget) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.set(w)
+}
+
+// Update replaces a known widget with w. It reports false, and changes nothing, if the index has
+// no widget with w.ID.
+func (ix *SpatialIndex) Update(w Widget) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if _, ok := ix.geometry().Widget(w.ID); !ok {
+		return false
+	}
+	ix.set(w)
+	return true
+}
+
+// Remove drops a widget from the index and reports whether it was present.
+func (ix *SpatialIndex) Remove(id string) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.remove(id)
+}
+
+// geometry returns the CanvasGeometry the index resolves positions with, creating it for a zero
+// SpatialIndex. ix.mu must be held for writing.
+func (ix *SpatialIndex) geometry() *CanvasGeometry {
+	if ix.geom == nil {
+		ix.geom = NewCanvasGeometry(nil)
+	}
+	return ix.geom
+}
+
+func (ix *SpatialIndex) set(w Widget) {
+	if w.WidgetType == "SharedCanvas" {
+		ix.sharedCanvasID = w.ID
+	}
+	g := ix.geometry()
+	g.Set(w)
+	ix.index(w.ID)
+	for _, id := range g.descendants(w.ID) {
+		ix.index(id)
+	}
+}
+
+func (ix *SpatialIndex) remove(id string) bool {
+	g := ix.geometry()
+	if _, ok := g.Widget(id); !ok {
+		return false
+	}
+	kids := g.descendants(id)
+	g.Remove(id)
+	ix.unindex(id)
+	for _, kid := range kids {
+		ix.index(kid)
+	}
+	return true
+}
+
+// index (re)computes the canvas rectangle of a widget and stores it in the tree.
+func (ix *SpatialIndex) index(id string) {
+	ix.unindex(id)
+	w, ok := ix.geom.Widget(id)
+	if !ok || w.Size == nil {
+		return
+	}
+	r := ix.geom.CanvasRect(w)
+	if !finiteRect(r) {
+		return
+	}
+	if ix.entries == nil {
+		ix.entries = make(map[string]*spatialEntry)
+	}
+	e := &spatialEntry{widget: w, rect: r}
+	ix.entries[id] = e
+	ix.grow(r)
+	ix.root.insert(e)
+}
+
+func (ix *SpatialIndex) unindex(id string) {
+	e, ok := ix.entries[id]
+	if !ok {
+		return
+	}
+	delete(ix.entries, id)
+	n := e.node
+	for i, other := range n.entries {
+		if other == e {
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+			break
+		}
+	}
+}
+
+// grow enlarges the root until it contains r, keeping the old root as one of the new quadrants.
+func (ix *SpatialIndex) grow(r Rectangle) {
+	if ix.root == nil {
+		side := math.Max(quadInitialSize, math.Max(r.Width, r.Height))
+		ix.root = &quadNode{bounds: Rectangle{X: r.X, Y: r.Y, Width: side, Height: side}}
+		return
+	}
+	for !Contains(ix.root.bounds, r) {
+		old := ix.root
+		b := old.bounds
+		nb := Rectangle{X: b.X, Y: b.Y, Width: 2 * b.Width, Height: 2 * b.Height}
+		q := 0
+		if r.X < b.X {
+			nb.X -= b.Width
+			q |= 1
+		}
+		if r.Y < b.Y {
+			nb.Y -= b.Height
+			q |= 2
+		}
+		root := &quadNode{bounds: nb}
+		root.children = quadrants(nb)
+		root.children[q] = old
+		ix.root = root
+	}
+}
+
+// quadrants returns the four quarters of b; bit 0 of the index selects the right half and bit 1
+// the bottom half.
+func quadrants(b Rectangle) *[4]*quadNode {
+	hw, hh := b.Width/2, b.Height/2
+	var c [4]*quadNode
+	for i := range c {
+		q := Rectangle{X: b.X, Y: b.Y, Width: hw, Height: hh}
+		if i&1 != 0 {
+			q.X += hw
+		}
+		if i&2 != 0 {
+			q.Y += hh
+		}
+		c[i] = &quadNode{bounds: q}
+	}
+	return &c
+}
+
+// insert stores e in the deepest node that fully contains it, splitting full leaves.
+func (n *quadNode) insert(e *spatialEntry) {
+	if n.children != nil {
+		for _, c := range n.children {
+			if Contains(c.bounds, e.rect) {
+				c.insert(e)
+				return
+			}
+		}
+	}
+	n.entries = append(n.entries, e)
+	e.node = n
+	if n.children == nil && len(n.entries) > quadNodeCapacity && n.bounds.Width/2 >= quadMinSize {
+		n.children = quadrants(n.bounds)
+		old := n.entries
+		n.entries = nil
+		for _, e := range old {
+			n.insert(e)
+		}
+	}
+}
+
+// search calls fn for every entry in nodes whose bounds share a point with r.
+func (n *quadNode) search(r Rectangle, fn func(*spatialEntry)) {
+	if !Touches(n.bounds, r) {
+		return
+	}
+	for _, e := range n.entries {
+		fn(e)
+	}
+	if n.children != nil {
+		for _, c := range n.children {
+			c.search(r, fn)
+		}
+	}
+}
+
+func (ix *SpatialIndex) query(r Rectangle, keep func(Rectangle) bool) []Widget {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.root == nil {
+		return nil
+	}
+	var out []Widget
+	ix.root.search(r, func(e *spatialEntry) {
+		if keep(e.rect) {
+			out = append(out, e.widget)
+		}
+	})
+	sortTopmostFirst(out)
+	return out
+}
+
+// Within returns the widgets whose bounding boxes lie fully inside r, topmost (highest Depth) first.
+func (ix *SpatialIndex) Within(r Rectangle) []Widget {
+	return ix.query(r, func(b Rectangle) bool { return Contains(r, b) })
+}
+
+// Intersecting returns the widgets whose bounding boxes overlap or touch r, topmost first.
+func (ix *SpatialIndex) Intersecting(r Rectangle) []Widget {
+	return ix.query(r, func(b Rectangle) bool { return Touches(r, b) })
+}
+
+// Containing returns the widgets whose bounding boxes fully contain r, topmost first.
+func (ix *SpatialIndex) Containing(r Rectangle) []Widget {
+	return ix.query(r, func(b Rectangle) bool { return Contains(b, r) })
+}
+
+// At returns the widgets under a canvas point, topmost first; edges count as hits.
+func (ix *SpatialIndex) At(p Point) []Widget {
+	return ix.Containing(Rectangle{X: p.X, Y: p.Y})
+}
+
+// Nearest returns up to k widgets ordered by the distance from p to their bounding boxes
+// (zero when p is inside); ties are ordered by widget ID.
+func (ix *SpatialIndex) Nearest(p Point, k int) []Widget {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.root == nil || k <= 0 {
+		return nil
+	}
+	q := &nearQueue{{dist: pointRectDistance(p, ix.root.bounds), node: ix.root}}
+	var out []Widget
+	for q.Len() > 0 && len(out) < k {
+		it := heap.Pop(q).(nearItem)
+		if it.entry != nil {
+			out = append(out, it.entry.widget)
+			continue
+		}
+		for _, e := range it.node.entries {
+			heap.Push(q, nearItem{dist: pointRectDistance(p, e.rect), entry: e})
+		}
+		if it.node.children != nil {
+			for _, c := range it.node.children {
+				heap.Push(q, nearItem{dist: pointRectDistance(p, c.bounds), node: c})
+			}
+		}
+	}
+	return out
+}
+
+// Zone is WidgetsContainId answered from the index: the widget as Container and every other
+// indexed widget fully inside its canvas bounding box (grown by tolerance) as Contents, with ParentIDs
+// that point at the SharedCanvas blanked. It reports false if widgetID is not indexed.
+func (ix *SpatialIndex) Zone(widgetID string, tolerance float64) (WidgetZone, bool) {
+	ix.mu.RLock()
+	src, ok := ix.entries[widgetID]
+	sharedCanvasID := ix.sharedCanvasID
+	ix.mu.RUnlock()
+	if !ok {
+		return WidgetZone{}, false
+	}
+	r := src.rect
+	r.X -= tolerance
+	r.Y -= tolerance
+	r.Width += 2 * tolerance
+	r.Height += 2 * tolerance
+
+	zone := WidgetZone{CanvasID: ix.CanvasID, SharedCanvasID: sharedCanvasID, Container: src.widget}
+	for _, w := range ix.Within(r) {
+		if w.ID == widgetID || w.WidgetType == "SharedCanvas" {
+			continue
+		}
+		if sharedCanvasID != "" && w.ParentID == sharedCanvasID {
+			w.ParentID = ""
+		}
+		zone.Contents = append(zone.Contents, w)
+	}
+	if sharedCanvasID != "" && zone.Container.ParentID == sharedCanvasID {
+		zone.Container.ParentID = ""
+	}
+	return zone, true
+}
+
+func finiteRect(r Rectangle) bool {
+	for _, v := range []float64{r.X, r.Y, r.Width, r.Height} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return r.Width >= 0 && r.Height >= 0
+}
+
+func pointRectDistance(p Point, r Rectangle) float64 {
+	dx := math.Max(0, math.Max(r.X-p.X, p.X-(r.X+r.Width)))
+	dy := math.Max(0, math.Max(r.Y-p.Y, p.Y-(r.Y+r.Height)))
+	return math.Hypot(dx, dy)
+}
+
+func sortTopmostFirst(ws []Widget) {
+	sort.Slice(ws, func(i, j int) bool {
+		if ws[i].Depth != ws[j].Depth {
+			return ws[i].Depth > ws[j].Depth
+		}
+		return ws[i].ID < ws[j].ID
+	})
+}
+
+type nearItem struct {
+	dist  float64
+	node  *quadNode
+	entry *spatialEntry
+}
+
+// nearQueue is a min-heap by distance. At equal distance nodes come before entries, so every
+// entry at that distance is queued before any is returned and ties can be ordered by ID.
+type nearQueue []nearItem
+
+func (q nearQueue) Len() int { return len(q) }
+func (q nearQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.dist != b.dist {
+		return a.dist < b.dist
+	}
+	if (a.entry == nil) != (b.entry == nil) {
+		return a.entry == nil
+	}
+	if a.entry != nil {
+		return a.entry.widget.ID < b.entry.widget.ID
+	}
+	return false
+}
+func (q nearQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nearQueue) Push(x interface{}) { *q = append(*q, x.(nearItem)) }
+func (q *nearQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
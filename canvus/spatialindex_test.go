@@ -0,0 +1,126 @@
+package canvus
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func widgetAt(id string, x, y, w, h float64) Widget {
+	return Widget{ID: id, WidgetType: "Note", Location: &Point{X: x, Y: y}, Size: &Size{Width: w, Height: h}}
+}
+
+func idsOf(ws []Widget) []string {
+	ids := make([]string, len(ws))
+	for i, w := range ws {
+		ids[i] = w.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSpatialIndexMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var widgets []Widget
+	for i := 0; i < 2000; i++ {
+		widgets = append(widgets, widgetAt(fmt.Sprint("w", i),
+			rng.Float64()*20000-5000, rng.Float64()*20000-5000, rng.Float64()*300, rng.Float64()*300))
+	}
+	ix := NewSpatialIndex(widgets)
+	if ix.Len() != len(widgets) {
+		t.Fatalf("Len = %d", ix.Len())
+	}
+	for q := 0; q < 50; q++ {
+		r := Rectangle{X: rng.Float64()*20000 - 5000, Y: rng.Float64()*20000 - 5000, Width: rng.Float64() * 3000, Height: rng.Float64() * 3000}
+		var within, inter []Widget
+		for _, w := range widgets {
+			b := WidgetBoundingBox(w)
+			if Contains(r, b) {
+				within = append(within, w)
+			}
+			if Touches(r, b) {
+				inter = append(inter, w)
+			}
+		}
+		if got, want := idsOf(ix.Within(r)), idsOf(within); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("Within(%+v) = %v, want %v", r, got, want)
+		}
+		if got, want := idsOf(ix.Intersecting(r)), idsOf(inter); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("Intersecting(%+v) = %v, want %v", r, got, want)
+		}
+	}
+}
+
+func TestSpatialIndexIncrementalAndPointQueries(t *testing.T) {
+	bg := Widget{ID: "sc", WidgetType: "SharedCanvas", Location: &Point{}, Size: &Size{Width: 1000, Height: 1000}}
+	frame := widgetAt("frame", 100, 100, 400, 400)
+	frame.ParentID = "sc"
+	inner := widgetAt("inner", 150, 150, 50, 50)
+	inner.ParentID, inner.Depth = "sc", 2
+	edge := widgetAt("edge", 495, 200, 20, 20)
+	ix := NewSpatialIndex([]Widget{bg, frame, inner, edge, {ID: "conn", WidgetType: "Connector"}})
+	ix.CanvasID = "c1"
+
+	if got := idsOf(ix.At(Point{X: 160, Y: 160})); fmt.Sprint(got) != "[frame inner sc]" {
+		t.Errorf("At = %v", got)
+	}
+	if got := ix.At(Point{X: 160, Y: 160}); got[0].ID != "inner" {
+		t.Errorf("At not topmost first: %v", idsOf(got))
+	}
+	if got := ix.Nearest(Point{X: 700, Y: 210}, 2); len(got) != 2 || got[0].ID != "sc" || got[1].ID != "edge" {
+		t.Errorf("Nearest = %v", idsOf(got))
+	}
+
+	zone, ok := ix.Zone("frame", 0)
+	if !ok || zone.CanvasID != "c1" || zone.SharedCanvasID != "sc" || fmt.Sprint(idsOf(zone.Contents)) != "[inner]" {
+		t.Fatalf("Zone = %+v, %v", zone, ok)
+	}
+	if zone.Contents[0].ParentID != "" || zone.Container.ParentID != "" {
+		t.Error("Zone did not blank SharedCanvas parent IDs")
+	}
+	if zone, _ := ix.Zone("frame", 20); fmt.Sprint(idsOf(zone.Contents)) != "[edge inner]" {
+		t.Errorf("Zone with tolerance = %v", idsOf(zone.Contents))
+	}
+
+	// Move inner far outside the current root, then remove it.
+	if !ix.Update(widgetAt("inner", -90000, 70000, 10, 10)) {
+		t.Fatal("Update reported missing widget")
+	}
+	if got := idsOf(ix.At(Point{X: -89995, Y: 70005})); fmt.Sprint(got) != "[inner]" {
+		t.Errorf("At after move = %v", got)
+	}
+	if zone, _ := ix.Zone("frame", 0); len(zone.Contents) != 0 {
+		t.Errorf("stale contents after Update: %v", idsOf(zone.Contents))
+	}
+	if !ix.Remove("inner") || ix.Remove("inner") || ix.Update(widgetAt("inner", 0, 0, 1, 1)) {
+		t.Error("Remove/Update did not track membership")
+	}
+	if got := ix.At(Point{X: -89995, Y: 70005}); len(got) != 0 {
+		t.Errorf("removed widget still hit: %v", idsOf(got))
+	}
+	if _, ok := ix.Get("conn"); !ok || ix.Len() != 3 {
+		t.Errorf("unsized widgets should be tracked but not indexed; Len = %d", ix.Len())
+	}
+}
+
+func TestSpatialIndexFollowsParents(t *testing.T) {
+	frame := widgetAt("frame", 100, 100, 400, 400)
+	frame.Scale = 0.5
+	child := widgetAt("child", 20, 20, 100, 100)
+	child.ParentID = "frame"
+	ix := NewSpatialIndex([]Widget{child, frame})
+
+	if r, _ := ix.CanvasRect("child"); r != (Rectangle{X: 110, Y: 110, Width: 50, Height: 50}) {
+		t.Fatalf("child canvas rect = %+v", r)
+	}
+	frame.Location = &Point{X: 1000, Y: 0}
+	ix.Update(frame)
+	if got := idsOf(ix.At(Point{X: 1020, Y: 20})); fmt.Sprint(got) != "[child frame]" {
+		t.Errorf("child did not move with its parent: %v", got)
+	}
+	ix.Remove("frame")
+	if r, _ := ix.CanvasRect("child"); r != (Rectangle{X: 20, Y: 20, Width: 100, Height: 100}) {
+		t.Errorf("orphaned child rect = %+v", r)
+	}
+}
@@ -0,0 +1,574 @@
+package canvus
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rendering defaults, in canvas units unless noted.
+const (
+	svgDefaultWidth   = 1600 // output pixels
+	svgDefaultPadding = 50
+	svgFontSize       = 24 // note and label text, scaled with the widget
+	svgLineHeight     = 1.25
+	svgAnnotationSize = 4 // annotation stroke width, scaled with the owning widget
+	svgConnectorWidth = 3 // used when a connector reports no line width
+)
+
+// SVGOptions controls RenderCanvasSVG. Zero values select the defaults.
+type SVGOptions struct {
+	// Region is the canvas area to draw. By default it is the bounding box of all widgets,
+	// grown by Padding on every side.
+	Region  *Rectangle
+	Padding float64 // default 50
+	Width   int     // output width in pixels; the height follows the region. Default 1600
+	// NoThumbnails draws images, PDFs and videos as labelled placeholders instead of
+	// embedding their mipmap thumbnails.
+	NoThumbnails bool
+}
+
+// RenderCanvasSVG draws a canvas, or the part of it inside opts.Region, as a standalone SVG
+// document built from the widget model rather than the server's preview. Notes are drawn with
+// their background colour and text, images, PDFs and videos with an embedded mipmap thumbnail
+// sized for the output, browsers and anchors as labelled placeholders, then annotation strokes
+// and connectors with their tips. Thumbnails that cannot be fetched fall back to placeholders.
+//
+// Usage Example:
+//
+//	svg, err := session.RenderCanvasSVG(ctx, canvasID, canvus.SVGOptions{Width: 1200})
+//	_ = os.WriteFile("board.svg", svg, 0644)
+func (s *Session) RenderCanvasSVG(ctx context.Context, canvasID string, opts SVGOptions) ([]byte, error) {
+	sc, err := s.loadSVGScene(ctx, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("RenderCanvasSVG: %w", err)
+	}
+	region, width := sc.viewport(opts)
+	if !opts.NoThumbnails {
+		if err := s.loadSVGThumbnails(ctx, canvasID, sc, region, width); err != nil {
+			return nil, fmt.Errorf("RenderCanvasSVG: %w", err)
+		}
+	}
+	return sc.render(region, width), nil
+}
+
+// DecodeAnnotationPoints decodes an Annotation's Points: base64 of little-endian float32 x,y
+// pairs in the local space of the annotated widget.
+func DecodeAnnotationPoints(points string) ([]Point, error) {
+	raw, err := base64.StdEncoding.DecodeString(points)
+	if err != nil {
+		return nil, fmt.Errorf("DecodeAnnotationPoints: %w", err)
+	}
+	if len(raw)%8 != 0 {
+		return nil, fmt.Errorf("DecodeAnnotationPoints: %d bytes is not a whole number of points", len(raw))
+	}
+	out := make([]Point, 0, len(raw)/8)
+	for i := 0; i < len(raw); i += 8 {
+		x := math.Float32frombits(binary.LittleEndian.Uint32(raw[i:]))
+		y := math.Float32frombits(binary.LittleEndian.Uint32(raw[i+4:]))
+		out = append(out, Point{X: float64(x), Y: float64(y)})
+	}
+	return out, nil
+}
+
+// svgScene is everything RenderCanvasSVG draws, fetched up front.
+type svgScene struct {
+	geom       *CanvasGeometry
+	widgets    []Widget
+	notes      map[string]Note
+	browsers   map[string]Browser
+	anchors    map[string]Anchor
+	assets     map[string]svgAsset
+	connectors []Connector
+	background string            // RRGGBBAA
+	thumbs     map[string]string // widget ID -> data URI
+}
+
+type svgAsset struct {
+	label string
+	hash  string
+	page  *int
+}
+
+func (s *Session) loadSVGScene(ctx context.Context, canvasID string) (*svgScene, error) {
+	widgets, err := s.ListWidgets(ctx, canvasID, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	sc := &svgScene{
+		geom:       NewCanvasGeometry(widgets),
+		widgets:    widgets,
+		notes:      map[string]Note{},
+		browsers:   map[string]Browser{},
+		anchors:    map[string]Anchor{},
+		assets:     map[string]svgAsset{},
+		background: ColorWhite,
+		thumbs:     map[string]string{},
+	}
+	kinds := map[string]bool{}
+	for _, w := range widgets {
+		kinds[strings.ToLower(w.WidgetType)] = true
+	}
+	label := func(filename, title string) string {
+		if filename != "" {
+			return filename
+		}
+		return title
+	}
+	if kinds["note"] {
+		notes, err := s.ListNotes(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			sc.notes[n.ID] = n
+		}
+	}
+	if kinds["browser"] {
+		browsers, err := s.ListBrowsers(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range browsers {
+			sc.browsers[b.ID] = b
+		}
+	}
+	if kinds["anchor"] {
+		anchors, err := s.ListAnchors(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range anchors {
+			sc.anchors[a.ID] = a
+		}
+	}
+	if kinds["image"] {
+		images, err := s.ListImages(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, im := range images {
+			sc.assets[im.ID] = svgAsset{label: label(im.OriginalFilename, im.Title), hash: im.Hash}
+		}
+	}
+	if kinds["pdf"] {
+		pdfs, err := s.ListPDFs(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pdfs {
+			page := p.Index
+			sc.assets[p.ID] = svgAsset{label: label(p.OriginalFilename, p.Title), hash: p.Hash, page: &page}
+		}
+	}
+	if kinds["video"] {
+		videos, err := s.ListVideos(ctx, canvasID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range videos {
+			sc.assets[v.ID] = svgAsset{label: label(v.OriginalFilename, v.Title), hash: v.Hash}
+		}
+	}
+	if sc.connectors, err = s.ListConnectors(ctx, canvasID, nil); err != nil {
+		return nil, err
+	}
+	// The background is cosmetic; keep white if it cannot be read.
+	if bg, err := s.GetCanvasBackground(ctx, canvasID); err == nil {
+		c := bg.BackgroundColor
+		if c == "" && bg.Haze != nil {
+			c = bg.Haze.Color1
+		}
+		if c, err := NormalizeColor(c); err == nil {
+			sc.background = c
+		}
+	}
+	return sc, nil
+}
+
+// loadSVGThumbnails embeds a mipmap level of each visible asset, the smallest that is at least
+// as wide as the asset appears in the output. Only a cancelled context is an error.
+func (s *Session) loadSVGThumbnails(ctx context.Context, canvasID string, sc *svgScene, region Rectangle, width int) error {
+	px := float64(width) / region.Width
+	for _, w := range sc.widgets {
+		a, ok := sc.assets[w.ID]
+		if !ok || a.hash == "" || !svgDrawable(w) {
+			continue
+		}
+		r := sc.geom.CanvasRect(w)
+		if !Touches(r, region) {
+			continue
+		}
+		info, err := s.GetMipmapInfo(ctx, canvasID, a.hash, a.page)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		level := 0
+		for level < info.MaxLevel && float64(info.Resolution.Width>>(level+1)) >= r.Width*px {
+			level++
+		}
+		data, err := s.GetMipmapLevel(ctx, canvasID, a.hash, level, a.page)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		sc.thumbs[w.ID] = "data:image/webp;base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	return nil
+}
+
+// svgDrawable reports whether w is a widget with its own rectangle.
+func svgDrawable(w Widget) bool {
+	return w.Location != nil && w.Size != nil && w.WidgetType != "SharedCanvas" && w.WidgetType != "Connector"
+}
+
+func (sc *svgScene) viewport(opts SVGOptions) (Rectangle, int) {
+	width := opts.Width
+	if width <= 0 {
+		width = svgDefaultWidth
+	}
+	if r := opts.Region; r != nil && r.Width > 0 && r.Height > 0 {
+		return *r, width
+	}
+	pad := opts.Padding
+	if pad <= 0 {
+		pad = svgDefaultPadding
+	}
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, w := range sc.widgets {
+		if !svgDrawable(w) {
+			continue
+		}
+		r := sc.geom.CanvasRect(w)
+		minX, minY = math.Min(minX, r.X), math.Min(minY, r.Y)
+		maxX, maxY = math.Max(maxX, r.X+r.Width), math.Max(maxY, r.Y+r.Height)
+	}
+	if math.IsInf(minX, 1) {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+	return Rectangle{X: minX - pad, Y: minY - pad, Width: maxX - minX + 2*pad, Height: maxY - minY + 2*pad}, width
+}
+
+// render writes the SVG document. Widgets are drawn in scene-graph order: siblings by depth,
+// each followed by its annotations and then its children.
+func (sc *svgScene) render(region Rectangle, width int) []byte {
+	height := int(math.Round(float64(width) * region.Height / region.Width))
+	if height < 1 {
+		height = 1
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%s %s %s %s" font-family="sans-serif">`+"\n",
+		width, height, svgNum(region.X), svgNum(region.Y), svgNum(region.Width), svgNum(region.Height))
+	fmt.Fprintf(&b, "<rect %s%s/>\n", svgRectAttrs(region), svgPaint("fill", sc.background, ColorWhite))
+
+	children := map[string][]Widget{}
+	var roots []Widget
+	var canvasID string
+	for _, w := range sc.widgets {
+		if w.WidgetType == "SharedCanvas" {
+			canvasID = w.ID
+			continue
+		}
+		if !svgDrawable(w) {
+			continue
+		}
+		if p, ok := sc.geom.Widget(w.ParentID); ok && svgDrawable(p) {
+			children[p.ID] = append(children[p.ID], w)
+		} else {
+			roots = append(roots, w)
+		}
+	}
+	byDepth := func(ws []Widget) {
+		sort.SliceStable(ws, func(i, j int) bool {
+			if ws[i].Depth != ws[j].Depth {
+				return ws[i].Depth < ws[j].Depth
+			}
+			return ws[i].ID < ws[j].ID
+		})
+	}
+	seen := map[string]bool{}
+	var walk func(ws []Widget)
+	walk = func(ws []Widget) {
+		byDepth(ws)
+		for _, w := range ws {
+			if seen[w.ID] {
+				continue
+			}
+			seen[w.ID] = true
+			if r := sc.geom.CanvasRect(w); Touches(r, region) {
+				sc.drawWidget(&b, w, r)
+				sc.drawAnnotations(&b, w.ID, w.Annotations)
+			}
+			walk(children[w.ID])
+		}
+	}
+	walk(roots)
+	for _, w := range sc.widgets {
+		if w.ID == canvasID {
+			sc.drawAnnotations(&b, w.ID, w.Annotations)
+		}
+	}
+	for _, c := range sc.connectors {
+		sc.drawConnector(&b, c, region)
+	}
+	b.WriteString("</svg>\n")
+	return []byte(b.String())
+}
+
+func (sc *svgScene) drawWidget(b *strings.Builder, w Widget, r Rectangle) {
+	scale := sc.geom.ParentTransform(w).Scale * widgetScale(w)
+	fs := svgFontSize * scale
+	switch strings.ToLower(w.WidgetType) {
+	case "note":
+		n := sc.notes[w.ID]
+		bg, err := NormalizeColor(n.BackgroundColor)
+		if err != nil {
+			bg = ColorWhite
+		}
+		fmt.Fprintf(b, "<rect %s%s/>\n", svgRectAttrs(r), svgPaint("fill", bg, ColorWhite))
+		svgText(b, r, n.Text, fs, svgTextColor(bg), false)
+	case "browser":
+		br := sc.browsers[w.ID]
+		bar := Rectangle{X: r.X, Y: r.Y, Width: r.Width, Height: math.Min(fs*2, r.Height)}
+		fmt.Fprintf(b, `<rect %s fill="#f5f5f5" stroke="#999999" stroke-width="%s"/>`+"\n", svgRectAttrs(r), svgNum(scale))
+		fmt.Fprintf(b, `<rect %s fill="#dddddd"/>`+"\n", svgRectAttrs(bar))
+		title := br.Title
+		if title == "" {
+			title = br.URL
+		}
+		svgText(b, bar, title, fs, ColorBlack, false)
+		body := Rectangle{X: r.X, Y: bar.Y + bar.Height, Width: r.Width, Height: r.Height - bar.Height}
+		svgText(b, body, br.URL, fs, "666666FF", true)
+	case "anchor":
+		fmt.Fprintf(b, `<rect %s fill="none" stroke="#3366cc" stroke-width="%s" stroke-dasharray="%s"/>`+"\n",
+			svgRectAttrs(r), svgNum(2*scale), svgNum(10*scale))
+		svgText(b, r, sc.anchors[w.ID].AnchorName, fs, "3366CCFF", false)
+	case "image", "pdf", "video":
+		if uri, ok := sc.thumbs[w.ID]; ok {
+			fmt.Fprintf(b, `<image %s preserveAspectRatio="none" href="%s"/>`+"\n", svgRectAttrs(r), uri)
+			return
+		}
+		fmt.Fprintf(b, `<rect %s fill="#cccccc" stroke="#999999" stroke-width="%s"/>`+"\n", svgRectAttrs(r), svgNum(scale))
+		label := sc.assets[w.ID].label
+		if label == "" {
+			label = w.WidgetType
+		}
+		svgText(b, r, label, fs, "333333FF", true)
+	default:
+		fmt.Fprintf(b, `<rect %s fill="none" stroke="#999999" stroke-width="%s"/>`+"\n", svgRectAttrs(r), svgNum(scale))
+	}
+}
+
+// drawAnnotations strokes annotations in the local space of widget ownerID. Four or more points
+// in a 3n+1 run are drawn as a cubic Bézier spline, anything else as a polyline.
+func (sc *svgScene) drawAnnotations(b *strings.Builder, ownerID string, anns []Annotation) {
+	if len(anns) == 0 {
+		return
+	}
+	t := sc.geom.Transform(ownerID)
+	sorted := append([]Annotation(nil), anns...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Depth < sorted[j].Depth })
+	for _, a := range sorted {
+		pts, err := DecodeAnnotationPoints(a.Points)
+		if err != nil || len(pts) < 2 {
+			continue
+		}
+		var d strings.Builder
+		p := t.ToCanvas(pts[0])
+		fmt.Fprintf(&d, "M%s %s", svgNum(p.X), svgNum(p.Y))
+		cmd := "L"
+		if len(pts) >= 4 && (len(pts)-1)%3 == 0 {
+			cmd = "C"
+		}
+		d.WriteString(cmd)
+		for i, q := range pts[1:] {
+			p := t.ToCanvas(q)
+			if i > 0 {
+				d.WriteByte(' ')
+			}
+			fmt.Fprintf(&d, "%s %s", svgNum(p.X), svgNum(p.Y))
+		}
+		fmt.Fprintf(b, `<path d="%s" fill="none"%s stroke-width="%s" stroke-linecap="round" stroke-linejoin="round"/>`+"\n",
+			d.String(), svgPaint("stroke", a.LineColor, ColorBlack), svgNum(svgAnnotationSize*t.Scale))
+	}
+}
+
+// drawConnector draws a connector between the edges of its end widgets, with a triangle at
+// each end whose tip is not "none".
+func (sc *svgScene) drawConnector(b *strings.Builder, c Connector, region Rectangle) {
+	if c.Src == nil || c.Dst == nil {
+		return
+	}
+	sw, ok1 := sc.geom.Widget(c.Src.ID)
+	dw, ok2 := sc.geom.Widget(c.Dst.ID)
+	if !ok1 || !ok2 || !svgDrawable(sw) || !svgDrawable(dw) {
+		return
+	}
+	sr, dr := sc.geom.CanvasRect(sw), sc.geom.CanvasRect(dw)
+	a := svgRectExit(sr, svgCenter(dr))
+	z := svgRectExit(dr, svgCenter(sr))
+	box := Rectangle{X: math.Min(a.X, z.X), Y: math.Min(a.Y, z.Y), Width: math.Abs(a.X - z.X), Height: math.Abs(a.Y - z.Y)}
+	if !Touches(box, region) {
+		return
+	}
+	lw := float64(c.LineWidth)
+	if lw <= 0 {
+		lw = svgConnectorWidth
+	}
+	color, err := NormalizeColor(c.LineColor)
+	if err != nil {
+		color = ColorBlack
+	}
+	fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s stroke-width="%s"/>`+"\n",
+		svgNum(a.X), svgNum(a.Y), svgNum(z.X), svgNum(z.Y), svgPaint("stroke", color, ColorBlack), svgNum(lw))
+	for _, end := range []struct {
+		tip      string
+		at, from Point
+	}{{c.Src.Tip, a, z}, {c.Dst.Tip, z, a}} {
+		if end.tip == "" || end.tip == tipNone {
+			continue
+		}
+		dx, dy := end.at.X-end.from.X, end.at.Y-end.from.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			continue
+		}
+		ux, uy := dx/l, dy/l
+		size := 4*lw + 6
+		bx, by := end.at.X-ux*size, end.at.Y-uy*size
+		fmt.Fprintf(b, `<polygon points="%s,%s %s,%s %s,%s"%s/>`+"\n",
+			svgNum(end.at.X), svgNum(end.at.Y),
+			svgNum(bx-uy*size/2), svgNum(by+ux*size/2),
+			svgNum(bx+uy*size/2), svgNum(by-ux*size/2),
+			svgPaint("fill", color, ColorBlack))
+	}
+}
+
+func svgCenter(r Rectangle) Point {
+	return Point{X: r.X + r.Width/2, Y: r.Y + r.Height/2}
+}
+
+// svgRectExit returns where the segment from r's centre towards p leaves r, or p if it is
+// inside r.
+func svgRectExit(r Rectangle, p Point) Point {
+	c := svgCenter(r)
+	dx, dy := p.X-c.X, p.Y-c.Y
+	t := 1.0
+	if dx != 0 {
+		t = math.Min(t, r.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		t = math.Min(t, r.Height/2/math.Abs(dy))
+	}
+	return Point{X: c.X + dx*t, Y: c.Y + dy*t}
+}
+
+// svgText writes text wrapped to r (by an estimated glyph width), dropping lines that do not
+// fit. Centred text is centred both ways; otherwise it starts at the top left.
+func svgText(b *strings.Builder, r Rectangle, text string, fs float64, color string, center bool) {
+	if strings.TrimSpace(text) == "" || fs <= 0 {
+		return
+	}
+	pad := fs / 2
+	step := fs * svgLineHeight
+	maxLines := int((r.Height - 2*pad) / step)
+	if maxLines < 1 {
+		return
+	}
+	lines := svgWrap(text, int((r.Width-2*pad)/(fs*0.55)))
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = strings.TrimRight(lines[maxLines-1], " ") + "…"
+	}
+	x, y, anchor := r.X+pad, r.Y+pad+fs, ""
+	if center {
+		x, anchor = r.X+r.Width/2, ` text-anchor="middle"`
+		y = r.Y + (r.Height-float64(len(lines))*step)/2 + fs
+	}
+	fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s"%s%s>`, svgNum(x), svgNum(y), svgNum(fs), anchor, svgPaint("fill", color, ColorBlack))
+	for i, l := range lines {
+		dy := "0"
+		if i > 0 {
+			dy = svgNum(step)
+		}
+		fmt.Fprintf(b, `<tspan x="%s" dy="%s">%s</tspan>`, svgNum(x), dy, xmlEscape(l))
+	}
+	b.WriteString("</text>\n")
+}
+
+// svgWrap breaks text into lines of at most width runes, at spaces where possible.
+func svgWrap(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var out []string
+	for _, para := range strings.Split(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Fields(para) {
+			rw := []rune(word)
+			if len(line) > 0 && len(line)+1+len(rw) > width {
+				out = append(out, string(line))
+				line = line[:0]
+			}
+			for len(rw) > width {
+				if len(line) > 0 {
+					out = append(out, string(line))
+					line = line[:0]
+				}
+				out = append(out, string(rw[:width]))
+				rw = rw[width:]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, rw...)
+		}
+		out = append(out, string(line))
+	}
+	return out
+}
+
+// svgTextColor picks black or white text for a background colour.
+func svgTextColor(bg string) string {
+	r, g, bl, a, err := ColorToRGBA(bg)
+	if err != nil || a < 128 || 0.299*float64(r)+0.587*float64(g)+0.114*float64(bl) >= 128 {
+		return ColorBlack
+	}
+	return ColorWhite
+}
+
+// svgPaint returns a fill or stroke attribute, with its opacity, for an RRGGBBAA colour.
+func svgPaint(attr, color, fallback string) string {
+	c, err := NormalizeColor(color)
+	if err != nil {
+		c = fallback
+	}
+	s := fmt.Sprintf(` %s="#%s"`, attr, strings.ToLower(c[:6]))
+	if a, err := strconv.ParseUint(c[6:], 16, 8); err == nil && a < 255 {
+		s += fmt.Sprintf(` %s-opacity="%s"`, attr, svgNum(float64(a)/255))
+	}
+	return s
+}
+
+func svgRectAttrs(r Rectangle) string {
+	return fmt.Sprintf(`x="%s" y="%s" width="%s" height="%s"`, svgNum(r.X), svgNum(r.Y), svgNum(r.Width), svgNum(r.Height))
+}
+
+// svgNum formats a coordinate with at most two decimals.
+func svgNum(v float64) string {
+	v = math.Round(v*100) / 100
+	if v == 0 {
+		v = 0 // no "-0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,103 @@
+package canvus
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func encodeAnnotationPoints(pts ...Point) string {
+	raw := make([]byte, 0, 8*len(pts))
+	for _, p := range pts {
+		raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(float32(p.X)))
+		raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(float32(p.Y)))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestDecodeAnnotationPoints(t *testing.T) {
+	pts, err := DecodeAnnotationPoints(encodeAnnotationPoints(Point{X: 1.5, Y: -2}, Point{X: 300, Y: 40}))
+	if err != nil || len(pts) != 2 || pts[0] != (Point{X: 1.5, Y: -2}) || pts[1] != (Point{X: 300, Y: 40}) {
+		t.Errorf("points = %v, %v", pts, err)
+	}
+	if _, err := DecodeAnnotationPoints(base64.StdEncoding.EncodeToString([]byte{1, 2, 3})); err == nil {
+		t.Error("expected error for a partial point")
+	}
+}
+
+func TestRenderCanvasSVG(t *testing.T) {
+	fake := newFakeCanvus(t)
+	loc := func(x, y float64) map[string]interface{} { return map[string]interface{}{"x": x, "y": y} }
+	size := func(w, h float64) map[string]interface{} { return map[string]interface{}{"width": w, "height": h} }
+	fake.seed("canvases/c1/notes", map[string]interface{}{"id": "n1", "text": "Ship <it> & celebrate", "background_color": "#202020",
+		"location": loc(0, 0), "size": size(400, 200), "scale": 1,
+		"annotations": []interface{}{map[string]interface{}{"id": "a1", "line_color": "FF000080",
+			"points": encodeAnnotationPoints(Point{X: 10, Y: 10}, Point{X: 50, Y: 50})}}})
+	fake.seed("canvases/c1/images", map[string]interface{}{"id": "i1", "hash": "abc", "original_filename": "chart.png",
+		"location": loc(600, 0), "size": size(400, 400), "scale": 1})
+	fake.seed("canvases/c1/browsers", map[string]interface{}{"id": "b1", "url": "https://example.com",
+		"location": loc(5000, 5000), "size": size(800, 600), "scale": 1})
+	fake.seed("canvases/c1/connectors", map[string]interface{}{"id": "k1", "line_width": 2,
+		"src": map[string]interface{}{"id": "n1", "tip": tipNone},
+		"dst": map[string]interface{}{"id": "i1", "tip": tipArrow}})
+	fake.handle("GET", "api/v1/mipmaps/abc", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"resolution": map[string]int{"width": 4096, "height": 4096}, "max_level": 5})
+	})
+	var level string
+	fake.handle("GET", "api/v1/mipmaps/abc/3", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		level = "3"
+		_, _ = w.Write([]byte("webp"))
+	})
+	s := fake.session()
+	ctx := context.Background()
+
+	// Region covers the note and image at 1 px per unit, so the image needs a 400 px level.
+	svg, err := s.RenderCanvasSVG(ctx, "c1", SVGOptions{Region: &Rectangle{X: -100, Y: -100, Width: 1200, Height: 600}, Width: 1200})
+	if err != nil {
+		t.Fatalf("RenderCanvasSVG: %v", err)
+	}
+	out := string(svg)
+	dec := xml.NewDecoder(strings.NewReader(out))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid XML: %v\n%s", err, out)
+		}
+	}
+	for _, want := range []string{
+		`width="1200" height="600" viewBox="-100 -100 1200 600"`,
+		`<rect x="0" y="0" width="400" height="200" fill="#202020"/>`,
+		`fill="#ffffff"><tspan x="12" dy="0">Ship &lt;it&gt; &amp; celebrate</tspan>`,
+		`<path d="M10 10L50 50" fill="none" stroke="#ff0000" stroke-opacity="0.5"`,
+		`href="data:image/webp;base64,` + base64.StdEncoding.EncodeToString([]byte("webp")) + `"`,
+		`<line x1="400" y1="133.33" x2="600" y2="166.67"`,
+		`<polygon points="600,166.67 `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+	if level != "3" {
+		t.Errorf("mipmap level not fetched; calls %v", fake.calls)
+	}
+	if strings.Contains(out, "example.com") {
+		t.Error("drew a browser outside the region")
+	}
+
+	// Without a region everything is framed; without thumbnails assets are placeholders.
+	svg, err = s.RenderCanvasSVG(ctx, "c1", SVGOptions{NoThumbnails: true, Width: 580})
+	if err != nil {
+		t.Fatalf("RenderCanvasSVG: %v", err)
+	}
+	out = string(svg)
+	if !strings.Contains(out, `viewBox="-50 -50 5900 5700"`) || !strings.Contains(out, ">chart.png</tspan>") || !strings.Contains(out, "https://example.com") {
+		t.Errorf("full render =\n%s", out)
+	}
+}
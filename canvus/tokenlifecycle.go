@@ -0,0 +1,249 @@
+package canvus
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TokenFinding is a reason an access token was flagged by an AccessTokenManager.
+type TokenFinding string
+
+const (
+	TokenStale          TokenFinding = "stale"           // older than TokenPolicy.MaxAge
+	TokenOverPrivileged TokenFinding = "over_privileged" // owned by an admin, carrying full server rights
+	TokenOrphaned       TokenFinding = "orphaned"        // owned by a blocked user
+)
+
+// TokenPolicy decides which tokens an AccessTokenManager flags.
+type TokenPolicy struct {
+	// MaxAge flags tokens created longer ago than this. Zero disables the check.
+	MaxAge time.Duration
+	// FlagAdminTokens flags tokens owned by admin users: a token carries all of its owner's rights.
+	FlagAdminTokens bool
+	// AllowedAdmins are admin user IDs whose tokens are expected (e.g. service accounts).
+	AllowedAdmins []int64
+}
+
+// TokenInventoryEntry is one access token with its owner and policy findings.
+type TokenInventoryEntry struct {
+	Owner    User
+	Token    AccessToken
+	Created  time.Time     // zero if created_at could not be parsed
+	Age      time.Duration // zero if Created is unknown
+	Findings []TokenFinding
+}
+
+// Flagged reports whether the entry has any findings.
+func (e TokenInventoryEntry) Flagged() bool {
+	return len(e.Findings) > 0
+}
+
+// TokenInventory lists access tokens across all users.
+type TokenInventory struct {
+	GeneratedAt time.Time
+	Entries     []TokenInventoryEntry
+	// Errors holds users whose tokens could not be listed, keyed by user ID.
+	Errors map[int64]error
+}
+
+// Flagged returns the entries with at least one finding.
+func (inv *TokenInventory) Flagged() []TokenInventoryEntry {
+	var out []TokenInventoryEntry
+	for _, e := range inv.Entries {
+		if e.Flagged() {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// WriteCSV writes the inventory with one row per token.
+func (inv *TokenInventory) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"user_id", "user_email", "admin", "token_id", "description", "created_at", "age_days", "findings"})
+	for _, e := range inv.Entries {
+		age := ""
+		if !e.Created.IsZero() {
+			age = strconv.Itoa(int(e.Age.Hours() / 24))
+		}
+		findings := make([]string, len(e.Findings))
+		for i, f := range e.Findings {
+			findings[i] = string(f)
+		}
+		_ = cw.Write([]string{
+			strconv.FormatInt(e.Owner.ID, 10), e.Owner.Email, strconv.FormatBool(e.Owner.Admin),
+			e.Token.ID, e.Token.Description, e.Token.CreatedAt, age, strings.Join(findings, ";"),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// TokenSink receives a newly created token during rotation, before the old token is revoked,
+// e.g. to write it to a secret store. Returning an error aborts the rotation.
+type TokenSink func(ctx context.Context, userID int64, token *AccessToken) error
+
+// RotateOptions controls AccessTokenManager.Rotate.
+type RotateOptions struct {
+	// Description for the new token; defaults to the old token's description.
+	Description string
+	// Sink is handed the new token (with PlainToken) before the old one is revoked.
+	Sink TokenSink
+	// UseInSession switches the manager's Session (authenticator, API key transport and
+	// TokenStore) to the new token before the old one is revoked. Set it when rotating the
+	// token the session itself authenticates with.
+	UseInSession bool
+}
+
+// AccessTokenManager inventories access tokens against a TokenPolicy and rotates them.
+type AccessTokenManager struct {
+	session *Session
+	policy  TokenPolicy
+	now     func() time.Time
+}
+
+// NewAccessTokenManager creates a manager using the given session and policy.
+func NewAccessTokenManager(session *Session, policy TokenPolicy) *AccessTokenManager {
+	return &AccessTokenManager{session: session, policy: policy, now: time.Now}
+}
+
+// Inventory lists the tokens of every user and applies the policy. Users whose tokens cannot be
+// listed are recorded in Errors rather than failing the whole inventory.
+func (m *AccessTokenManager) Inventory(ctx context.Context) (*TokenInventory, error) {
+	users, err := m.session.ListUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Inventory: %w", err)
+	}
+	inv := &TokenInventory{GeneratedAt: m.now().UTC(), Errors: map[int64]error{}}
+	for _, u := range users {
+		tokens, err := m.session.ListAccessTokens(ctx, u.ID, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("Inventory: %w", ctx.Err())
+			}
+			inv.Errors[u.ID] = err
+			continue
+		}
+		for _, t := range tokens {
+			inv.Entries = append(inv.Entries, m.evaluate(u, t))
+		}
+	}
+	sort.SliceStable(inv.Entries, func(i, j int) bool {
+		if inv.Entries[i].Owner.ID != inv.Entries[j].Owner.ID {
+			return inv.Entries[i].Owner.ID < inv.Entries[j].Owner.ID
+		}
+		return inv.Entries[i].Token.CreatedAt < inv.Entries[j].Token.CreatedAt
+	})
+	return inv, nil
+}
+
+func (m *AccessTokenManager) evaluate(owner User, token AccessToken) TokenInventoryEntry {
+	e := TokenInventoryEntry{Owner: owner, Token: token}
+	if t, err := time.Parse(time.RFC3339, token.CreatedAt); err == nil {
+		e.Created = t
+		e.Age = m.now().Sub(t)
+	}
+	if m.policy.MaxAge > 0 && !e.Created.IsZero() && e.Age > m.policy.MaxAge {
+		e.Findings = append(e.Findings, TokenStale)
+	}
+	if m.policy.FlagAdminTokens && owner.Admin && !containsInt64(m.policy.AllowedAdmins, owner.ID) {
+		e.Findings = append(e.Findings, TokenOverPrivileged)
+	}
+	if owner.Blocked {
+		e.Findings = append(e.Findings, TokenOrphaned)
+	}
+	return e
+}
+
+func containsInt64(list []int64, v int64) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Rotate replaces a token: it creates the new token, hands it to opts.Sink, optionally switches
+// the session to it, and only then revokes the old token. If the sink fails the new token is
+// revoked and the old one is kept; if switching the session fails, the session is switched back.
+//
+// Usage Example:
+//
+//	mgr := canvus.NewAccessTokenManager(session, canvus.TokenPolicy{MaxAge: 24 * time.Hour})
+//	newTok, err := mgr.Rotate(ctx, serviceUserID, oldTokenID, &canvus.RotateOptions{
+//		Sink:         func(ctx context.Context, uid int64, t *canvus.AccessToken) error { return vault.Put(t.PlainToken) },
+//		UseInSession: true,
+//	})
+func (m *AccessTokenManager) Rotate(ctx context.Context, userID int64, tokenID string, opts *RotateOptions) (*AccessToken, error) {
+	if opts == nil {
+		opts = &RotateOptions{}
+	}
+	desc := opts.Description
+	if desc == "" {
+		old, err := m.session.GetAccessToken(ctx, userID, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("Rotate: %w", err)
+		}
+		desc = old.Description
+	}
+	created, err := m.session.CreateAccessToken(ctx, userID, CreateAccessTokenRequest{Description: desc})
+	if err != nil {
+		return nil, fmt.Errorf("Rotate: %w", err)
+	}
+	if created.PlainToken == "" {
+		_ = m.session.DeleteAccessToken(ctx, userID, created.ID)
+		return nil, fmt.Errorf("Rotate: server did not return the new token")
+	}
+	if opts.Sink != nil {
+		if err := opts.Sink(ctx, userID, created); err != nil {
+			_ = m.session.DeleteAccessToken(ctx, userID, created.ID)
+			return nil, fmt.Errorf("Rotate: sink: %w", err)
+		}
+	}
+	if opts.UseInSession {
+		previous := m.session.currentToken()
+		m.session.SetToken(created.PlainToken, time.Time{})
+		// Check the new token works before giving up the old one.
+		if _, err := m.session.GetAccessToken(ctx, userID, created.ID); err != nil {
+			m.session.SetToken(previous, time.Time{})
+			_ = m.session.DeleteAccessToken(ctx, userID, created.ID)
+			return nil, fmt.Errorf("Rotate: new token rejected: %w", err)
+		}
+	}
+	if err := m.session.DeleteAccessToken(ctx, userID, tokenID); err != nil {
+		return created, fmt.Errorf("Rotate: new token %s is active but revoking %s failed: %w", created.ID, tokenID, err)
+	}
+	return created, nil
+}
+
+// KeepFresh rotates the session's own token every interval until ctx is cancelled, so service
+// accounts can run with short-lived tokens. tokenID is the ID of the token the session currently
+// uses; opts.UseInSession is implied. It returns ctx.Err() on cancellation or the first rotation error.
+func (m *AccessTokenManager) KeepFresh(ctx context.Context, userID int64, tokenID string, interval time.Duration, opts *RotateOptions) error {
+	o := RotateOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.UseInSession = true
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			created, err := m.Rotate(ctx, userID, tokenID, &o)
+			if err != nil {
+				return fmt.Errorf("KeepFresh: %w", err)
+			}
+			tokenID = created.ID
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package canvus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memTokenStore struct{ token string }
+
+func (m *memTokenStore) GetToken() (string, error)                  { return m.token, nil }
+func (m *memTokenStore) StoreToken(token string, _ time.Time) error { m.token = token; return nil }
+func (m *memTokenStore) ClearToken() error                          { m.token = ""; return nil }
+
+func TestAccessTokenInventory(t *testing.T) {
+	fake := newFakeCanvus(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	fake.seed("users", map[string]interface{}{"id": float64(1), "email": "admin@example.com", "admin": true})
+	fake.seed("users", map[string]interface{}{"id": float64(2), "email": "gone@example.com", "blocked": true})
+	fake.seed("users", map[string]interface{}{"id": float64(3), "email": "svc@example.com"})
+	fake.seed("users/1/access-tokens", map[string]interface{}{"id": "a", "description": "admin cli", "created_at": "2024-05-30T00:00:00Z"})
+	fake.seed("users/2/access-tokens", map[string]interface{}{"id": "b", "description": "old laptop", "created_at": "2024-05-30T00:00:00Z"})
+	fake.seed("users/3/access-tokens", map[string]interface{}{"id": "c", "description": "ci", "created_at": "2023-01-01T00:00:00Z"})
+	fake.seed("users/3/access-tokens", map[string]interface{}{"id": "d", "description": "ci new", "created_at": "2024-05-31T00:00:00Z"})
+
+	m := NewAccessTokenManager(fake.session(), TokenPolicy{MaxAge: 30 * 24 * time.Hour, FlagAdminTokens: true})
+	m.now = func() time.Time { return now }
+	inv, err := m.Inventory(context.Background())
+	if err != nil {
+		t.Fatalf("Inventory: %v", err)
+	}
+	got := map[string][]TokenFinding{}
+	for _, e := range inv.Flagged() {
+		got[e.Token.ID] = e.Findings
+	}
+	if len(got) != 3 || got["a"][0] != TokenOverPrivileged || got["b"][0] != TokenOrphaned || got["c"][0] != TokenStale {
+		t.Errorf("flagged = %v", got)
+	}
+	var buf bytes.Buffer
+	if err := inv.WriteCSV(&buf); err != nil || !strings.Contains(buf.String(), "3,svc@example.com,false,c,ci,2023-01-01T00:00:00Z,517,stale") {
+		t.Errorf("WriteCSV:\n%s", buf.String())
+	}
+}
+
+func TestAccessTokenRotate(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.seed("users", map[string]interface{}{"id": float64(3), "email": "svc@example.com"})
+	fake.seed("users/3/access-tokens", map[string]interface{}{"id": "old", "description": "ci", "plain_token": "old-secret"})
+	var seen string
+	fake.handle("GET", "users/3", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		seen = r.Header.Get("Private-Token")
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"id": 3})
+	})
+
+	store := &memTokenStore{token: "old-secret"}
+	cfg := DefaultSessionConfig()
+	cfg.BaseURL = fake.server.URL
+	cfg.MaxRetries = 0
+	cfg.HTTPClient = &http.Client{}
+	cfg.TokenStore = store
+	s := NewSession(cfg)
+	ctx := context.Background()
+	m := NewAccessTokenManager(s, TokenPolicy{})
+
+	sinkErr := errors.New("vault down")
+	_, err := m.Rotate(ctx, 3, "old", &RotateOptions{
+		Sink:         func(context.Context, int64, *AccessToken) error { return sinkErr },
+		UseInSession: true,
+	})
+	if !errors.Is(err, sinkErr) || len(fake.items("users/3/access-tokens")) != 1 || s.currentToken() != "old-secret" {
+		t.Fatalf("failed sink must keep the old token only: %v %v", err, fake.items("users/3/access-tokens"))
+	}
+
+	var sunk *AccessToken
+	created, err := m.Rotate(ctx, 3, "old", &RotateOptions{
+		Sink:         func(_ context.Context, _ int64, tok *AccessToken) error { sunk = tok; return nil },
+		UseInSession: true,
+	})
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	tokens := fake.items("users/3/access-tokens")
+	if len(tokens) != 1 || tokens[0]["id"] != created.ID || tokens[0]["description"] != "ci" || sunk != created {
+		t.Fatalf("after rotation tokens = %v", tokens)
+	}
+	if store.token != created.PlainToken {
+		t.Errorf("TokenStore = %q, want %q", store.token, created.PlainToken)
+	}
+	if _, err := s.GetUser(ctx, 3); err != nil || seen != created.PlainToken {
+		t.Errorf("session sent %q, want the rotated token %q", seen, created.PlainToken)
+	}
+}
+
+func TestSessionAuthenticatorConcurrentSwitches(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle(http.MethodPost, "users/login", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"token": "login-token", "user": map[string]interface{}{"id": 1}})
+	})
+	fake.handle(http.MethodPost, "users/logout", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{})
+	})
+	s := fake.session()
+	ctx := context.Background()
+
+	// Run with -race: switching tokens must not race with requests reading the authenticator.
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 20; j++ {
+				switch i {
+				case 0:
+					s.SetToken("set-token", time.Time{})
+				case 1:
+					_ = s.Login(ctx, "a@example.com", "pw")
+				case 2:
+					_ = s.Logout(ctx)
+				default:
+					_, _ = s.ListUsers(ctx, nil)
+					_ = s.refreshAuthToken(ctx)
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	s.SetToken("final", time.Time{})
+	if got := s.currentToken(); got != "final" {
+		t.Errorf("currentToken() = %q, want final", got)
+	}
+}
@@ -0,0 +1,194 @@
+package canvus
+
+// Transform maps points from a widget's local coordinate space to canvas coordinates:
+// canvas = Offset + Scale*local. Use IdentityTransform rather than the zero value.
+type Transform struct {
+	Offset Point
+	Scale  float64
+}
+
+// IdentityTransform returns the transform of canvas space itself.
+func IdentityTransform() Transform {
+	return Transform{Scale: 1}
+}
+
+// ToCanvas converts a local point to canvas coordinates.
+func (t Transform) ToCanvas(p Point) Point {
+	return Point{X: t.Offset.X + t.Scale*p.X, Y: t.Offset.Y + t.Scale*p.Y}
+}
+
+// ToLocal converts a canvas point to local coordinates.
+func (t Transform) ToLocal(p Point) Point {
+	return Point{X: (p.X - t.Offset.X) / t.Scale, Y: (p.Y - t.Offset.Y) / t.Scale}
+}
+
+// RectToCanvas converts a local rectangle to canvas coordinates.
+func (t Transform) RectToCanvas(r Rectangle) Rectangle {
+	o := t.ToCanvas(Point{X: r.X, Y: r.Y})
+	return Rectangle{X: o.X, Y: o.Y, Width: r.Width * t.Scale, Height: r.Height * t.Scale}
+}
+
+// RectToLocal converts a canvas rectangle to local coordinates.
+func (t Transform) RectToLocal(r Rectangle) Rectangle {
+	o := t.ToLocal(Point{X: r.X, Y: r.Y})
+	return Rectangle{X: o.X, Y: o.Y, Width: r.Width / t.Scale, Height: r.Height / t.Scale}
+}
+
+// Compose returns the transform that applies local first and then t, i.e. the transform of a
+// space nested inside t's space.
+func (t Transform) Compose(local Transform) Transform {
+	return Transform{Offset: t.ToCanvas(local.Offset), Scale: t.Scale * local.Scale}
+}
+
+// widgetScale returns w.Scale, treating an unset scale as 1.
+func widgetScale(w Widget) float64 {
+	if w.Scale <= 0 {
+		return 1
+	}
+	return w.Scale
+}
+
+// CanvasGeometry resolves widget positions through the parent chain. A widget's Location is in
+// its parent's local space and its Size is scaled by its own Scale and every ancestor's; children
+// of the SharedCanvas, or of a parent that is not in the set, are in canvas space.
+// A CanvasGeometry is not safe for concurrent modification.
+//
+// Usage Example:
+//
+//	widgets, _ := session.ListWidgets(ctx, canvasID, nil)
+//	g := canvus.NewCanvasGeometry(widgets)
+//	rect := g.CanvasRect(note)
+//	local := g.ToLocal(frameID, canvus.Point{X: 1200, Y: 800}) // location for a new child of frameID
+type CanvasGeometry struct {
+	widgets  map[string]Widget
+	children map[string]map[string]bool
+}
+
+// NewCanvasGeometry builds a CanvasGeometry from a widget list (typically from ListWidgets).
+func NewCanvasGeometry(widgets []Widget) *CanvasGeometry {
+	g := &CanvasGeometry{
+		widgets:  make(map[string]Widget, len(widgets)),
+		children: make(map[string]map[string]bool),
+	}
+	for _, w := range widgets {
+		g.Set(w)
+	}
+	return g
+}
+
+// Set adds or replaces a widget.
+func (g *CanvasGeometry) Set(w Widget) {
+	g.Remove(w.ID)
+	g.widgets[w.ID] = w
+	if w.ParentID != "" {
+		if g.children[w.ParentID] == nil {
+			g.children[w.ParentID] = make(map[string]bool)
+		}
+		g.children[w.ParentID][w.ID] = true
+	}
+}
+
+// Remove drops a widget. Its children are treated as canvas-level until it is set again.
+func (g *CanvasGeometry) Remove(id string) {
+	old, ok := g.widgets[id]
+	if !ok {
+		return
+	}
+	delete(g.widgets, id)
+	if kids := g.children[old.ParentID]; kids != nil {
+		delete(kids, id)
+		if len(kids) == 0 {
+			delete(g.children, old.ParentID)
+		}
+	}
+}
+
+// Widget returns a widget by ID.
+func (g *CanvasGeometry) Widget(id string) (Widget, bool) {
+	w, ok := g.widgets[id]
+	return w, ok
+}
+
+// ParentTransform returns the transform of the space w.Location is expressed in.
+func (g *CanvasGeometry) ParentTransform(w Widget) Transform {
+	var chain []Widget
+	seen := map[string]bool{w.ID: true}
+	for id := w.ParentID; id != "" && !seen[id]; {
+		p, ok := g.widgets[id]
+		if !ok || p.WidgetType == "SharedCanvas" {
+			break
+		}
+		seen[id] = true
+		chain = append(chain, p)
+		id = p.ParentID
+	}
+	t := IdentityTransform()
+	for i := len(chain) - 1; i >= 0; i-- {
+		t = t.Compose(localTransform(chain[i]))
+	}
+	return t
+}
+
+// Transform returns the transform of the widget's own local space, the space its children's
+// locations are expressed in. Unknown IDs and the SharedCanvas resolve to IdentityTransform.
+func (g *CanvasGeometry) Transform(id string) Transform {
+	w, ok := g.widgets[id]
+	if !ok || w.WidgetType == "SharedCanvas" {
+		return IdentityTransform()
+	}
+	return g.ParentTransform(w).Compose(localTransform(w))
+}
+
+func localTransform(w Widget) Transform {
+	t := Transform{Scale: widgetScale(w)}
+	if w.Location != nil {
+		t.Offset = *w.Location
+	}
+	return t
+}
+
+// CanvasRect returns the widget's bounding box in canvas coordinates. w need not be in the set;
+// its ParentID is resolved against the widgets that are.
+func (g *CanvasGeometry) CanvasRect(w Widget) Rectangle {
+	return g.ParentTransform(w).RectToCanvas(WidgetBoundingBox(w))
+}
+
+// ToCanvas converts a point in the local space of widget id to canvas coordinates.
+func (g *CanvasGeometry) ToCanvas(id string, p Point) Point {
+	return g.Transform(id).ToCanvas(p)
+}
+
+// ToLocal converts a canvas point to the local space of widget id, e.g. to get the location of
+// a new child of that widget.
+func (g *CanvasGeometry) ToLocal(id string, p Point) Point {
+	return g.Transform(id).ToLocal(p)
+}
+
+// Contains reports whether widget a fully contains widget b in canvas space.
+func (g *CanvasGeometry) Contains(a, b Widget) bool {
+	return Contains(g.CanvasRect(a), g.CanvasRect(b))
+}
+
+// Touches reports whether widgets a and b overlap or touch in canvas space.
+func (g *CanvasGeometry) Touches(a, b Widget) bool {
+	return Touches(g.CanvasRect(a), g.CanvasRect(b))
+}
+
+// descendants returns the IDs of all widgets below id, parents before children.
+func (g *CanvasGeometry) descendants(id string) []string {
+	var out []string
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for kid := range g.children[cur] {
+			if !seen[kid] {
+				seen[kid] = true
+				out = append(out, kid)
+				queue = append(queue, kid)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCanvasGeometryResolvesParentChain(t *testing.T) {
+	sc := Widget{ID: "sc", WidgetType: "SharedCanvas", Location: &Point{}, Size: &Size{Width: 1000, Height: 1000}}
+	frame := Widget{ID: "frame", WidgetType: "Note", ParentID: "sc", Location: &Point{X: 100, Y: 200}, Size: &Size{Width: 400, Height: 400}, Scale: 2}
+	inner := Widget{ID: "inner", WidgetType: "Note", ParentID: "frame", Location: &Point{X: 10, Y: 20}, Size: &Size{Width: 50, Height: 50}, Scale: 0.5}
+	leaf := Widget{ID: "leaf", WidgetType: "Note", ParentID: "inner", Location: &Point{X: 4, Y: 4}, Size: &Size{Width: 10, Height: 10}}
+	g := NewCanvasGeometry([]Widget{sc, frame, inner, leaf})
+
+	if r := g.CanvasRect(frame); r != (Rectangle{X: 100, Y: 200, Width: 800, Height: 800}) {
+		t.Errorf("frame = %+v", r)
+	}
+	if r := g.CanvasRect(inner); r != (Rectangle{X: 120, Y: 240, Width: 50, Height: 50}) {
+		t.Errorf("inner = %+v", r)
+	}
+	// inner's local space: origin (120,240), scale 2*0.5 = 1.
+	if r := g.CanvasRect(leaf); r != (Rectangle{X: 124, Y: 244, Width: 10, Height: 10}) {
+		t.Errorf("leaf = %+v", r)
+	}
+	p := g.ToCanvas("frame", Point{X: 10, Y: 20})
+	if p != (Point{X: 120, Y: 240}) || g.ToLocal("frame", p) != (Point{X: 10, Y: 20}) {
+		t.Errorf("frame round trip = %+v", p)
+	}
+	if !g.Contains(frame, leaf) || g.Contains(inner, frame) || !g.Touches(inner, leaf) {
+		t.Error("canvas-space Contains/Touches disagree with the hierarchy")
+	}
+	if WidgetBoundingBox(frame) != (Rectangle{X: 100, Y: 200, Width: 800, Height: 800}) {
+		t.Errorf("WidgetBoundingBox ignores Scale: %+v", WidgetBoundingBox(frame))
+	}
+
+	// A parent cycle must not loop forever.
+	a := Widget{ID: "a", ParentID: "b", Location: &Point{X: 1}, Size: &Size{Width: 1, Height: 1}}
+	b := Widget{ID: "b", ParentID: "a", Location: &Point{X: 2}, Size: &Size{Width: 1, Height: 1}}
+	if r := NewCanvasGeometry([]Widget{a, b}).CanvasRect(a); r.X != 3 {
+		t.Errorf("cycle rect = %+v", r)
+	}
+}
+
+func TestWidgetsContainIdUsesCanvasSpace(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle("GET", "canvases/c1/widgets", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": "sc", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0, "y": 0}, "size": map[string]interface{}{"width": 5000, "height": 5000}},
+			{"id": "box", "widget_type": "Note", "parent_id": "sc", "location": map[string]interface{}{"x": 1000, "y": 1000}, "size": map[string]interface{}{"width": 100, "height": 100}, "scale": 4},
+			// Local (10,10) inside box is canvas (1040,1040); the bounding box test alone would miss it.
+			{"id": "child", "widget_type": "Note", "parent_id": "box", "location": map[string]interface{}{"x": 10, "y": 10}, "size": map[string]interface{}{"width": 50, "height": 50}},
+			// Fits inside box's unscaled 100x100 only while its own scale is ignored.
+			{"id": "big", "widget_type": "Note", "parent_id": "sc", "location": map[string]interface{}{"x": 1050, "y": 1050}, "size": map[string]interface{}{"width": 40, "height": 40}, "scale": 10},
+		})
+	})
+	box := Widget{ID: "box", ParentID: "sc", Location: &Point{X: 1000, Y: 1000}, Size: &Size{Width: 100, Height: 100}, Scale: 4}
+
+	zone, err := WidgetsContainId(context.Background(), fake.session(), "c1", "", &box, 0)
+	if err != nil {
+		t.Fatalf("WidgetsContainId: %v", err)
+	}
+	if len(zone.Contents) != 1 || zone.Contents[0].ID != "child" {
+		t.Errorf("Contents = %+v", zone.Contents)
+	}
+}
@@ -0,0 +1,388 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrashService manages per-user trash folders. Every user has a home folder whose ID is the
+// user ID (e.g. "1034") and a trash folder "trash.<user ID>". The service derives the owner of
+// a canvas or folder from its folder chain, so it also works for admin API-key sessions that
+// have no logged-in user.
+//
+// The API does not report when an item was trashed, so the service records the time of every
+// TrashCanvas and TrashFolder call; SaveTrashTimes and LoadTrashTimes keep these records across
+// runs. Items trashed any other way have an unknown trash time.
+type TrashService struct {
+	session *Session
+	now     func() time.Time
+
+	mu        sync.Mutex
+	trashedAt map[string]time.Time // canvas or folder ID -> when the service trashed it
+}
+
+// TrashedItem is a canvas or folder sitting in a user's trash.
+type TrashedItem struct {
+	Kind      string    // "canvas" or "folder"
+	ID        string    // canvas or folder ID
+	Name      string    // canvas or folder name
+	OwnerID   int64     // user whose trash holds the item
+	FolderID  string    // direct parent (the trash folder, or a trashed folder for nested items)
+	TrashedAt time.Time // when the service trashed the item; zero if unknown
+}
+
+// TrashContents lists the top-level items in one or more trash folders.
+type TrashContents struct {
+	Canvases []TrashedItem
+	Folders  []TrashedItem
+}
+
+// Items returns folders followed by canvases.
+func (c *TrashContents) Items() []TrashedItem {
+	return append(append([]TrashedItem(nil), c.Folders...), c.Canvases...)
+}
+
+// RetentionPolicy selects trashed items to purge.
+type RetentionPolicy struct {
+	MaxAge        time.Duration // purge items trashed longer ago than this
+	UserID        int64         // restrict to one user's trash; 0 means all users
+	PurgeUnknowns bool          // also purge items with no known trash time (trashed outside the service)
+	DryRun        bool          // report what would be purged without deleting
+}
+
+// RetentionReport is the result of ApplyRetention.
+type RetentionReport struct {
+	Purged  []TrashedItem // deleted (or, in a dry run, would be deleted)
+	Kept    []TrashedItem // too recent, or unknown age without PurgeUnknowns
+	Errors  map[string]error
+	DryRun  bool
+	Cutoff  time.Time
+	Elapsed time.Duration
+}
+
+// NewTrashService creates a TrashService for the given session.
+func NewTrashService(session *Session) *TrashService {
+	return &TrashService{session: session, now: time.Now, trashedAt: map[string]time.Time{}}
+}
+
+// TrashFolderID returns the ID of a user's trash folder.
+func TrashFolderID(userID int64) string {
+	return fmt.Sprintf("trash.%d", userID)
+}
+
+// trashOwner parses the user ID out of a "trash.<id>" folder ID.
+func trashOwner(folderID string) (int64, bool) {
+	if !isTrashFolderID(folderID) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(folderID, "trash."), 10, 64)
+	return id, err == nil
+}
+
+// folderIndex maps folder IDs to folders for walking parent chains.
+type folderIndex map[string]Folder
+
+func (s *TrashService) folders(ctx context.Context) (folderIndex, error) {
+	list, err := s.session.ListFolders(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(folderIndex, len(list))
+	for _, f := range list {
+		idx[f.ID] = f
+	}
+	return idx, nil
+}
+
+// ownerOf walks up from folderID to the user's home folder (a direct child of the root)
+// or trash folder, and returns the user ID it belongs to.
+func (idx folderIndex) ownerOf(folderID string) (int64, error) {
+	seen := map[string]bool{}
+	for id := folderID; id != ""; {
+		if owner, ok := trashOwner(id); ok {
+			return owner, nil
+		}
+		if seen[id] {
+			return 0, fmt.Errorf("folder cycle at %s", id)
+		}
+		seen[id] = true
+		f, ok := idx[id]
+		if !ok {
+			return 0, fmt.Errorf("folder %s not found", id)
+		}
+		if isTrashFolderID(f.ParentID) {
+			id = f.ParentID
+			continue
+		}
+		parent, hasParent := idx[f.ParentID]
+		if !hasParent || (parent.ParentID == "" && parent.Name == "") {
+			// f is a home folder: a child of the root, named after its user
+			owner, err := strconv.ParseInt(f.ID, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("folder %s is not a user home folder", f.ID)
+			}
+			return owner, nil
+		}
+		id = f.ParentID
+	}
+	return 0, fmt.Errorf("folder %s has no owner", folderID)
+}
+
+// OwnerOfCanvas returns the ID of the user whose home folder (or trash) contains the canvas.
+func (s *TrashService) OwnerOfCanvas(ctx context.Context, canvasID string) (int64, error) {
+	canvas, err := s.session.GetCanvas(ctx, canvasID)
+	if err != nil {
+		return 0, fmt.Errorf("OwnerOfCanvas: %w", err)
+	}
+	idx, err := s.folders(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("OwnerOfCanvas: %w", err)
+	}
+	owner, err := idx.ownerOf(canvas.FolderID)
+	if err != nil {
+		return 0, fmt.Errorf("OwnerOfCanvas: %w", err)
+	}
+	return owner, nil
+}
+
+// OwnerOfFolder returns the ID of the user whose home folder (or trash) contains the folder.
+func (s *TrashService) OwnerOfFolder(ctx context.Context, folderID string) (int64, error) {
+	idx, err := s.folders(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("OwnerOfFolder: %w", err)
+	}
+	owner, err := idx.ownerOf(folderID)
+	if err != nil {
+		return 0, fmt.Errorf("OwnerOfFolder: %w", err)
+	}
+	return owner, nil
+}
+
+// TrashCanvas moves a canvas into its owner's trash folder.
+func (s *TrashService) TrashCanvas(ctx context.Context, canvasID string) (*Canvas, error) {
+	owner, err := s.OwnerOfCanvas(ctx, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("TrashCanvas: %w", err)
+	}
+	canvas, err := s.session.TrashCanvas(ctx, canvasID, strconv.FormatInt(owner, 10))
+	if err != nil {
+		return nil, err
+	}
+	s.SetTrashedAt(canvasID, s.now())
+	return canvas, nil
+}
+
+// TrashFolder moves a folder into its owner's trash folder.
+func (s *TrashService) TrashFolder(ctx context.Context, folderID string) (*Folder, error) {
+	owner, err := s.OwnerOfFolder(ctx, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("TrashFolder: %w", err)
+	}
+	folder, err := s.session.TrashFolder(ctx, folderID, strconv.FormatInt(owner, 10))
+	if err != nil {
+		return nil, err
+	}
+	s.SetTrashedAt(folderID, s.now())
+	return folder, nil
+}
+
+// SetTrashedAt records when a canvas or folder was trashed, for items trashed outside the
+// service. A zero time forgets the record.
+func (s *TrashService) SetTrashedAt(id string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if at.IsZero() {
+		delete(s.trashedAt, id)
+		return
+	}
+	s.trashedAt[id] = at
+}
+
+func (s *TrashService) trashTime(id string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trashedAt[id]
+}
+
+// SaveTrashTimes writes the recorded trash times to a JSON file.
+func (s *TrashService) SaveTrashTimes(path string) error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.trashedAt, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("SaveTrashTimes: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("SaveTrashTimes: %w", err)
+	}
+	return nil
+}
+
+// LoadTrashTimes adds the trash times saved by SaveTrashTimes. A missing file is not an error.
+func (s *TrashService) LoadTrashTimes(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("LoadTrashTimes: %w", err)
+	}
+	var times map[string]time.Time
+	if err := json.Unmarshal(data, &times); err != nil {
+		return fmt.Errorf("LoadTrashTimes: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, at := range times {
+		s.trashedAt[id] = at
+	}
+	return nil
+}
+
+// List returns the top-level items in a user's trash. A userID of 0 lists the trash of all users.
+// Canvases count as trashed when InTrash is set, wherever they are filed. Items nested inside
+// trashed folders are not listed separately; they are restored or purged with their folder.
+func (s *TrashService) List(ctx context.Context, userID int64) (*TrashContents, error) {
+	idx, err := s.folders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	canvases, err := s.session.ListCanvases(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	contents := &TrashContents{}
+	for _, c := range canvases {
+		if !c.InTrash || (!isTrashFolderID(c.FolderID) && idx[c.FolderID].InTrash) {
+			continue // not trashed, or inside a trashed folder
+		}
+		owner, ok := trashOwner(c.FolderID)
+		if !ok {
+			owner, _ = idx.ownerOf(c.FolderID)
+		}
+		if userID != 0 && owner != userID {
+			continue
+		}
+		contents.Canvases = append(contents.Canvases, TrashedItem{
+			Kind: "canvas", ID: c.ID, Name: c.Name, OwnerID: owner, FolderID: c.FolderID, TrashedAt: s.trashTime(c.ID),
+		})
+	}
+	for _, f := range idx {
+		owner, ok := trashOwner(f.ParentID)
+		if !ok || isTrashFolderID(f.ID) || (userID != 0 && owner != userID) {
+			continue
+		}
+		contents.Folders = append(contents.Folders, TrashedItem{
+			Kind: "folder", ID: f.ID, Name: f.Name, OwnerID: owner, FolderID: f.ParentID, TrashedAt: s.trashTime(f.ID),
+		})
+	}
+	sortTrashed(contents.Canvases)
+	sortTrashed(contents.Folders)
+	return contents, nil
+}
+
+func sortTrashed(items []TrashedItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].OwnerID != items[j].OwnerID {
+			return items[i].OwnerID < items[j].OwnerID
+		}
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+// Restore moves a trashed item back out of the trash into folderID. If folderID is empty,
+// the item is restored into its owner's home folder.
+func (s *TrashService) Restore(ctx context.Context, item TrashedItem, folderID, conflicts string) error {
+	if folderID == "" {
+		folderID = strconv.FormatInt(item.OwnerID, 10)
+	}
+	var err error
+	switch item.Kind {
+	case "canvas":
+		_, err = s.session.MoveCanvas(ctx, item.ID, MoveOrCopyCanvasRequest{FolderID: folderID, Conflicts: conflicts})
+	case "folder":
+		_, err = s.session.MoveFolder(ctx, item.ID, folderID, conflicts)
+	default:
+		err = fmt.Errorf("unknown item kind %q", item.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	s.SetTrashedAt(item.ID, time.Time{})
+	return nil
+}
+
+// Empty permanently deletes everything in a user's trash folder and forgets the recorded trash
+// times of the purged items.
+func (s *TrashService) Empty(ctx context.Context, userID int64) error {
+	if userID == 0 {
+		return fmt.Errorf("Empty: user ID is required")
+	}
+	contents, err := s.List(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Empty: %w", err)
+	}
+	if err := s.session.DeleteFolderContents(ctx, TrashFolderID(userID)); err != nil {
+		return fmt.Errorf("Empty: %w", err)
+	}
+	for _, item := range append(contents.Canvases, contents.Folders...) {
+		s.SetTrashedAt(item.ID, time.Time{})
+	}
+	return nil
+}
+
+// ApplyRetention permanently deletes items trashed longer than policy.MaxAge ago, which must be
+// positive. Items with no recorded trash time are kept unless policy.PurgeUnknowns is set.
+// Individual delete failures are collected in the report instead of aborting the run.
+//
+// Usage Example:
+//
+//	trash := canvus.NewTrashService(session)
+//	report, err := trash.ApplyRetention(ctx, canvus.RetentionPolicy{MaxAge: 30 * 24 * time.Hour})
+func (s *TrashService) ApplyRetention(ctx context.Context, policy RetentionPolicy) (*RetentionReport, error) {
+	if policy.MaxAge <= 0 {
+		return nil, fmt.Errorf("ApplyRetention: MaxAge must be positive, got %v", policy.MaxAge)
+	}
+	start := s.now()
+	report := &RetentionReport{DryRun: policy.DryRun, Cutoff: start.Add(-policy.MaxAge), Errors: map[string]error{}}
+	contents, err := s.List(ctx, policy.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ApplyRetention: %w", err)
+	}
+	for _, item := range contents.Items() {
+		expired := !item.TrashedAt.IsZero() && item.TrashedAt.Before(report.Cutoff)
+		if item.TrashedAt.IsZero() && policy.PurgeUnknowns {
+			expired = true
+		}
+		if !expired {
+			report.Kept = append(report.Kept, item)
+			continue
+		}
+		if !policy.DryRun {
+			var err error
+			if item.Kind == "folder" {
+				err = s.session.DeleteFolder(ctx, item.ID)
+			} else {
+				err = s.session.DeleteCanvas(ctx, item.ID)
+			}
+			if err != nil {
+				report.Errors[item.ID] = err
+				continue
+			}
+			s.SetTrashedAt(item.ID, time.Time{})
+		}
+		report.Purged = append(report.Purged, item)
+	}
+	report.Elapsed = s.now().Sub(start)
+	return report, nil
+}
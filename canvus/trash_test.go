@@ -0,0 +1,152 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func seedTrash(f *fakeCanvus) {
+	f.seed("canvas-folders", map[string]interface{}{"id": "root", "name": "", "folder_id": ""})
+	f.seed("canvas-folders", map[string]interface{}{"id": "1000", "name": "alice", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "1001", "name": "bob", "folder_id": "root"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "proj", "name": "Project", "folder_id": "1001"})
+	f.seed("canvas-folders", map[string]interface{}{"id": "oldf", "name": "Old stuff", "folder_id": "trash.1000", "in_trash": true})
+	f.seed("canvases", map[string]interface{}{"id": "live", "name": "Live", "folder_id": "proj"})
+	f.seed("canvases", map[string]interface{}{"id": "old", "name": "Old", "folder_id": "trash.1000", "in_trash": true, "modified_at": "2025-01-01T00:00:00Z"})
+	f.seed("canvases", map[string]interface{}{"id": "nested", "name": "Nested", "folder_id": "oldf", "in_trash": true, "modified_at": "2025-01-02T00:00:00Z"})
+	f.seed("canvases", map[string]interface{}{"id": "recent", "name": "Recent", "folder_id": "trash.1001", "in_trash": true, "modified_at": "2025-03-30T00:00:00Z"})
+}
+
+func TestTrashServiceTrashAndRestore(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedTrash(fake)
+	ctx := context.Background()
+	trash := NewTrashService(fake.session()) // API-key style session: no logged-in user
+
+	if owner, err := trash.OwnerOfCanvas(ctx, "live"); err != nil || owner != 1001 {
+		t.Fatalf("OwnerOfCanvas = %d, %v", owner, err)
+	}
+	canvas, err := trash.TrashCanvas(ctx, "live")
+	if err != nil {
+		t.Fatalf("TrashCanvas: %v", err)
+	}
+	if canvas.FolderID != "trash.1001" || !canvas.InTrash {
+		t.Errorf("canvas not trashed: %+v", canvas)
+	}
+	if owner, err := trash.OwnerOfFolder(ctx, "oldf"); err != nil || owner != 1000 {
+		t.Errorf("OwnerOfFolder(trashed) = %d, %v", owner, err)
+	}
+
+	contents, err := trash.List(ctx, 1001)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(contents.Canvases) != 2 || contents.Canvases[0].ID != "live" || len(contents.Folders) != 0 {
+		t.Fatalf("unexpected contents: %+v", contents)
+	}
+	if err := trash.Restore(ctx, contents.Canvases[0], "", ConflictSkip); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	restored, _ := fake.session().GetCanvas(ctx, "live")
+	if restored.FolderID != "1001" || restored.InTrash {
+		t.Errorf("canvas not restored to home folder: %+v", restored)
+	}
+
+	if _, err := fake.session().TrashCanvas(ctx, "live", ""); err == nil {
+		t.Error("Session.TrashCanvas without user ID or login should fail")
+	}
+	if c, err := fake.session().TrashCanvas(ctx, "live", "1001"); err != nil || c.FolderID != "trash.1001" {
+		t.Errorf("Session.TrashCanvas with owner = %+v, %v", c, err)
+	}
+}
+
+func TestTrashServiceRetentionAndEmpty(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedTrash(fake)
+	ctx := context.Background()
+	trash := NewTrashService(fake.session())
+	trash.now = func() time.Time { return time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC) }
+
+	if _, err := trash.ApplyRetention(ctx, RetentionPolicy{}); err == nil {
+		t.Fatal("ApplyRetention with no MaxAge should fail")
+	}
+
+	// modified_at is not a trash time: without records every item is kept.
+	policy := RetentionPolicy{MaxAge: 30 * 24 * time.Hour, DryRun: true}
+	report, err := trash.ApplyRetention(ctx, policy)
+	if err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if len(report.Purged) != 0 || len(report.Kept) != 3 {
+		t.Fatalf("unknown trash times should be kept: purged=%+v kept=%+v", report.Purged, report.Kept)
+	}
+
+	trash.SetTrashedAt("oldf", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	if _, err := trash.TrashCanvas(ctx, "live"); err != nil {
+		t.Fatalf("TrashCanvas: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "trash-times.json")
+	if err := trash.SaveTrashTimes(path); err != nil {
+		t.Fatalf("SaveTrashTimes: %v", err)
+	}
+	trash = NewTrashService(fake.session())
+	trash.now = func() time.Time { return time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC) }
+	if err := trash.LoadTrashTimes(path); err != nil {
+		t.Fatalf("LoadTrashTimes: %v", err)
+	}
+	all, err := trash.List(ctx, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all.Folders) != 1 || !all.Folders[0].TrashedAt.Equal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("folder trash time not loaded: %+v", all.Folders)
+	}
+
+	report, err = trash.ApplyRetention(ctx, policy)
+	if err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if len(report.Purged) != 1 || report.Purged[0].ID != "oldf" || len(report.Kept) != 3 {
+		t.Fatalf("unexpected dry-run report: purged=%+v kept=%+v", report.Purged, report.Kept)
+	}
+	if fake.callCount("DELETE") != 0 {
+		t.Fatal("dry run must not delete")
+	}
+
+	policy.DryRun = false
+	policy.PurgeUnknowns = true
+	if _, err := trash.ApplyRetention(ctx, policy); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if fake.callCount("DELETE canvas-folders/oldf") != 1 || fake.callCount("DELETE canvases/old") != 1 || fake.callCount("DELETE canvases/live") != 0 {
+		t.Errorf("expected old and unknown items deleted and the freshly trashed canvas kept, calls: %v", fake.calls)
+	}
+
+	emptied := false
+	fake.handle(http.MethodDelete, "canvas-folders/trash.1001/children", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		emptied = true
+		w.WriteHeader(http.StatusOK)
+	})
+	if err := trash.Empty(ctx, 1001); err != nil || !emptied {
+		t.Errorf("Empty: %v (called=%v)", err, emptied)
+	}
+	if at := trash.trashTime("live"); !at.IsZero() {
+		t.Errorf("trash time of an emptied canvas kept: %v", at)
+	}
+}
+
+func TestTrashServiceListUsesInTrash(t *testing.T) {
+	fake := newFakeCanvus(t)
+	seedTrash(fake)
+	fake.seed("canvases", map[string]interface{}{"id": "flagged", "name": "Flagged", "folder_id": "proj", "in_trash": true})
+	contents, err := NewTrashService(fake.session()).List(context.Background(), 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents.Canvases) != 2 || contents.Canvases[0].ID != "flagged" || contents.Canvases[0].OwnerID != 1001 {
+		t.Errorf("canvases = %+v", contents.Canvases)
+	}
+}
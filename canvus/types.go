@@ -24,8 +24,12 @@ import (
 
 // Filter provides generic, client-side filtering for SDK list/get endpoints.
 // It supports arbitrary JSON criteria, wildcards ("*"), and JSONPath-like selectors ("$").
+// Filters built with ParseFilter also evaluate an expression; see ParseFilter for the syntax.
 type Filter struct {
 	Criteria map[string]interface{} // Arbitrary filter criteria
+
+	expr   filterNode // compiled ParseFilter expression, ANDed with Criteria
+	source string
 }
 
 // Filterable is an interface for types that can be filtered by Filter.
@@ -116,11 +120,12 @@ func (f *Filter) Match(obj map[string]interface{}) bool {
 				continue
 			}
 		}
-		if actual != v {
+		// Numbers compare by value, so a JSON float64 matches an int criterion.
+		if !filterEqual(actual, v) {
 			return false
 		}
 	}
-	return true
+	return f.expr == nil || f.expr.eval(obj)
 }
 
 // Canvas represents a canvas resource in the Canvus system.
@@ -216,6 +221,8 @@ type Widget struct {
 	State       string       `json:"state"`
 	Depth       float64      `json:"depth"`
 	Annotations []Annotation `json:"annotations,omitempty"`
+
+	extra map[string]interface{} // every field of the decoded JSON, for AsMap
 }
 
 // Annotation represents a drawing annotation (stroke) on a widget.
@@ -514,22 +521,16 @@ func (c Canvas) AsMap() map[string]interface{} {
 	}
 }
 
-// AsMap returns the Widget as a map[string]interface{} for filtering.
+// AsMap returns the Widget as a map[string]interface{} for filtering. It includes the
+// annotations and, for widgets decoded from the API, the type-specific fields such as a
+// note's "background_color" or a browser's "url".
 func (w Widget) AsMap() map[string]interface{} {
-	m := map[string]interface{}{
-		"id":          w.ID,
-		"widget_type": w.WidgetType,
-		"parent_id":   w.ParentID,
-		"pinned":      w.Pinned,
-		"scale":       w.Scale,
-		"state":       w.State,
-		"depth":       w.Depth,
-	}
-	if w.Location != nil {
-		m["location"] = map[string]interface{}{"x": w.Location.X, "y": w.Location.Y}
+	m := make(map[string]interface{}, len(w.extra)+10)
+	for k, v := range w.extra {
+		m[k] = v
 	}
-	if w.Size != nil {
-		m["size"] = map[string]interface{}{"width": w.Size.Width, "height": w.Size.Height}
+	for k, v := range jsonFields(w) {
+		m[k] = v
 	}
 	return m
 }
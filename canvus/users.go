@@ -42,13 +42,16 @@ type UpdateUserRequest struct {
 	// Add other fields as needed
 }
 
-// ListUsers retrieves all users from the Canvus API.
-func (s *Session) ListUsers(ctx context.Context) ([]User, error) {
+// ListUsers retrieves all users from the Canvus API. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListUsers(ctx context.Context, filter *Filter) ([]User, error) {
 	var users []User
 	err := s.doRequest(ctx, "GET", "users", nil, &users, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListUsers: %w", err)
 	}
+	if filter != nil {
+		users = FilterSlice(users, filter)
+	}
 	return users, nil
 }
 
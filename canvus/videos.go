@@ -5,14 +5,17 @@ import (
 	"fmt"
 )
 
-// ListVideos retrieves all videos for a given canvas.
-func (s *Session) ListVideos(ctx context.Context, canvasID string) ([]Video, error) {
+// ListVideos retrieves all videos for a given canvas. If filter is non-nil, results are filtered client-side.
+func (s *Session) ListVideos(ctx context.Context, canvasID string, filter *Filter) ([]Video, error) {
 	var videos []Video
 	path := fmt.Sprintf("canvases/%s/videos", canvasID)
 	err := s.doRequest(ctx, "GET", path, nil, &videos, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListVideos: %w", err)
 	}
+	if filter != nil {
+		videos = FilterSlice(videos, filter)
+	}
 	return videos, nil
 }
 
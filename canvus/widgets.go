@@ -295,6 +295,8 @@ type WidgetsLister interface {
 // FindWidgetsAcrossCanvases searches all canvases for widgets matching the given query.
 // The query supports exact, wildcard, and partial string matches (see Filter abstraction).
 // Returns a slice of WidgetMatch with CanvasID, WidgetID, and the Widget itself.
+// It stops at the first canvas error; SearchWidgetsAcrossCanvases searches concurrently and reports
+// per-canvas errors instead.
 func FindWidgetsAcrossCanvases(ctx context.Context, lister WidgetsLister, query map[string]interface{}) ([]WidgetMatch, error) {
 	canvases, err := lister.ListCanvases(ctx, nil)
 	if err != nil {
@@ -327,7 +329,9 @@ type WidgetZone struct {
 }
 
 // WidgetsContainId returns a WidgetZone: the source widget as Container, and all widgets fully contained within it as Contents.
+// Containment is tested on canvas-space rectangles resolved through each widget's parent chain and scale.
 // For all returned widgets, if ParentID matches the SharedCanvas ID, it is set to "".
+// It lists the canvas on every call; for repeated queries build a SpatialIndex and use its Zone method.
 func WidgetsContainId(ctx context.Context, s *Session, canvasID string, widgetID string, widget *Widget, tolerance float64) (WidgetZone, error) {
 	var srcWidget Widget
 	if widget != nil {
@@ -358,7 +362,8 @@ func WidgetsContainId(ctx context.Context, s *Session, canvasID string, widgetID
 		}
 	}
 
-	srcRect := WidgetBoundingBox(srcWidget)
+	geom := NewCanvasGeometry(widgets)
+	srcRect := geom.CanvasRect(srcWidget)
 	// Expand bounding box by tolerance
 	srcRect.X -= tolerance
 	srcRect.Y -= tolerance
@@ -373,7 +378,7 @@ func WidgetsContainId(ctx context.Context, s *Session, canvasID string, widgetID
 		if w.WidgetType == "SharedCanvas" {
 			continue // skip SharedCanvas in results
 		}
-		if Contains(srcRect, WidgetBoundingBox(w)) {
+		if Contains(srcRect, geom.CanvasRect(w)) {
 			// Normalize ParentID if it matches SharedCanvas
 			if sharedCanvasID != "" && w.ParentID == sharedCanvasID {
 				w.ParentID = ""
@@ -22,7 +22,7 @@ func (m *mockSession) ListCanvases(ctx context.Context, filter *Filter) ([]Canva
 	return m.canvases, nil
 }
 
-func (m *mockSession) ListWidgets(ctx context.Context, canvasID string, filter *Filter) ([]Widget, error) {
+func (m *mockSession) ListWidgets(ctx context.Context, canvasID string, filter *Filter, includeAnnotations ...bool) ([]Widget, error) {
 	if m.failListWidgets != nil && m.failListWidgets[canvasID] {
 		return nil, errors.New("mock ListWidgets failure")
 	}
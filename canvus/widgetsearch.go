@@ -0,0 +1,151 @@
+package canvus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CrossCanvasSearchOptions controls SearchWidgetsAcrossCanvases. Zero values select the
+// defaults.
+type CrossCanvasSearchOptions struct {
+	MaxConcurrency int    // canvases searched at once; default 4
+	MaxMatches     int    // stop after this many matches; 0 returns all
+	FolderID       string // only canvases directly in this folder
+	// NamePattern only searches canvases whose name matches, with Filter wildcards
+	// ("Sprint*", "*retro*").
+	NamePattern    string
+	IncludeTrashed bool    // canvases in the trash are skipped unless set
+	CanvasFilter   *Filter // further criteria on Canvas.AsMap
+}
+
+func (o CrossCanvasSearchOptions) selects(c Canvas) bool {
+	if c.InTrash && !o.IncludeTrashed {
+		return false
+	}
+	if o.FolderID != "" && c.FolderID != o.FolderID {
+		return false
+	}
+	if o.NamePattern != "" && !(&Filter{Criteria: map[string]interface{}{"name": o.NamePattern}}).Match(c.AsMap()) {
+		return false
+	}
+	return o.CanvasFilter == nil || o.CanvasFilter.Match(c.AsMap())
+}
+
+// WidgetSearchResult is one item streamed by SearchWidgetsAcrossCanvases: a match, or an error
+// for a canvas that could not be searched (CanvasID names it; it is empty if listing the
+// canvases failed).
+type WidgetSearchResult struct {
+	WidgetMatch
+	Err error
+}
+
+// SearchWidgetsAcrossCanvases is a concurrent, streaming FindWidgetsAcrossCanvases. Canvases
+// selected by opts are searched by up to MaxConcurrency workers and matches are sent as they
+// are found, in no particular order. A canvas that cannot be read yields an error result and
+// the search goes on. The channel is closed when the search finishes, MaxMatches is reached
+// or ctx is cancelled; cancel ctx to stop reading early.
+//
+// Usage Example:
+//
+//	results := canvus.SearchWidgetsAcrossCanvases(ctx, session, map[string]interface{}{"widget_type": "Browser"},
+//		canvus.CrossCanvasSearchOptions{NamePattern: "Sprint*", MaxMatches: 50})
+//	for r := range results {
+//		if r.Err != nil {
+//			log.Printf("skipped canvas %s: %v", r.CanvasID, r.Err)
+//			continue
+//		}
+//		fmt.Println(r.CanvasID, r.WidgetID)
+//	}
+func SearchWidgetsAcrossCanvases(ctx context.Context, lister WidgetsLister, query map[string]interface{}, opts CrossCanvasSearchOptions) <-chan WidgetSearchResult {
+	out := make(chan WidgetSearchResult)
+	go func() {
+		defer close(out)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		send := func(r WidgetSearchResult) bool {
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		canvases, err := lister.ListCanvases(ctx, nil)
+		if err != nil {
+			send(WidgetSearchResult{Err: fmt.Errorf("SearchWidgetsAcrossCanvases: failed to list canvases: %w", err)})
+			return
+		}
+		workers := opts.MaxConcurrency
+		if workers <= 0 {
+			workers = 4
+		}
+		filter := &Filter{Criteria: query}
+		jobs := make(chan Canvas)
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			found     int
+			delivered int
+		)
+		// reserve claims a match slot, so that no more than MaxMatches are sent.
+		reserve := func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if opts.MaxMatches > 0 && found >= opts.MaxMatches {
+				return false
+			}
+			found++
+			return true
+		}
+		// deliver counts a sent match and stops the search once the last reserved slot has
+		// been delivered; cancelling any earlier would drop matches still being sent.
+		deliver := func() {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered++
+			if opts.MaxMatches > 0 && delivered == opts.MaxMatches {
+				cancel()
+			}
+		}
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c := range jobs {
+					widgets, err := lister.ListWidgets(ctx, c.ID, filter)
+					if err != nil {
+						if ctx.Err() == nil {
+							send(WidgetSearchResult{
+								WidgetMatch: WidgetMatch{CanvasID: c.ID},
+								Err:         fmt.Errorf("SearchWidgetsAcrossCanvases: failed to list widgets for canvas %s: %w", c.ID, err),
+							})
+						}
+						continue
+					}
+					for _, w := range widgets {
+						if !reserve() || !send(WidgetSearchResult{WidgetMatch: WidgetMatch{CanvasID: c.ID, WidgetID: w.ID, Widget: w}}) {
+							break
+						}
+						deliver()
+					}
+				}
+			}()
+		}
+	feed:
+		for _, c := range canvases {
+			if !opts.selects(c) {
+				continue
+			}
+			select {
+			case jobs <- c:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+	}()
+	return out
+}
@@ -0,0 +1,70 @@
+package canvus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestSearchWidgetsAcrossCanvases(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockSession{
+		canvases: []Canvas{
+			{ID: "c1", Name: "Sprint 1", FolderID: "f1"},
+			{ID: "c2", Name: "Sprint 2", FolderID: "f1"},
+			{ID: "c3", Name: "Sprint 3", FolderID: "f1", InTrash: true},
+			{ID: "c4", Name: "Roadmap", FolderID: "f2"},
+		},
+		widgets:         map[string][]Widget{},
+		failListWidgets: map[string]bool{"c2": true},
+	}
+	for _, c := range ms.canvases {
+		for i := 0; i < 5; i++ {
+			ms.widgets[c.ID] = append(ms.widgets[c.ID], Widget{ID: fmt.Sprintf("%s-w%d", c.ID, i), WidgetType: "Note"})
+		}
+	}
+	collect := func(opts CrossCanvasSearchOptions) (ids []string, errCanvases []string) {
+		for r := range SearchWidgetsAcrossCanvases(ctx, ms, map[string]interface{}{"widget_type": "Note"}, opts) {
+			if r.Err != nil {
+				errCanvases = append(errCanvases, r.CanvasID)
+				continue
+			}
+			ids = append(ids, r.WidgetID)
+		}
+		sort.Strings(ids)
+		return ids, errCanvases
+	}
+
+	ids, errs := collect(CrossCanvasSearchOptions{MaxConcurrency: 2})
+	if len(ids) != 10 || len(errs) != 1 || errs[0] != "c2" {
+		t.Errorf("all canvases: %d matches, errors %v", len(ids), errs)
+	}
+	for _, id := range ids {
+		if id[:2] == "c3" {
+			t.Errorf("searched a trashed canvas: %s", id)
+		}
+	}
+
+	ids, errs = collect(CrossCanvasSearchOptions{NamePattern: "Sprint*", IncludeTrashed: true})
+	if len(ids) != 10 || len(errs) != 1 || ids[0] != "c1-w0" || ids[9] != "c3-w4" {
+		t.Errorf("name pattern: %v, errors %v", ids, errs)
+	}
+
+	ids, _ = collect(CrossCanvasSearchOptions{FolderID: "f2"})
+	if len(ids) != 5 || ids[0] != "c4-w0" {
+		t.Errorf("folder: %v", ids)
+	}
+
+	ids, _ = collect(CrossCanvasSearchOptions{MaxMatches: 3, MaxConcurrency: 3})
+	if len(ids) != 3 {
+		t.Errorf("MaxMatches: %v", ids)
+	}
+
+	ms.failListCanvases = true
+	_, errs = collect(CrossCanvasSearchOptions{})
+	ms.failListCanvases = false
+	if len(errs) != 1 || errs[0] != "" {
+		t.Errorf("ListCanvases failure: %v", errs)
+	}
+}
@@ -17,7 +17,7 @@ func (c *Session) resolveWorkspaceIndex(ctx context.Context, clientID string, se
 	if selector.Index != nil {
 		return *selector.Index, nil
 	}
-	workspaces, err := c.ListWorkspaces(ctx, clientID)
+	workspaces, err := c.ListWorkspaces(ctx, clientID, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -41,14 +41,17 @@ func (c *Session) resolveWorkspaceIndex(ctx context.Context, clientID string, se
 	return 0, nil
 }
 
-// ListWorkspaces retrieves all workspaces for a client.
-func (c *Session) ListWorkspaces(ctx context.Context, clientID string) ([]Workspace, error) {
+// ListWorkspaces retrieves all workspaces for a client. If filter is non-nil, results are filtered client-side.
+func (c *Session) ListWorkspaces(ctx context.Context, clientID string, filter *Filter) ([]Workspace, error) {
 	var workspaces []Workspace
 	endpoint := fmt.Sprintf("clients/%s/workspaces", clientID)
 	err := c.doRequest(ctx, "GET", endpoint, nil, &workspaces, nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("ListWorkspaces: %w", err)
 	}
+	if filter != nil {
+		workspaces = FilterSlice(workspaces, filter)
+	}
 	return workspaces, nil
 }
 
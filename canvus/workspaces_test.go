@@ -22,14 +22,14 @@ func TestWorkspaceLifecycle(t *testing.T) {
 	session := uc.Session
 
 	// List clients and pick the first one (or skip if none)
-	clients, err := session.ListClients(ctx)
+	clients, err := session.ListClients(ctx, nil)
 	if err != nil || len(clients) == 0 {
 		t.Skip("No clients available for workspace tests")
 	}
 	clientID := clients[0].ID
 
 	t.Run("ListWorkspaces", func(t *testing.T) {
-		workspaces, err := session.ListWorkspaces(ctx, clientID)
+		workspaces, err := session.ListWorkspaces(ctx, clientID, nil)
 		if err != nil {
 			t.Fatalf("ListWorkspaces failed: %v", err)
 		}
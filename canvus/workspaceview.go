@@ -0,0 +1,164 @@
+package canvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// WorkspaceView maps between screen pixels of a client workspace and canvas coordinates.
+// Screen points are relative to the workspace's top-left corner; subtract Workspace.Location
+// from display coordinates first. The ViewRectangle is fitted into the workspace keeping its
+// aspect ratio and centred, so when the aspects differ more canvas is visible along one axis.
+//
+// Usage Example:
+//
+//	view, _ := session.GetWorkspaceView(ctx, clientID, canvus.WorkspaceSelector{})
+//	p := view.ToCanvas(canvus.Point{X: 960, Y: 540})
+type WorkspaceView struct {
+	Workspace Workspace
+	// Transform maps screen pixels (local) to canvas coordinates.
+	Transform Transform
+}
+
+// NewWorkspaceView builds the screen/canvas mapping of a workspace. It fails if the workspace
+// has no ViewRectangle or Size, e.g. because no canvas is open.
+func NewWorkspaceView(ws Workspace) (*WorkspaceView, error) {
+	if ws.ViewRectangle == nil || ws.Size == nil ||
+		ws.ViewRectangle.Width <= 0 || ws.ViewRectangle.Height <= 0 || ws.Size.Width <= 0 || ws.Size.Height <= 0 {
+		return nil, fmt.Errorf("NewWorkspaceView: workspace %d has no view rectangle or size", ws.Index)
+	}
+	vr := *ws.ViewRectangle
+	// Pixels per canvas unit, fitting the whole view rectangle on screen.
+	fit := math.Min(ws.Size.Width/vr.Width, ws.Size.Height/vr.Height)
+	offset := Point{
+		X: vr.X + vr.Width/2 - ws.Size.Width/2/fit,
+		Y: vr.Y + vr.Height/2 - ws.Size.Height/2/fit,
+	}
+	return &WorkspaceView{Workspace: ws, Transform: Transform{Offset: offset, Scale: 1 / fit}}, nil
+}
+
+// ToCanvas converts a workspace pixel to canvas coordinates.
+func (v *WorkspaceView) ToCanvas(p Point) Point {
+	return v.Transform.ToCanvas(p)
+}
+
+// ToScreen converts a canvas point to workspace pixels. The result may lie off screen.
+func (v *WorkspaceView) ToScreen(p Point) Point {
+	return v.Transform.ToLocal(p)
+}
+
+// RectToCanvas converts a rectangle in workspace pixels to canvas coordinates.
+func (v *WorkspaceView) RectToCanvas(r Rectangle) Rectangle {
+	return v.Transform.RectToCanvas(r)
+}
+
+// RectToScreen converts a canvas rectangle to workspace pixels.
+func (v *WorkspaceView) RectToScreen(r Rectangle) Rectangle {
+	return v.Transform.RectToLocal(r)
+}
+
+// Visible returns the canvas area shown by the whole workspace.
+func (v *WorkspaceView) Visible() Rectangle {
+	return v.RectToCanvas(Rectangle{Width: v.Workspace.Size.Width, Height: v.Workspace.Size.Height})
+}
+
+// Center returns the canvas point at the centre of the workspace.
+func (v *WorkspaceView) Center() Point {
+	return v.ToCanvas(Point{X: v.Workspace.Size.Width / 2, Y: v.Workspace.Size.Height / 2})
+}
+
+// GetWorkspaceView fetches a workspace with GetWorkspace and returns its screen/canvas mapping.
+func (s *Session) GetWorkspaceView(ctx context.Context, clientID string, selector WorkspaceSelector) (*WorkspaceView, error) {
+	ws, err := s.GetWorkspace(ctx, clientID, selector)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkspaceView: %w", err)
+	}
+	v, err := NewWorkspaceView(*ws)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkspaceView: %w", err)
+	}
+	return v, nil
+}
+
+// VisibleWidgets returns the widgets of the canvas open on a workspace that are at least partly
+// on screen, topmost first. Positions are resolved through the parent chain; the SharedCanvas
+// is left out.
+//
+// Usage Example:
+//
+//	widgets, view, err := session.VisibleWidgets(ctx, clientID, canvus.WorkspaceSelector{User: &email})
+func (s *Session) VisibleWidgets(ctx context.Context, clientID string, selector WorkspaceSelector) ([]Widget, *WorkspaceView, error) {
+	view, err := s.GetWorkspaceView(ctx, clientID, selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("VisibleWidgets: %w", err)
+	}
+	if view.Workspace.CanvasID == "" {
+		return nil, nil, fmt.Errorf("VisibleWidgets: workspace %d has no open canvas", view.Workspace.Index)
+	}
+	widgets, err := s.ListWidgets(ctx, view.Workspace.CanvasID, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("VisibleWidgets: %w", err)
+	}
+	var visible []Widget
+	for _, w := range NewSpatialIndex(widgets).Intersecting(view.Visible()) {
+		if w.WidgetType != "SharedCanvas" {
+			visible = append(visible, w)
+		}
+	}
+	return visible, view, nil
+}
+
+// CreateWidgetOnScreen creates a widget on the canvas open on a workspace, centred on the
+// workspace pixel at (nil means the centre of the workspace). req is a CreateWidget request map;
+// its "location" is set here and its "size", if any, is used to centre the widget. If req has a
+// "parent_id", location and size are in that parent's local space.
+//
+// Usage Example:
+//
+//	note, err := session.CreateWidgetOnScreen(ctx, clientID, canvus.WorkspaceSelector{}, map[string]interface{}{
+//		"widget_type": "note",
+//		"text":        "Look here",
+//		"size":        map[string]interface{}{"width": 300, "height": 300},
+//	}, nil)
+func (s *Session) CreateWidgetOnScreen(ctx context.Context, clientID string, selector WorkspaceSelector, req map[string]interface{}, at *Point) (*Widget, error) {
+	view, err := s.GetWorkspaceView(ctx, clientID, selector)
+	if err != nil {
+		return nil, fmt.Errorf("CreateWidgetOnScreen: %w", err)
+	}
+	canvasID := view.Workspace.CanvasID
+	if canvasID == "" {
+		return nil, fmt.Errorf("CreateWidgetOnScreen: workspace %d has no open canvas", view.Workspace.Index)
+	}
+	center := view.Center()
+	if at != nil {
+		center = view.ToCanvas(*at)
+	}
+
+	t := IdentityTransform()
+	if parentID, _ := req["parent_id"].(string); parentID != "" {
+		widgets, err := s.ListWidgets(ctx, canvasID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("CreateWidgetOnScreen: %w", err)
+		}
+		t = NewCanvasGeometry(widgets).Transform(parentID)
+	}
+	loc := t.ToLocal(center)
+	var size Size
+	if raw, err := json.Marshal(req["size"]); err == nil && json.Unmarshal(raw, &size) == nil {
+		loc.X -= size.Width / 2
+		loc.Y -= size.Height / 2
+	}
+
+	create := make(map[string]interface{}, len(req)+1)
+	for k, v := range req {
+		create[k] = v
+	}
+	create["location"] = map[string]interface{}{"x": loc.X, "y": loc.Y}
+	w, err := s.CreateWidget(ctx, canvasID, create)
+	if err != nil {
+		return nil, fmt.Errorf("CreateWidgetOnScreen: %w", err)
+	}
+	return w, nil
+}
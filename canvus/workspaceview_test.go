@@ -0,0 +1,85 @@
+package canvus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWorkspaceViewMapping(t *testing.T) {
+	// A 1000x500 canvas view shown on a 2000x1200 px workspace: 2 px per unit, 100 px of
+	// extra height split above and below.
+	v, err := NewWorkspaceView(Workspace{
+		ViewRectangle: &Rectangle{X: 100, Y: 200, Width: 1000, Height: 500},
+		Size:          &Size{Width: 2000, Height: 1200},
+	})
+	if err != nil {
+		t.Fatalf("NewWorkspaceView: %v", err)
+	}
+	if p := v.ToCanvas(Point{X: 0, Y: 100}); p != (Point{X: 100, Y: 200}) {
+		t.Errorf("ToCanvas = %+v", p)
+	}
+	if p := v.ToScreen(Point{X: 600, Y: 450}); p != (Point{X: 1000, Y: 600}) {
+		t.Errorf("ToScreen = %+v", p)
+	}
+	if r := v.Visible(); r != (Rectangle{X: 100, Y: 150, Width: 1000, Height: 600}) {
+		t.Errorf("Visible = %+v", r)
+	}
+	if _, err := NewWorkspaceView(Workspace{}); err == nil {
+		t.Error("expected error without a view rectangle")
+	}
+}
+
+func TestVisibleWidgetsAndCreateOnScreen(t *testing.T) {
+	fake := newFakeCanvus(t)
+	fake.handle("GET", "clients/cl1/workspaces/0", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+			"canvas_id":      "c1",
+			"index":          0,
+			"size":           map[string]interface{}{"width": 1000, "height": 1000},
+			"view_rectangle": map[string]interface{}{"x": 0, "y": 0, "width": 500, "height": 500},
+		})
+	})
+	fake.handle("GET", "canvases/c1/widgets", func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		writeFakeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": "sc", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0, "y": 0}, "size": map[string]interface{}{"width": 5000, "height": 5000}},
+			{"id": "on", "widget_type": "Note", "parent_id": "sc", "location": map[string]interface{}{"x": 450, "y": 450}, "size": map[string]interface{}{"width": 100, "height": 100}},
+			{"id": "off", "widget_type": "Note", "parent_id": "sc", "location": map[string]interface{}{"x": 600, "y": 0}, "size": map[string]interface{}{"width": 100, "height": 100}},
+			{"id": "frame", "widget_type": "Note", "parent_id": "sc", "location": map[string]interface{}{"x": 100, "y": 100}, "size": map[string]interface{}{"width": 100, "height": 100}, "scale": 2},
+		})
+	})
+	s := fake.session()
+	ctx := context.Background()
+
+	visible, _, err := s.VisibleWidgets(ctx, "cl1", WorkspaceSelector{})
+	if err != nil {
+		t.Fatalf("VisibleWidgets: %v", err)
+	}
+	if got := idsOf(visible); len(got) != 2 || got[0] != "frame" || got[1] != "on" {
+		t.Errorf("VisibleWidgets = %v", got)
+	}
+
+	note, err := s.CreateWidgetOnScreen(ctx, "cl1", WorkspaceSelector{}, map[string]interface{}{
+		"widget_type": "note",
+		"size":        map[string]interface{}{"width": 50, "height": 50},
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateWidgetOnScreen: %v", err)
+	}
+	if note.Location == nil || *note.Location != (Point{X: 225, Y: 225}) {
+		t.Errorf("centred note at %+v", note.Location)
+	}
+
+	// Screen (400,400) is canvas (200,200): local (50,50) inside the 2x frame, minus half the size.
+	child, err := s.CreateWidgetOnScreen(ctx, "cl1", WorkspaceSelector{}, map[string]interface{}{
+		"widget_type": "note",
+		"parent_id":   "frame",
+		"size":        map[string]interface{}{"width": 20, "height": 20},
+	}, &Point{X: 400, Y: 400})
+	if err != nil {
+		t.Fatalf("CreateWidgetOnScreen with parent: %v", err)
+	}
+	if child.Location == nil || *child.Location != (Point{X: 40, Y: 40}) {
+		t.Errorf("child note at %+v", child.Location)
+	}
+}
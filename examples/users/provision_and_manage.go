@@ -54,7 +54,7 @@ func main() {
 	// Step 3: List existing users to see current state
 	fmt.Println("Listing existing users...")
 
-	users, err := session.ListUsers(ctx)
+	users, err := session.ListUsers(ctx, nil)
 	if err != nil {
 		// Check for permission errors
 		if apiErr, ok := err.(*canvus.APIError); ok {
@@ -177,7 +177,7 @@ func main() {
 	// Step 8: List all access tokens for the user
 	fmt.Println("Listing user's access tokens...")
 
-	tokens, err := session.ListAccessTokens(ctx, newUser.ID)
+	tokens, err := session.ListAccessTokens(ctx, newUser.ID, nil)
 	if err != nil {
 		log.Fatalf("Error listing access tokens: %v", err)
 	}
@@ -212,7 +212,7 @@ func main() {
 	fmt.Printf("Access token %s deleted successfully\n", accessToken.ID)
 
 	// Verify deletion
-	remainingTokens, err := session.ListAccessTokens(ctx, newUser.ID)
+	remainingTokens, err := session.ListAccessTokens(ctx, newUser.ID, nil)
 	if err != nil {
 		log.Fatalf("Error listing tokens after deletion: %v", err)
 	}